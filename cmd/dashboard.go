@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/reporter"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// startDashboard resolves --dashboard into a listening *reporter.Hub plus
+// its HTTP server: empty addr means the dashboard is disabled (nil hub, a
+// no-op closer) so callers can wire it unconditionally, same as
+// openStreamWriter. The server runs until the returned closer shuts it
+// down.
+func startDashboard(addr string, logger *utils.Logger) (*reporter.Hub, func(), error) {
+	if addr == "" {
+		return nil, func() {}, nil
+	}
+
+	hub := reporter.NewHub()
+	mux := http.NewServeMux()
+	reporter.ServeDashboard(mux, hub)
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Dashboard server failed: %v", err)
+		}
+	}()
+	logger.Info("Live dashboard at http://%s", addr)
+
+	return hub, func() { srv.Shutdown(context.Background()) }, nil
+}
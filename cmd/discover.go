@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/scanner"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// NewCLIDiscover builds the `discover` subcommand: authenticate and run
+// endpoint discovery only, skipping every test module. Useful for quickly
+// checking what a crawl/wayback/wordlist pass would feed into a full scan,
+// or for seeding a `scan --import-har`/`--import-openapi` run.
+func NewCLIDiscover(f *flags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "discover",
+		Short: "Authenticate and discover endpoints without running test modules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiscover(f)
+		},
+	}
+}
+
+func runDiscover(f *flags) error {
+	logger := f.newLogger()
+	logger.Banner("Web Payment Scanner v" + version)
+
+	if !utils.IsValidURL(f.targetURL) {
+		return fmt.Errorf("invalid target URL")
+	}
+
+	config := *f.scanConfig()
+
+	ctx := context.Background()
+	sess, br, _, err := establishSession(ctx, f, config, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if br != nil {
+			br.Close()
+		}
+	}()
+
+	engine := scanner.NewEngine(config, sess, br)
+
+	stream, closeStream, err := openStreamWriter(f.streamPath)
+	if err != nil {
+		return fmt.Errorf("failed to open --stream target: %w", err)
+	}
+	defer closeStream()
+	if stream != nil {
+		engine.SetStreamWriter(stream)
+	}
+
+	if err := engine.StartDiscovery(); err != nil {
+		return fmt.Errorf("discovery failed: %w", err)
+	}
+	engine.Close()
+
+	result := engine.GetResults()
+	logger.Success("Discovered %d endpoints", len(result.Endpoints))
+
+	if err := os.MkdirAll(f.outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output dir: %w", err)
+	}
+	filename := filepath.Join(f.outputDir, fmt.Sprintf("endpoints_%s.json", time.Now().Format("20060102_150405")))
+	data, err := json.MarshalIndent(result.Endpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return err
+	}
+	logger.Success("Endpoints written to %s", filename)
+
+	return nil
+}
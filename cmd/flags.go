@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/spf13/pflag"
+)
+
+// flags holds every flag value the scan/discover/ws subcommands read. It's
+// built once in Execute and the same *flags is handed to every subcommand
+// constructor, which calls its scanConfig method when it runs, so none of
+// them need package-level mutable globals the way the old single-command
+// CLI did.
+type flags struct {
+	verbose, headless                                          bool
+	browserType, loginURL, targetURL, outputDir, wordlistPath  string
+	wordlistSources                                            []string
+	harImportPath, openAPIImportPath, harRecordPath            string
+	sessionPassphrase                                          string
+	clientCertPath, clientKeyPath, caBundlePath                string
+	tlsInsecure                                                bool
+	jwtSecretsWordlist, jwtJWKSURI                             string
+	jwtMaxCrackSeconds                                         int
+	jwtRedactSecrets                                           bool
+	priceFieldPattern                                          string
+	timeout, maxDepth                                          int
+	enableCrawl, enableWayback, enableCommonPaths              bool
+	enableCommonCrawl, enableURLScan, enableOTX, enableCrtSh   bool
+	urlscanAPIKey, otxAPIKey                                   string
+	passiveSourceRPS                                           int
+	passiveCacheDir                                            string
+	enableJSAnalysis                                           bool
+	enableSignalR                                              bool
+	raceMode                                                   string
+	enableRace, enablePrice, enableIDOR                        bool
+	enableOTP, enableCallback, enableAmount, enableIdempotency bool
+	enableWSInterceptor                                        bool
+	enableWSActive                                             bool
+	skipSessionCache                                           bool
+	sessionStoreKind                                           string
+	authProvider, authConfigPath                               string
+	rateRPS, rateMin, rateMax                                  int
+	wafAdaptive                                                bool
+	streamPath                                                 string
+	dashboardAddr                                              string
+	reportFormats                                              []string
+	logFormat                                                  string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// register binds every flag the scan command (and, by inheritance through
+// cobra's persistent flags, its sibling subcommands) understands onto fs.
+func (f *flags) register(fs *pflag.FlagSet) {
+	fs.BoolVarP(&f.verbose, "verbose", "v", false, "Verbose output")
+	fs.BoolVar(&f.headless, "headless", false, "Headless browser")
+	fs.StringVarP(&f.browserType, "browser", "b", "firefox", "Browser type")
+	fs.IntVarP(&f.timeout, "timeout", "t", 300, "Login timeout (seconds)")
+
+	fs.StringVarP(&f.targetURL, "target", "u", "", "Target URL (required)")
+	fs.StringVarP(&f.loginURL, "login", "l", "", "Login URL")
+	fs.StringVarP(&f.outputDir, "output", "o", "reports", "Output directory")
+	fs.StringVarP(&f.wordlistPath, "wordlist", "w", "configs/wordlists/payment_paths.txt", "Path to wordlist file")
+	fs.StringSliceVar(&f.wordlistSources, "wordlist-sources", nil, "Extra wordlist sources to merge in for path brute-force (local files or http(s) URLs), comma-separated")
+	fs.IntVarP(&f.maxDepth, "depth", "d", 3, "Max crawl depth")
+
+	fs.BoolVar(&f.enableCrawl, "crawl", true, "Enable crawler")
+	fs.BoolVar(&f.enableWayback, "wayback", true, "Enable Wayback")
+	fs.BoolVar(&f.enableCommonCrawl, "commoncrawl", false, "Enable CommonCrawl passive discovery")
+	fs.BoolVar(&f.enableURLScan, "urlscan", false, "Enable URLScan.io passive discovery")
+	fs.StringVar(&f.urlscanAPIKey, "urlscan-api-key", "", "API key for --urlscan (optional, raises the rate limit/result cap)")
+	fs.BoolVar(&f.enableOTX, "otx", false, "Enable AlienVault OTX passive discovery")
+	fs.StringVar(&f.otxAPIKey, "otx-api-key", "", "API key for --otx (optional, raises the rate limit)")
+	fs.BoolVar(&f.enableCrtSh, "crtsh", false, "Enable crt.sh subdomain discovery, chained into the other passive sources")
+	fs.IntVar(&f.passiveSourceRPS, "passive-rate", 1, "Requests/sec per passive discovery source")
+	fs.StringVar(&f.passiveCacheDir, "passive-cache-dir", ".scanner-cache/passive", "Directory to cache passive discovery responses in")
+	fs.BoolVar(&f.enableCommonPaths, "common-paths", true, "Enable path brute-force")
+	fs.BoolVar(&f.enableJSAnalysis, "js-analysis", true, "Enable JS analysis")
+	fs.BoolVar(&f.enableSignalR, "signalr", true, "Enable SignalR hub discovery (negotiate probing)")
+	fs.StringVar(&f.harImportPath, "import-har", "", "Seed endpoints from a saved HAR file")
+	fs.StringVar(&f.openAPIImportPath, "import-openapi", "", "Seed endpoints from an OpenAPI 3 spec")
+	fs.StringVar(&f.harRecordPath, "record-har", "", "Record the authenticated session to a HAR file")
+	fs.StringVar(&f.sessionPassphrase, "session-passphrase", "", "Passphrase used to encrypt/decrypt cached session files (or set SCANNER_SESSION_KEY)")
+
+	fs.StringVar(&f.clientCertPath, "client-cert", "", "Client certificate for mTLS-protected targets (PEM, or PKCS#12 with a .p12/.pfx extension)")
+	fs.StringVar(&f.clientKeyPath, "client-key", "", "Client private key for --client-cert (PEM only; PKCS#12 bundles carry their own key)")
+	fs.StringVar(&f.caBundlePath, "ca-bundle", "", "Verify the target's certificate against this CA bundle instead of the system pool")
+	fs.BoolVar(&f.tlsInsecure, "tls-insecure", true, "Skip TLS server certificate verification (default, for self-signed test targets)")
+
+	fs.StringVar(&f.jwtSecretsWordlist, "jwt-wordlist", "", "Extra leaked-JWT-secret wordlist merged alongside the embedded default list for HMAC weak-secret cracking")
+	fs.IntVar(&f.jwtMaxCrackSeconds, "jwt-crack-timeout", 10, "Max seconds testJWTWeakSecret spends brute-forcing a single token")
+	fs.StringVar(&f.jwtJWKSURI, "jwt-jwks-uri", "", "JWKS endpoint for the RS256->HS256 algorithm-confusion test (default: discover via /.well-known/openid-configuration)")
+	fs.BoolVar(&f.jwtRedactSecrets, "jwt-redact-secrets", false, "Mask cracked JWT secrets in report output down to their first/last two characters")
+
+	fs.StringVar(&f.priceFieldPattern, "price-field-pattern", "", "Regex of request-body field/tag names TestPriceManipulation mutates (default: amount|price|total|cost|subtotal|tax|shipping|quantity|discount)")
+
+	fs.BoolVar(&f.enableRace, "race", true, "Enable Race Condition")
+	fs.StringVar(&f.raceMode, "race-mode", "barrier", "Race condition burst strategy: barrier|single-packet|both")
+	fs.BoolVar(&f.enablePrice, "price", true, "Enable Price Manipulation")
+	fs.BoolVar(&f.enableIDOR, "idor", true, "Enable IDOR")
+	fs.BoolVar(&f.enableOTP, "otp", true, "Enable OTP Security")
+	fs.BoolVar(&f.enableCallback, "callback", true, "Enable Callback Auth")
+	fs.BoolVar(&f.enableAmount, "amount", true, "Enable Amount Validation")
+	fs.BoolVar(&f.enableIdempotency, "idempotency", true, "Enable Idempotency")
+
+	fs.BoolVar(&f.enableWSInterceptor, "ws-intercept", true, "Enable WebSocket interceptor")
+	fs.BoolVar(&f.enableWSActive, "ws-active", true, "Enable active WebSocket attack probes (missing-auth/CSWSH/IDOR) via a direct connection")
+	fs.BoolVar(&f.skipSessionCache, "no-cache", false, "Skip session cache")
+	fs.StringVar(&f.sessionStoreKind, "session-store", "file", "Session store backend (file|memory)")
+
+	fs.StringVar(&f.authProvider, "auth-provider", "", "Bootstrap the session from an OAuth2/OIDC login instead of the browser flow (oidc|github|bitbucket|keycloak|password|client-credentials); requires --auth-config")
+	fs.StringVar(&f.authConfigPath, "auth-config", "", "Path to the auth.Config YAML file for --auth-provider")
+
+	fs.IntVar(&f.rateRPS, "rate", 10, "Starting requests/sec for scanner HTTP traffic")
+	fs.IntVar(&f.rateMin, "rate-min", 1, "Minimum requests/sec the adaptive limiter may back off to")
+	fs.IntVar(&f.rateMax, "rate-max", 20, "Maximum requests/sec the adaptive limiter may ramp up to")
+	fs.BoolVar(&f.wafAdaptive, "waf-adaptive", true, "Floor the rate limiter based on fingerprinted WAF")
+
+	fs.StringVar(&f.streamPath, "stream", "", "Stream NDJSON events (endpoints/vulnerabilities/summary) to stdout (-) or a file path as they occur")
+	fs.StringVar(&f.dashboardAddr, "dashboard", "", "Serve a live HTML dashboard and SSE event hub on this address (e.g. :8090) while the scan runs")
+	fs.StringSliceVar(&f.reportFormats, "report-formats", []string{"json", "html", "console"}, "Report formats to generate (json,html,console,sarif,har)")
+	fs.StringVar(&f.logFormat, "log-format", "text", "Log line format: text (ANSI-colored) or json (one object per line, for CI/SIEM ingestion)")
+}
+
+// newLogger builds the Logger every subcommand starts with, honoring
+// --log-format alongside --verbose.
+func (f *flags) newLogger() *utils.Logger {
+	if f.logFormat == "json" {
+		level := utils.LevelInfo
+		if f.verbose {
+			level = utils.LevelDebug
+		}
+		return utils.NewJSONLogger(level)
+	}
+	return utils.NewLogger(f.verbose)
+}
+
+// scanConfig builds a models.ScanConfig from the current flag values. Each
+// subcommand calls it on the *flags its constructor was given.
+func (f *flags) scanConfig() *models.ScanConfig {
+	return &models.ScanConfig{
+		TargetURL:               f.targetURL,
+		LoginURL:                f.loginURL,
+		Browser:                 f.browserType,
+		Headless:                f.headless,
+		BrowserTimeout:          time.Duration(f.timeout) * time.Second,
+		OutputDir:               f.outputDir,
+		Verbose:                 f.verbose,
+		MaxDepth:                f.maxDepth,
+		WordlistPath:            f.wordlistPath,
+		WordlistSources:         f.wordlistSources,
+		EnableCrawl:             f.enableCrawl,
+		EnableWayback:           f.enableWayback,
+		EnableCommonCrawl:       f.enableCommonCrawl,
+		EnableURLScan:           f.enableURLScan,
+		URLScanAPIKey:           f.urlscanAPIKey,
+		EnableOTX:               f.enableOTX,
+		OTXAPIKey:               f.otxAPIKey,
+		EnableCrtSh:             f.enableCrtSh,
+		PassiveSourceRPS:        f.passiveSourceRPS,
+		PassiveCacheDir:         f.passiveCacheDir,
+		EnableCommonPaths:       f.enableCommonPaths,
+		EnableJSAnalysis:        f.enableJSAnalysis,
+		EnableSignalR:           f.enableSignalR,
+		HARImportPath:           f.harImportPath,
+		OpenAPIImportPath:       f.openAPIImportPath,
+		HARRecordPath:           f.harRecordPath,
+		ClientCertPath:          f.clientCertPath,
+		ClientKeyPath:           f.clientKeyPath,
+		CABundlePath:            f.caBundlePath,
+		TLSInsecure:             f.tlsInsecure,
+		JWTSecretsWordlist:      f.jwtSecretsWordlist,
+		JWTMaxCrackDuration:     time.Duration(f.jwtMaxCrackSeconds) * time.Second,
+		JWTJWKSURI:              f.jwtJWKSURI,
+		JWTRedactSecrets:        f.jwtRedactSecrets,
+		PriceFieldPattern:       f.priceFieldPattern,
+		RaceMode:                f.raceMode,
+		EnableRaceCondition:     f.enableRace,
+		EnablePriceManipulation: f.enablePrice,
+		EnableIDOR:              f.enableIDOR,
+		EnableOTPSecurity:       f.enableOTP,
+		EnableCallbackAuth:      f.enableCallback,
+		EnableAmountValidation:  f.enableAmount,
+		EnableIdempotency:       f.enableIdempotency,
+		Domain:                  utils.ExtractDomain(f.targetURL),
+		RateRPS:                 f.rateRPS,
+		RateMin:                 f.rateMin,
+		RateMax:                 f.rateMax,
+		WAFAdaptive:             f.wafAdaptive,
+		ReportFormats:           f.reportFormats,
+		LogFormat:               f.logFormat,
+	}
+}
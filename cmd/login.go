@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/auth"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/session"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// establishSession wires up the configured session.Store (file or memory,
+// cached or not) and returns an authenticated *models.Session, along with
+// the *browser.Browser/WSInterceptor the login flow created so callers can
+// keep driving the same browser afterwards (discovery, WS scanning).
+func establishSession(ctx context.Context, f *flags, config models.ScanConfig, logger *utils.Logger) (*models.Session, *browser.Browser, *browser.WSInterceptor, error) {
+	if f.sessionPassphrase != "" {
+		os.Setenv("SCANNER_SESSION_KEY", f.sessionPassphrase)
+	}
+
+	if f.authProvider != "" {
+		sess, err := establishOAuthSession(ctx, f, logger)
+		return sess, nil, nil, err
+	}
+
+	var br *browser.Browser
+	var wsi *browser.WSInterceptor
+
+	loginFn := func(ctx context.Context, params session.LoginParams) (*models.Session, error) {
+		return browserLogin(ctx, params, config, logger, f.enableWSInterceptor, f.enableSignalR, &br, &wsi)
+	}
+
+	var store session.Store
+	switch f.sessionStoreKind {
+	case "memory":
+		store = session.NewMemoryStore(loginFn, session.HTTPProber(), nil)
+	default:
+		store = session.NewFileStore("sessions", loginFn, session.HTTPProber(), nil)
+	}
+
+	params := session.LoginParams{LoginURL: f.loginURL, TargetURL: f.targetURL}
+
+	var sess *models.Session
+	if !f.skipSessionCache {
+		if fileStore, ok := store.(*session.FileStore); ok {
+			if cached, err := fileStore.Load(params); err == nil && cached != nil {
+				logger.Info("Validating cached session...")
+				sess = cached
+			}
+		}
+	}
+
+	var err error
+	if sess != nil {
+		sess, err = store.Validate(ctx, sess)
+	} else {
+		sess, err = store.New(ctx, params)
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("session setup failed: %w", err)
+	}
+
+	if err := attachTLSConfig(sess, config); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return sess, br, wsi, nil
+}
+
+// attachTLSConfig loads --client-cert/--client-key/--ca-bundle/--tls-insecure
+// into sess.TLSConfig, shared by both the browser-driven and
+// internal/auth-driven establishSession paths.
+func attachTLSConfig(sess *models.Session, config models.ScanConfig) error {
+	tlsConfig, err := utils.LoadTLSConfig(utils.TLSConfigSpec{
+		ClientCertPath: config.ClientCertPath,
+		ClientKeyPath:  config.ClientKeyPath,
+		CABundlePath:   config.CABundlePath,
+		Insecure:       config.TLSInsecure,
+	})
+	if err != nil {
+		return fmt.Errorf("loading TLS config: %w", err)
+	}
+	sess.TLSConfig = tlsConfig
+	return nil
+}
+
+// establishOAuthSession bootstraps a session via internal/auth instead of
+// the browser-driven login flow, for targets whose API authenticates
+// through an OAuth2/OIDC identity provider rather than a cookie-based web
+// login. The resulting session's refresh token (if the grant issued one)
+// is kept rotating in the background for the life of the scan.
+func establishOAuthSession(ctx context.Context, f *flags, logger *utils.Logger) (*models.Session, error) {
+	if f.authConfigPath == "" {
+		return nil, fmt.Errorf("--auth-provider requires --auth-config")
+	}
+
+	cfg, err := auth.LoadConfig(f.authConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Provider = f.authProvider
+
+	provider, err := auth.NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Section("Phase 0: Authentication")
+	logger.Info("Logging in via %s...", f.authProvider)
+
+	sess, err := provider.Login(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("auth provider login failed: %w", err)
+	}
+	logger.Success("Authenticated via %s", f.authProvider)
+
+	if err := attachTLSConfig(sess, *f.scanConfig()); err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	auth.StartAutoRefresh(ctx, cfg, sess, &mu)
+
+	return sess, nil
+}
+
+// browserLogin drives the interactive (or direct-navigation) Playwright
+// login flow and extracts the resulting session. It's wired in as the
+// session.LoginFunc for whichever session.Store establishSession picked, so
+// the store doesn't need to know anything about browsers. br and wsi are
+// written back through the given pointers so callers can keep using the
+// same browser/interceptor for discovery and WebSocket scanning.
+func browserLogin(ctx context.Context, params session.LoginParams, config models.ScanConfig, logger *utils.Logger, wsInterceptEnabled bool, signalREnabled bool, brOut **browser.Browser, wsiOut **browser.WSInterceptor) (*models.Session, error) {
+	logger.Section("Phase 0: Authentication")
+
+	br, err := browser.NewBrowserWithHAR(config.Browser, config.Headless, config.HARRecordPath)
+	if err != nil {
+		return nil, err
+	}
+	*brOut = br
+
+	var wsi *browser.WSInterceptor
+
+	if params.LoginURL != "" {
+		// Navigate to login page first (to have a page context)
+		logger.Info("Navigating to login page...")
+		if err := br.Navigate(params.LoginURL); err != nil {
+			return nil, fmt.Errorf("failed to navigate: %w", err)
+		}
+
+		// Enable WebSocket interceptor BEFORE user login
+		if wsInterceptEnabled {
+			wsi = browser.NewWSInterceptor().WithSignalR(signalREnabled)
+			if err := wsi.Enable(br.GetPage()); err != nil {
+				logger.Error("Failed to enable WS interceptor: %v", err)
+			} else {
+				logger.Success("WebSocket interceptor enabled")
+			}
+		}
+
+		// Wait for user to complete login
+		logger.Info("Waiting for manual login...")
+		if err := br.WaitForManualLogin(params.LoginURL, config.BrowserTimeout); err != nil {
+			return nil, fmt.Errorf("login failed: %w", err)
+		}
+	} else {
+		// Navigate to target directly
+		if err := br.Navigate(params.TargetURL); err != nil {
+			return nil, err
+		}
+
+		// Enable WS interceptor after navigation
+		if wsInterceptEnabled {
+			wsi = browser.NewWSInterceptor().WithSignalR(signalREnabled)
+			if err := wsi.Enable(br.GetPage()); err != nil {
+				logger.Error("Failed to enable WS interceptor: %v", err)
+			} else {
+				logger.Success("WebSocket interceptor enabled")
+			}
+		}
+	}
+	*wsiOut = wsi
+
+	logger.Success("Authentication complete")
+
+	// Wait for cookies and WebSocket connection to be established
+	logger.Info("Waiting for session to stabilize...")
+	time.Sleep(3 * time.Second)
+
+	// Wait for WebSocket connection
+	if wsInterceptEnabled && wsi != nil {
+		wsInfo := wsi.GetConnectionInfo(br.GetPage())
+		if wsInfo != nil && wsInfo["connected"].(bool) {
+			logger.Success("WebSocket connected: %s", wsInfo["url"])
+		} else {
+			logger.Warn("No WebSocket connection detected (site may use HTTP polling)")
+		}
+	}
+
+	// Extract session
+	sess, err := browser.ExtractWebSocketSession(br.GetPage())
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract session: %w", err)
+	}
+
+	cookieCount := len(sess.Cookies)
+	tokenPreview := truncate(sess.SessionToken, 20)
+	if tokenPreview == "" {
+		tokenPreview = "(none)"
+	}
+	logger.Success("Session extracted (%d cookies, token: %s)", cookieCount, tokenPreview)
+
+	return sess, nil
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
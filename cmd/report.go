@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/reporter"
+	"github.com/spf13/cobra"
+)
+
+// NewCLIReport builds the `report` command group for turning a previously
+// saved JSON scan result back into other formats, without re-running or
+// re-authenticating a scan.
+func NewCLIReport(f *flags) *cobra.Command {
+	var outputDir string
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Render or convert a saved JSON scan result",
+	}
+	cmd.PersistentFlags().StringVarP(&outputDir, "output", "o", "reports", "Output directory for rendered reports")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "render <result.json>",
+		Short: "Render a JSON scan result as an HTML report",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return reportRender(args[0], outputDir)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "convert <result.json> <format>",
+		Short: "Convert a JSON scan result to another report format (html)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return reportConvert(args[0], args[1], outputDir)
+		},
+	})
+
+	return cmd
+}
+
+func loadScanResult(path string) (models.ScanResult, error) {
+	var result models.ScanResult
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return result, nil
+}
+
+func reportRender(inputPath, outputDir string) error {
+	result, err := loadScanResult(inputPath)
+	if err != nil {
+		return err
+	}
+
+	htmlFile, err := reporter.GenerateHTMLReport(result, outputDir)
+	if err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	fmt.Printf("HTML: %s\n", htmlFile)
+	return nil
+}
+
+func reportConvert(inputPath, format, outputDir string) error {
+	switch format {
+	case "html":
+		return reportRender(inputPath, outputDir)
+	default:
+		return fmt.Errorf("unsupported report format: %s (supported: html)", format)
+	}
+}
@@ -0,0 +1,43 @@
+// Package cmd holds the scanner's cobra command tree: one file per
+// subcommand (scan, discover, session, report, ws), each built from a
+// shared *flags value so none of them rely on package-level mutable globals.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var version = "0.2.0"
+
+// Execute builds the scanner root command tree and runs it.
+func Execute() {
+	f := newFlags()
+
+	root := &cobra.Command{
+		Use:     "scanner",
+		Short:   "Web Payment Security Scanner",
+		Long:    `Payment scanner with WebSocket support`,
+		Version: version,
+	}
+	f.register(root.PersistentFlags())
+
+	scanCmd := NewCLIScan(f)
+	root.AddCommand(scanCmd)
+	root.AddCommand(NewCLIDiscover(f))
+	root.AddCommand(NewCLISession(f))
+	root.AddCommand(NewCLIReport(f))
+	root.AddCommand(NewCLIWS(f))
+
+	// `scanner -u ...` with no subcommand behaves like `scanner scan -u ...`,
+	// matching the single-command CLI this project shipped before
+	// subcommands were introduced.
+	root.RunE = scanCmd.RunE
+
+	if err := root.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/reporter"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/scanner"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// NewCLIScan builds the `scan` subcommand: authenticate, discover, run every
+// enabled test module, and write the console/JSON/HTML reports. This is the
+// full flow the scanner shipped as its only command before subcommands were
+// introduced, and it remains what bare `scanner -u ...` runs by default.
+func NewCLIScan(f *flags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "scan",
+		Short: "Authenticate, discover endpoints, and run the full test suite",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScan(f)
+		},
+	}
+}
+
+func runScan(f *flags) error {
+	logger := f.newLogger()
+	logger.Banner("Web Payment Scanner v" + version)
+
+	if !utils.IsValidURL(f.targetURL) {
+		return fmt.Errorf("invalid target URL")
+	}
+
+	config := *f.scanConfig()
+
+	ctx := context.Background()
+	sess, br, wsi, err := establishSession(ctx, f, config, logger)
+	if err != nil {
+		return err
+	}
+
+	// Initialize engine
+	engine := scanner.NewEngine(config, sess, br)
+
+	stream, closeStream, err := openStreamWriter(f.streamPath)
+	if err != nil {
+		return fmt.Errorf("failed to open --stream target: %w", err)
+	}
+	defer closeStream()
+	if stream != nil {
+		engine.SetStreamWriter(stream)
+	}
+
+	hub, closeDashboard, err := startDashboard(f.dashboardAddr, logger)
+	if err != nil {
+		return fmt.Errorf("failed to start --dashboard: %w", err)
+	}
+	defer closeDashboard()
+	if hub != nil {
+		engine.SetHub(hub)
+	}
+
+	// Discovery
+	startTime := time.Now()
+	if err := engine.StartDiscovery(); err != nil {
+		logger.Error("Discovery failed: %v", err)
+	}
+
+	// Scanning
+	if err := engine.StartScanning(); err != nil {
+		logger.Error("Scanning failed: %v", err)
+	}
+
+	// WebSocket-specific tests
+	if f.enableWSInterceptor && wsi != nil && br != nil {
+		logger.Section("Phase 2b: WebSocket Scanning")
+
+		// Show traffic summary
+		wsi.PrintSummary()
+
+		// Test race conditions
+		if f.enableRace {
+			logger.Info("Testing WebSocket race conditions...")
+			wsVulns := scanner.TestWebSocketRaceCondition(br.GetPage(), wsi, 10)
+			engine.AddVulnerabilities(wsVulns)
+		}
+
+		// Test replay
+		logger.Info("Testing WebSocket replay attacks...")
+		replayVulns := scanner.TestWebSocketReplay(br.GetPage(), wsi)
+		engine.AddVulnerabilities(replayVulns)
+
+		// Test amount manipulation
+		if f.enablePrice {
+			logger.Info("Testing WebSocket amount manipulation...")
+			amountVulns := scanner.TestWebSocketAmountManipulation(br.GetPage(), wsi)
+			engine.AddVulnerabilities(amountVulns)
+		}
+
+		// Active probes over a direct connection (missing auth, CSWSH,
+		// oversized/malformed frames, IDOR, subprotocol downgrade)
+		if f.enableWSActive {
+			logger.Info("Running active WebSocket attack probes...")
+			activeVulns := scanner.TestWebSocketActive(sess, wsi)
+			engine.AddVulnerabilities(activeVulns)
+		}
+	}
+
+	// Cleanup browser
+	if br != nil {
+		br.Close()
+	}
+
+	// Stop the event bus and flush a final summary line to --stream, if set.
+	engine.Close()
+
+	// Reports
+	logger.Section("Phase 3: Reporting")
+	result := engine.GetResults()
+	result.Duration = time.Since(startTime)
+
+	formats := f.reportFormats
+	if len(formats) == 0 {
+		formats = []string{"json", "html", "console"}
+	}
+
+	if formatEnabled(formats, "console") {
+		reporter.PrintConsoleSummary(result)
+	}
+
+	if formatEnabled(formats, "json") {
+		jsonFile, _ := reporter.GenerateJSONReport(result, f.outputDir)
+		logger.Success("JSON: %s", jsonFile)
+	}
+
+	if formatEnabled(formats, "html") {
+		htmlFile, _ := reporter.GenerateHTMLReport(result, f.outputDir)
+		logger.Success("HTML: %s", htmlFile)
+	}
+
+	if formatEnabled(formats, "sarif") {
+		sarifFile, err := reporter.GenerateSARIFReport(result, f.outputDir)
+		if err != nil {
+			logger.Error("Failed to write SARIF report: %v", err)
+		} else {
+			logger.Success("SARIF: %s", sarifFile)
+		}
+	}
+
+	if formatEnabled(formats, "har") {
+		timestamp := time.Now().Format("20060102_150405")
+		harFile := filepath.Join(f.outputDir, fmt.Sprintf("scan_report_%s.har", timestamp))
+		if err := reporter.WriteHAR(result, harFile); err != nil {
+			logger.Error("Failed to write HAR report: %v", err)
+		} else {
+			logger.Success("HAR: %s", harFile)
+		}
+
+		curlFile := filepath.Join(f.outputDir, fmt.Sprintf("reproduce_%s.sh", timestamp))
+		if err := reporter.WriteCurlReproducers(result, curlFile); err != nil {
+			logger.Error("Failed to write curl reproducers: %v", err)
+		}
+	}
+
+	color.Green("\nScan completed!")
+	return nil
+}
+
+// formatEnabled reports whether name appears in formats (case-insensitive).
+func formatEnabled(formats []string, name string) bool {
+	for _, f := range formats {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
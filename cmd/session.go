@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
+	"github.com/spf13/cobra"
+)
+
+// NewCLISession builds the `session` command group for inspecting and
+// managing the cached session files a prior `scan`/`discover` run left
+// behind, without needing to re-authenticate.
+func NewCLISession(f *flags) *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "List, show, purge, or export cached sessions",
+	}
+	cmd.PersistentFlags().StringVar(&dir, "dir", "sessions", "Directory the file session store caches sessions in")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List cached session files",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sessionList(dir)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show <file>",
+		Short: "Print a cached session as JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sessionShow(dir, args[0])
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "purge",
+		Short: "Delete every cached session file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sessionPurge(dir)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "export <file> <dest>",
+		Short: "Decrypt (if needed) and write a cached session as plaintext JSON",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sessionExport(dir, args[0], args[1])
+		},
+	})
+
+	return cmd
+}
+
+func sessionList(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		fmt.Println("no sessions cached")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fmt.Printf("%s\t%s\t%d bytes\n", entry.Name(), info.ModTime().Format(time.RFC3339), info.Size())
+	}
+	return nil
+}
+
+func sessionShow(dir, file string) error {
+	sess, err := browser.LoadSessionFromFile(filepath.Join(dir, file))
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func sessionPurge(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.Name(), err)
+		}
+		removed++
+	}
+	fmt.Printf("purged %d cached session(s)\n", removed)
+	return nil
+}
+
+func sessionExport(dir, file, dest string) error {
+	sess, err := browser.LoadSessionFromFile(filepath.Join(dir, file))
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dest, data, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("exported %s to %s\n", file, dest)
+	return nil
+}
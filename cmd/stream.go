@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/reporter"
+)
+
+// openStreamWriter resolves --stream into a reporter.StreamWriter: "-"
+// means stdout (so CI can `tee` it), anything else is a file path. Returns
+// a nil writer and no-op closer when path is empty, so callers can wire it
+// unconditionally.
+func openStreamWriter(path string) (*reporter.StreamWriter, func(), error) {
+	if path == "" {
+		return nil, func() {}, nil
+	}
+
+	var w io.Writer
+	closeFn := func() {}
+
+	if path == "-" {
+		w = os.Stdout
+	} else {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		w = f
+		closeFn = func() { f.Close() }
+	}
+
+	return reporter.NewStreamWriter(w), closeFn, nil
+}
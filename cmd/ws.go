@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/scanner"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// NewCLIWS builds the `ws` command group for WebSocket-focused flows that
+// don't need the rest of the test suite: capturing traffic for later
+// inspection, and replaying it back to check for missing server-side
+// validation.
+func NewCLIWS(f *flags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ws",
+		Short: "Capture or replay WebSocket traffic",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "record <output.json>",
+		Short: "Authenticate, capture WebSocket traffic, and save it to a file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return wsRecord(f, args[0])
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "replay",
+		Short: "Authenticate, capture WebSocket traffic, and replay captured messages",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return wsReplay(f)
+		},
+	})
+
+	return cmd
+}
+
+func wsSession(f *flags) (*browser.Browser, *browser.WSInterceptor, error) {
+	logger := f.newLogger()
+	logger.Banner("Web Payment Scanner v" + version)
+
+	if !utils.IsValidURL(f.targetURL) {
+		return nil, nil, fmt.Errorf("invalid target URL")
+	}
+
+	f.enableWSInterceptor = true
+	config := *f.scanConfig()
+
+	_, br, wsi, err := establishSession(context.Background(), f, config, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if wsi == nil {
+		if br != nil {
+			br.Close()
+		}
+		return nil, nil, fmt.Errorf("no WebSocket connection was captured")
+	}
+
+	return br, wsi, nil
+}
+
+func wsRecord(f *flags, outputPath string) error {
+	br, wsi, err := wsSession(f)
+	if err != nil {
+		return err
+	}
+	defer br.Close()
+
+	messages := wsi.GetMessages()
+	data, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("recorded %d WebSocket message(s) to %s\n", len(messages), outputPath)
+	return nil
+}
+
+func wsReplay(f *flags) error {
+	br, wsi, err := wsSession(f)
+	if err != nil {
+		return err
+	}
+	defer br.Close()
+
+	vulns := scanner.TestWebSocketReplay(br.GetPage(), wsi)
+	if len(vulns) == 0 {
+		fmt.Println("no replay vulnerabilities found")
+		return nil
+	}
+	for _, v := range vulns {
+		fmt.Printf("[%s] %s\n", v.Severity, v.Title)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package auth obtains a live models.Session from an OAuth2/OIDC identity
+// provider before a scan starts, for targets where the browser-driven
+// login in internal/session isn't how the API is actually authenticated.
+// It's modelled on the multi-connector approach dex and oauth2-proxy use:
+// a generic OIDC connector (discovery + authorization-code flow) plus
+// first-class profiles for providers whose endpoints don't follow OIDC
+// discovery (GitHub, Bitbucket) or whose discovery document lives at a
+// realm-scoped path (Keycloak).
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// defaultCallbackPort is used when Config.CallbackPort is left at zero.
+const defaultCallbackPort = 8912
+
+// Config describes how to reach and authenticate against an identity
+// provider. Which fields matter depends on Provider: the password grant
+// only needs TokenURL/Username/Password; the client-credentials grant
+// needs ClientID/ClientSecret plus TokenURL (or IssuerURL, for OIDC
+// discovery); everything else drives an authorization-code flow through
+// AuthURL/TokenURL (or IssuerURL, for OIDC discovery) and CallbackPort.
+type Config struct {
+	// Provider selects the connector: "oidc", "github", "bitbucket",
+	// "keycloak", "password", or "client-credentials".
+	Provider string
+
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	Scopes       []string
+
+	// IssuerURL is the OIDC issuer discovery is run against
+	// (<IssuerURL>/.well-known/openid-configuration). Keycloak realms use
+	// this too; GitHub/Bitbucket/password ignore it in favor of fixed or
+	// explicit endpoints.
+	IssuerURL string
+	AuthURL   string
+	TokenURL  string
+
+	// CallbackPort is the 127.0.0.1 port the authorization-code flow's
+	// local listener binds while waiting for the provider to redirect
+	// back with a code. Defaults to 8912 if zero.
+	CallbackPort int
+
+	// Username/Password drive the "password" connector's resource-owner
+	// password-credentials grant.
+	Username string
+	Password string
+
+	// Browser/Headless control the real browser the authorization-code
+	// connectors open on AuthURL so the user can log in interactively
+	// instead of copy-pasting a URL. Headless defaults to false here
+	// (there's no one to type credentials into a headless window);
+	// Browser defaults to "firefox" the same as the scan CLI's --browser.
+	// If launching a browser fails (no display, Playwright not installed),
+	// the connector falls back to printing AuthURL for the user to open
+	// manually.
+	Browser  string
+	Headless bool
+}
+
+// Provider obtains an authenticated models.Session from an identity
+// provider. Login blocks until the flow completes (which, for the
+// authorization-code connectors, means until the provider redirects back
+// to the local callback listener).
+type Provider interface {
+	Login(ctx context.Context) (*models.Session, error)
+}
+
+// NewProvider builds the Provider cfg.Provider names.
+func NewProvider(cfg Config) (Provider, error) {
+	if cfg.CallbackPort == 0 {
+		cfg.CallbackPort = defaultCallbackPort
+	}
+	if cfg.Browser == "" {
+		cfg.Browser = "firefox"
+	}
+
+	switch cfg.Provider {
+	case "oidc":
+		return &oidcProvider{cfg: cfg}, nil
+	case "github":
+		return &oidcProvider{cfg: withGitHubEndpoints(cfg)}, nil
+	case "bitbucket":
+		return &oidcProvider{cfg: withBitbucketEndpoints(cfg)}, nil
+	case "keycloak":
+		return &oidcProvider{cfg: cfg}, nil
+	case "password":
+		return &passwordProvider{cfg: cfg}, nil
+	case "client-credentials":
+		return &clientCredentialsProvider{cfg: cfg}, nil
+	default:
+		return nil, fmt.Errorf("auth: unsupported provider %q (want oidc, github, bitbucket, keycloak, password, or client-credentials)", cfg.Provider)
+	}
+}
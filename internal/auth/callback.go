@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// callbackResult is what the authorization server's redirect to the local
+// listener carries back: the authorization code and the state value it
+// was launched with (or an error, per RFC 6749 §4.1.2.1).
+type callbackResult struct {
+	Code  string
+	State string
+	Err   error
+}
+
+// awaitCallback starts a one-shot HTTP listener on 127.0.0.1:port,
+// handling exactly one request to path before shutting itself down, and
+// returns whatever authorization code/error it received. It blocks until
+// that request arrives or ctx is done.
+func awaitCallback(ctx context.Context, port int, path string) (*callbackResult, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("binding OAuth2 callback listener: %w", err)
+	}
+
+	results := make(chan callbackResult, 1)
+	srv := &http.Server{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		result := callbackResult{Code: q.Get("code"), State: q.Get("state")}
+		if errParam := q.Get("error"); errParam != "" {
+			result.Err = fmt.Errorf("authorization server returned error: %s (%s)", errParam, q.Get("error_description"))
+		}
+		fmt.Fprintln(w, "Authentication complete, you can close this tab.")
+		results <- result
+	})
+	srv.Handler = mux
+
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	select {
+	case result := <-results:
+		return &result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for OAuth2 callback: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// clientCredentialsProvider implements the RFC 6749 §4.4 client
+// credentials grant, for service-to-service payment APIs authenticated as
+// the scanner itself rather than as a logged-in user - no browser, no
+// callback listener, just a direct POST to TokenURL.
+type clientCredentialsProvider struct {
+	cfg Config
+}
+
+func (p *clientCredentialsProvider) Login(ctx context.Context) (*models.Session, error) {
+	cfg := p.cfg
+
+	if cfg.TokenURL == "" {
+		if cfg.IssuerURL == "" {
+			return nil, fmt.Errorf("auth: client-credentials provider needs token_url or issuer_url (for discovery)")
+		}
+		doc, err := discoverOIDC(cfg.IssuerURL)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TokenURL = doc.TokenEndpoint
+	}
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, fmt.Errorf("auth: client-credentials provider needs client_id and client_secret")
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {cfg.ClientID},
+		"client_secret": {cfg.ClientSecret},
+	}
+	if len(cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+
+	tok, err := exchangeToken(cfg.TokenURL, form)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionFromToken(tok), nil
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadConfig parses a flat "key: value" YAML auth config file - the same
+// hand-rolled subset scanner/fingerprints uses rather than pulling in a
+// YAML library for a fixed, simple shape:
+//
+//	provider: github
+//	client_id: abc123
+//	client_secret: shh
+//	redirect_uri: http://127.0.0.1:8912/callback
+//	scopes: read:user, user:email
+//	callback_port: 8912
+func LoadConfig(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("reading auth config: %w", err)
+	}
+	defer f.Close()
+
+	cfg := Config{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = unquoteYAMLValue(strings.TrimSpace(value))
+
+		switch key {
+		case "provider":
+			cfg.Provider = value
+		case "client_id":
+			cfg.ClientID = value
+		case "client_secret":
+			cfg.ClientSecret = value
+		case "redirect_uri":
+			cfg.RedirectURI = value
+		case "scopes":
+			cfg.Scopes = splitAndTrim(value, ",")
+		case "issuer_url":
+			cfg.IssuerURL = value
+		case "auth_url":
+			cfg.AuthURL = value
+		case "token_url":
+			cfg.TokenURL = value
+		case "callback_port":
+			if port, err := strconv.Atoi(value); err == nil {
+				cfg.CallbackPort = port
+			}
+		case "username":
+			cfg.Username = value
+		case "password":
+			cfg.Password = value
+		case "browser":
+			cfg.Browser = value
+		case "headless":
+			if headless, err := strconv.ParseBool(value); err == nil {
+				cfg.Headless = headless
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// unquoteYAMLValue strips a surrounding pair of single or double quotes.
+func unquoteYAMLValue(s string) string {
+	if len(s) >= 2 {
+		if (s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\'') {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+func splitAndTrim(s, sep string) []string {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, sep)
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if trimmed := strings.TrimSpace(p); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
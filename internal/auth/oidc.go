@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// oidcProvider drives a generic OAuth2/OIDC authorization-code flow:
+// resolve the authorize/token endpoints (via discovery, for plain "oidc"
+// and "keycloak"; from Config directly, for "github"/"bitbucket" whose
+// presets are filled in by withGitHubEndpoints/withBitbucketEndpoints),
+// send the user to AuthURL, and exchange the code the local callback
+// listener receives for a token.
+type oidcProvider struct {
+	cfg Config
+}
+
+type oidcDiscoveryDocument struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+func (p *oidcProvider) Login(ctx context.Context) (*models.Session, error) {
+	cfg := p.cfg
+
+	if cfg.AuthURL == "" || cfg.TokenURL == "" {
+		if cfg.IssuerURL == "" {
+			return nil, fmt.Errorf("auth: provider %q needs issuer_url (for discovery) or explicit auth_url/token_url", cfg.Provider)
+		}
+		doc, err := discoverOIDC(cfg.IssuerURL)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.AuthURL == "" {
+			cfg.AuthURL = doc.AuthorizationEndpoint
+		}
+		if cfg.TokenURL == "" {
+			cfg.TokenURL = doc.TokenEndpoint
+		}
+	}
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := newPKCEVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authorizeURL, err := buildAuthorizeURL(cfg, state, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	openAuthorizeURL(cfg, authorizeURL)
+
+	callbackPath := callbackPathFromRedirect(cfg.RedirectURI)
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	result, err := awaitCallback(waitCtx, cfg.CallbackPort, callbackPath)
+	if err != nil {
+		return nil, err
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	if result.State != state {
+		return nil, fmt.Errorf("auth: OAuth2 callback state mismatch (possible CSRF)")
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {result.Code},
+		"redirect_uri":  {cfg.RedirectURI},
+		"client_id":     {cfg.ClientID},
+		"client_secret": {cfg.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	tok, err := exchangeToken(cfg.TokenURL, form)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionFromToken(tok), nil
+}
+
+// discoverOIDC fetches issuerURL's OpenID Connect discovery document, the
+// same ".well-known/openid-configuration" convention dex and every major
+// OIDC provider (including Keycloak realms) publish.
+func discoverOIDC(issuerURL string) (*oidcDiscoveryDocument, error) {
+	discoveryURL := strings.TrimRight(issuerURL, "/") + "/.well-known/openid-configuration"
+
+	client := utils.NewHTTPClient(10 * time.Second)
+	resp, err := client.Get(discoveryURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching OIDC discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery document at %s returned %d", discoveryURL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &oidcDiscoveryDocument{}
+	if err := json.Unmarshal(body, doc); err != nil {
+		return nil, fmt.Errorf("parsing OIDC discovery document: %w", err)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" {
+		return nil, fmt.Errorf("OIDC discovery document missing authorization_endpoint/token_endpoint")
+	}
+	return doc, nil
+}
+
+func buildAuthorizeURL(cfg Config, state, verifier string) (string, error) {
+	u, err := url.Parse(cfg.AuthURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing auth_url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", cfg.ClientID)
+	q.Set("redirect_uri", cfg.RedirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", pkceChallengeS256(verifier))
+	q.Set("code_challenge_method", "S256")
+	if len(cfg.Scopes) > 0 {
+		q.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// openAuthorizeURL tries to drive authorizeURL through a real browser
+// (cfg.Browser/cfg.Headless) so the user can log in without copy-pasting,
+// the same browser.Browser the scan CLI's --browser flag drives. If the
+// browser can't be launched or navigated (no display, Playwright not
+// installed), it falls back to printing the URL for the user to open
+// manually - this provider must still work headless-only environments.
+func openAuthorizeURL(cfg Config, authorizeURL string) {
+	b, err := browser.NewBrowserWithHAR(cfg.Browser, cfg.Headless, "")
+	if err != nil {
+		fmt.Printf("Open the following URL to authenticate:\n\n  %s\n\n", authorizeURL)
+		return
+	}
+	if err := b.Navigate(authorizeURL); err != nil {
+		b.Close()
+		fmt.Printf("Open the following URL to authenticate:\n\n  %s\n\n", authorizeURL)
+		return
+	}
+}
+
+// callbackPathFromRedirect returns redirectURI's path (defaulting to
+// "/callback"), so awaitCallback listens on the same path Config.RedirectURI
+// points the identity provider back to.
+func callbackPathFromRedirect(redirectURI string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Path == "" {
+		return "/callback"
+	}
+	return u.Path
+}
+
+// randomState generates the CSRF-protection "state" value sent with the
+// authorization request and checked against the callback.
+func randomState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// withGitHubEndpoints fills in GitHub's fixed OAuth endpoints (GitHub
+// predates OIDC discovery and doesn't publish a discovery document) when
+// the caller hasn't already set AuthURL/TokenURL explicitly.
+func withGitHubEndpoints(cfg Config) Config {
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = "https://github.com/login/oauth/authorize"
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = "https://github.com/login/oauth/access_token"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"read:user"}
+	}
+	return cfg
+}
+
+// withBitbucketEndpoints fills in Bitbucket Cloud's fixed OAuth endpoints,
+// same rationale as withGitHubEndpoints.
+func withBitbucketEndpoints(cfg Config) Config {
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = "https://bitbucket.org/site/oauth2/authorize"
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = "https://bitbucket.org/site/oauth2/access_token"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"account"}
+	}
+	return cfg
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// newPKCEVerifier generates an RFC 7636 code_verifier: 32 random bytes,
+// base64url-encoded (no padding) to 43 characters, the minimum length in
+// the spec's 43-128 character range.
+func newPKCEVerifier() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// pkceChallengeS256 derives the S256 code_challenge from verifier, per
+// RFC 7636 §4.2.
+func pkceChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
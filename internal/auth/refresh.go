@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// refreshSkew is how long before ExpiresAt the background refresh loop
+// rotates the token, so a request in flight never races an expiring one.
+const refreshSkew = 30 * time.Second
+
+// StartAutoRefresh launches a background goroutine that rotates sess's
+// access token via the refresh_token grant shortly before ExpiresAt,
+// updating sess.Headers["Authorization"]/BearerToken/RefreshToken/ExpiresAt
+// in place under mu so the scan engine's already-shared *models.Session
+// picks up the new token without re-fetching anything. It returns
+// immediately; the goroutine exits when ctx is done or sess has no
+// RefreshToken/ExpiresAt to rotate.
+func StartAutoRefresh(ctx context.Context, cfg Config, sess *models.Session, mu *sync.Mutex) {
+	go func() {
+		for {
+			mu.Lock()
+			refreshToken := sess.RefreshToken
+			expiresAt := sess.ExpiresAt
+			mu.Unlock()
+
+			if refreshToken == "" || expiresAt.IsZero() {
+				return
+			}
+
+			wait := time.Until(expiresAt) - refreshSkew
+			if wait < 0 {
+				wait = 0
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+
+			tok, err := refreshAccessToken(cfg, refreshToken)
+			if err != nil {
+				// Leave the existing (soon-to-expire) token in place; the
+				// next scan request will surface the failure via a 401,
+				// same as any other session going stale.
+				return
+			}
+
+			mu.Lock()
+			if sess.Headers == nil {
+				sess.Headers = map[string]string{}
+			}
+			sess.Headers["Authorization"] = "Bearer " + tok.AccessToken
+			sess.BearerToken = tok.AccessToken
+			if tok.RefreshToken != "" {
+				sess.RefreshToken = tok.RefreshToken
+			}
+			if tok.ExpiresIn > 0 {
+				sess.ExpiresAt = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+			}
+			mu.Unlock()
+		}
+	}()
+}
+
+func refreshAccessToken(cfg Config, refreshToken string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {cfg.ClientID},
+		"client_secret": {cfg.ClientSecret},
+	}
+	return exchangeToken(cfg.TokenURL, form)
+}
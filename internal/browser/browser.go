@@ -18,12 +18,21 @@ type Browser struct {
 
 // NewBrowser creates a new browser instance
 func NewBrowser(browserType string, headless bool) (*Browser, error) {
+	return NewBrowserWithHAR(browserType, headless, "")
+}
+
+// NewBrowserWithHAR is identical to NewBrowser but, when harPath is
+// non-empty, records every request/response into a HAR file at that path
+// (Playwright's RecordHarPath option). The resulting HAR can be fed back
+// into discovery.ImportHAR to seed future scans of flows that needed
+// manual navigation (3DS, OTP) without re-crawling them.
+func NewBrowserWithHAR(browserType string, headless bool, harPath string) (*Browser, error) {
 	// Initialize Playwright
 	pw, err := playwright.Run()
 	if err != nil {
 		return nil, fmt.Errorf("failed to start playwright: %w", err)
 	}
-	
+
 	// Launch options with anti-detection
 	launchOptions := playwright.BrowserTypeLaunchOptions{
 		Headless: playwright.Bool(headless),
@@ -33,9 +42,9 @@ func NewBrowser(browserType string, headless bool) (*Browser, error) {
 			"--no-sandbox",
 		},
 	}
-	
+
 	var browser playwright.Browser
-	
+
 	// Select browser type
 	switch browserType {
 	case "firefox":
@@ -47,14 +56,14 @@ func NewBrowser(browserType string, headless bool) (*Browser, error) {
 	default:
 		browser, err = pw.Firefox.Launch(launchOptions)
 	}
-	
+
 	if err != nil {
 		pw.Stop()
 		return nil, fmt.Errorf("failed to launch browser: %w", err)
 	}
-	
+
 	// Create context with realistic settings
-	context, err := browser.NewContext(playwright.BrowserNewContextOptions{
+	contextOptions := playwright.BrowserNewContextOptions{
 		UserAgent: playwright.String("Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
 		Viewport: &playwright.Size{
 			Width:  1920,
@@ -64,8 +73,13 @@ func NewBrowser(browserType string, headless bool) (*Browser, error) {
 		TimezoneId:       playwright.String("America/New_York"),
 		AcceptDownloads:  playwright.Bool(false),
 		IgnoreHttpsErrors: playwright.Bool(true), // For testing environments
-	})
-	
+	}
+	if harPath != "" {
+		contextOptions.RecordHarPath = playwright.String(harPath)
+	}
+
+	context, err := browser.NewContext(contextOptions)
+
 	if err != nil {
 		browser.Close()
 		pw.Stop()
@@ -212,6 +226,7 @@ func (b *Browser) ExtractSession() (*models.Session, error) {
 	if err == nil {
 		for _, cookie := range cookies {
 			session.Cookies[cookie.Name] = cookie.Value
+			session.CookieDetails = append(session.CookieDetails, toModelCookie(cookie))
 		}
 	}
 	
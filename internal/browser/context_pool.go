@@ -0,0 +1,95 @@
+package browser
+
+import (
+	"fmt"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/playwright-community/playwright-go"
+)
+
+// ContextPool manages a fixed number of isolated browser contexts/pages
+// that share one underlying browser process (à la chromedp's NewContext/
+// NewExecAllocator), each cloning the cookies/localStorage of an
+// authenticated session. It lets callers like discovery.Crawler fan work
+// out across several navigations at once instead of serializing on a
+// single *Browser.
+type ContextPool struct {
+	contexts    []playwright.BrowserContext
+	pages       chan playwright.Page
+	rateLimiter *utils.RateLimiter
+}
+
+// NewContextPool creates a pool of size isolated contexts, each seeded
+// with br's current storage state (cookies + localStorage) so every
+// worker starts out authenticated the same way br is.
+func NewContextPool(br *Browser, size int) (*ContextPool, error) {
+	if size < 1 {
+		size = 1
+	}
+
+	state, err := br.context.StorageState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot storage state: %w", err)
+	}
+
+	pool := &ContextPool{
+		contexts: make([]playwright.BrowserContext, 0, size),
+		pages:    make(chan playwright.Page, size),
+	}
+
+	for i := 0; i < size; i++ {
+		ctx, err := br.browser.NewContext(playwright.BrowserNewContextOptions{
+			StorageState: state,
+			IgnoreHttpsErrors: playwright.Bool(true),
+		})
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to create pooled context: %w", err)
+		}
+
+		page, err := ctx.NewPage()
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to create pooled page: %w", err)
+		}
+
+		pool.contexts = append(pool.contexts, ctx)
+		pool.pages <- page
+	}
+
+	return pool, nil
+}
+
+// SetRateLimiter throttles Acquire using rl, so callers (e.g.
+// Engine.StartDiscovery after WAF detection) can slow the whole pool down
+// without changing its size.
+func (p *ContextPool) SetRateLimiter(rl *utils.RateLimiter) {
+	p.rateLimiter = rl
+}
+
+// Acquire blocks until a page is available and returns it, first waiting
+// out any rate limit set via SetRateLimiter.
+func (p *ContextPool) Acquire() playwright.Page {
+	if p.rateLimiter != nil {
+		p.rateLimiter.Wait("pool")
+	}
+	return <-p.pages
+}
+
+// Release returns a page to the pool so another worker can use it.
+func (p *ContextPool) Release(page playwright.Page) {
+	p.pages <- page
+}
+
+// Size returns the number of contexts managed by the pool.
+func (p *ContextPool) Size() int {
+	return len(p.contexts)
+}
+
+// Close tears down every context in the pool.
+func (p *ContextPool) Close() error {
+	for _, ctx := range p.contexts {
+		_ = ctx.Close()
+	}
+	return nil
+}
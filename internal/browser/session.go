@@ -2,10 +2,11 @@ package browser
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"regexp"
 	"time"
-	
+
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
 	"github.com/playwright-community/playwright-go"
 )
@@ -25,6 +26,7 @@ func ExtractWebSocketSession(page playwright.Page) (*models.Session, error) {
 	if cookies, err := page.Context().Cookies(); err == nil {
 		for _, cookie := range cookies {
 			session.Cookies[cookie.Name] = cookie.Value
+			session.CookieDetails = append(session.CookieDetails, toModelCookie(cookie))
 		}
 	}
 	
@@ -75,6 +77,29 @@ func ExtractWebSocketSession(page playwright.Page) (*models.Session, error) {
 	return session, nil
 }
 
+// toModelCookie converts a Playwright cookie into our models.Cookie,
+// preserving the security attributes used by the cookie/CSRF checks.
+func toModelCookie(cookie playwright.Cookie) models.Cookie {
+	sameSite := ""
+	if cookie.SameSite != nil {
+		sameSite = string(*cookie.SameSite)
+	}
+
+	c := models.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   cookie.Domain,
+		Path:     cookie.Path,
+		HttpOnly: cookie.HttpOnly,
+		Secure:   cookie.Secure,
+		SameSite: sameSite,
+	}
+	if cookie.Expires > 0 {
+		c.Expires = time.Unix(int64(cookie.Expires), 0)
+	}
+	return c
+}
+
 // ExtractCookies extracts cookies from the browser context
 func ExtractCookies(context playwright.BrowserContext) (map[string]string, error) {
 	cookies, err := context.Cookies()
@@ -90,6 +115,23 @@ func ExtractCookies(context playwright.BrowserContext) (map[string]string, error
 	return cookieMap, nil
 }
 
+// ExtractCookieDetails extracts cookies from the browser context along with
+// their security attributes (Secure/HttpOnly/SameSite/Path/Domain/Expires),
+// for use by cookie-hardening checks like scanner.TestCookieSecurity.
+func ExtractCookieDetails(context playwright.BrowserContext) ([]models.Cookie, error) {
+	cookies, err := context.Cookies()
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]models.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		details = append(details, toModelCookie(cookie))
+	}
+
+	return details, nil
+}
+
 // ExtractHeaders extracts common headers from the page
 func ExtractHeaders(page playwright.Page) map[string]string {
 	headers := make(map[string]string)
@@ -179,7 +221,11 @@ func ExtractFullSession(context playwright.BrowserContext, page playwright.Page)
 		return nil, err
 	}
 	session.Cookies = cookies
-	
+
+	if details, err := ExtractCookieDetails(context); err == nil {
+		session.CookieDetails = details
+	}
+
 	// Extract headers
 	session.Headers = ExtractHeaders(page)
 	
@@ -223,8 +269,15 @@ func SaveSession(session *models.Session, filepath string) error {
 	return nil
 }
 
-// SaveSessionToFile saves the session to a JSON file
+// SaveSessionToFile saves the session to a file. When SCANNER_SESSION_KEY
+// (set by the CLI from --session-passphrase) is present, the file is
+// encrypted via EncryptedSessionStore; otherwise it falls back to the
+// legacy plaintext JSON format.
 func SaveSessionToFile(session *models.Session, filepath string) error {
+	if passphrase := sessionPassphrase(); passphrase != "" {
+		return NewEncryptedSessionStore(passphrase).Save(session, filepath)
+	}
+
 	data, err := json.MarshalIndent(session, "", "  ")
 	if err != nil {
 		return err
@@ -232,13 +285,23 @@ func SaveSessionToFile(session *models.Session, filepath string) error {
 	return os.WriteFile(filepath, data, 0600) // 0600 = owner only
 }
 
-// LoadSessionFromFile loads the session from a JSON file
+// LoadSessionFromFile loads a session previously written by
+// SaveSessionToFile, transparently detecting the legacy plaintext format
+// vs. the encrypted one.
 func LoadSessionFromFile(filepath string) (*models.Session, error) {
 	data, err := os.ReadFile(filepath)
 	if err != nil {
 		return nil, err
 	}
-	
+
+	if isEncryptedSessionFile(data) {
+		passphrase := sessionPassphrase()
+		if passphrase == "" {
+			return nil, errors.New("session file is encrypted but no passphrase was provided (set SCANNER_SESSION_KEY or --session-passphrase)")
+		}
+		return NewEncryptedSessionStore(passphrase).Load(filepath)
+	}
+
 	var session models.Session
 	err = json.Unmarshal(data, &session)
 	if err != nil {
@@ -0,0 +1,135 @@
+package browser
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// sessionFileMagic tags an encrypted session file so LoadSessionFromFile can
+// tell it apart from the legacy plaintext JSON format.
+var sessionFileMagic = [8]byte{'S', 'C', 'A', 'N', 'S', 'E', 'S', '1'}
+
+const (
+	sessionSaltSize  = 16
+	sessionNonceSize = 24 // secretbox nonce size
+	scryptN          = 1 << 15
+	scryptR          = 8
+	scryptP          = 1
+	scryptKeyLen     = 32
+)
+
+// EncryptedSessionStore persists sessions as
+// magic || salt || nonce || ciphertext, where the key is derived from a
+// passphrase via scrypt. This replaces the plaintext JSON that
+// SaveSessionToFile used to write, which was a real credential-theft
+// target on shared build hosts.
+type EncryptedSessionStore struct {
+	passphrase string
+}
+
+// NewEncryptedSessionStore creates a store that encrypts with the given passphrase.
+func NewEncryptedSessionStore(passphrase string) *EncryptedSessionStore {
+	return &EncryptedSessionStore{passphrase: passphrase}
+}
+
+// Save encrypts and writes the session to filepath.
+func (s *EncryptedSessionStore) Save(session *models.Session, filepath string) error {
+	plaintext, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	var salt [sessionSaltSize]byte
+	if _, err := rand.Read(salt[:]); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key, err := s.deriveKey(salt[:])
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	var nonce [sessionNonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(sessionFileMagic)+len(salt)+len(nonce))
+	out = append(out, sessionFileMagic[:]...)
+	out = append(out, salt[:]...)
+	out = append(out, nonce[:]...)
+	out = secretbox.Seal(out, plaintext, &nonce, &key)
+
+	return os.WriteFile(filepath, out, 0600) // 0600 = owner only
+}
+
+// Load reads and decrypts a session previously written by Save.
+func (s *EncryptedSessionStore) Load(filepath string) (*models.Session, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	header := len(sessionFileMagic) + sessionSaltSize + sessionNonceSize
+	if len(data) < header {
+		return nil, errors.New("encrypted session file is truncated")
+	}
+
+	salt := data[len(sessionFileMagic) : len(sessionFileMagic)+sessionSaltSize]
+	var nonce [sessionNonceSize]byte
+	copy(nonce[:], data[len(sessionFileMagic)+sessionSaltSize:header])
+	ciphertext := data[header:]
+
+	key, err := s.deriveKey(salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	plaintext, ok := secretbox.Open(nil, ciphertext, &nonce, &key)
+	if !ok {
+		return nil, errors.New("failed to decrypt session file (wrong passphrase or corrupted file)")
+	}
+
+	var session models.Session
+	if err := json.Unmarshal(plaintext, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal decrypted session: %w", err)
+	}
+	return &session, nil
+}
+
+func (s *EncryptedSessionStore) deriveKey(salt []byte) ([32]byte, error) {
+	var key [32]byte
+	derived, err := scrypt.Key([]byte(s.passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return key, err
+	}
+	copy(key[:], derived)
+	return key, nil
+}
+
+// isEncryptedSessionFile reports whether data starts with sessionFileMagic.
+func isEncryptedSessionFile(data []byte) bool {
+	if len(data) < len(sessionFileMagic) {
+		return false
+	}
+	for i, b := range sessionFileMagic {
+		if data[i] != b {
+			return false
+		}
+	}
+	return true
+}
+
+// sessionPassphrase resolves the passphrase used to encrypt/decrypt session
+// files. It's sourced from SCANNER_SESSION_KEY, which the CLI layer sets
+// from the --session-passphrase flag when provided.
+func sessionPassphrase() string {
+	return os.Getenv("SCANNER_SESSION_KEY")
+}
@@ -0,0 +1,67 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+func TestEncryptedSessionStoreRoundTrip(t *testing.T) {
+	store := NewEncryptedSessionStore("correct-horse-battery-staple")
+	session := &models.Session{Cookies: map[string]string{"sid": "abc123"}}
+
+	path := filepath.Join(t.TempDir(), "session.enc")
+	if err := store.Save(session, path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := store.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Cookies["sid"] != "abc123" {
+		t.Errorf("loaded session Cookies[sid] = %q, want abc123", loaded.Cookies["sid"])
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !isEncryptedSessionFile(data) {
+		t.Errorf("isEncryptedSessionFile = false for a file Save just wrote")
+	}
+}
+
+func TestEncryptedSessionStoreWrongPassphrase(t *testing.T) {
+	session := &models.Session{Cookies: map[string]string{"sid": "abc123"}}
+	path := filepath.Join(t.TempDir(), "session.enc")
+	if err := NewEncryptedSessionStore("right-passphrase").Save(session, path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if _, err := NewEncryptedSessionStore("wrong-passphrase").Load(path); err == nil {
+		t.Errorf("Load with the wrong passphrase succeeded, want an error")
+	}
+}
+
+func TestEncryptedSessionStoreTruncatedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.enc")
+	if err := os.WriteFile(path, []byte("too short"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := NewEncryptedSessionStore("whatever").Load(path); err == nil {
+		t.Errorf("Load accepted a truncated file, want an error")
+	}
+}
+
+func TestIsEncryptedSessionFile(t *testing.T) {
+	if isEncryptedSessionFile([]byte(`{"cookies":{}}`)) {
+		t.Errorf("isEncryptedSessionFile = true for plaintext JSON")
+	}
+	if !isEncryptedSessionFile(append([]byte{'S', 'C', 'A', 'N', 'S', 'E', 'S', '1'}, 0, 0, 0)) {
+		t.Errorf("isEncryptedSessionFile = false for a buffer starting with the magic")
+	}
+}
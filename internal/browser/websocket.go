@@ -8,44 +8,309 @@ import (
 	"time"
 
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/gorilla/websocket"
 	"github.com/playwright-community/playwright-go"
 )
 
 // WSMessage represents a WebSocket message
 type WSMessage struct {
-	Direction string                 // "sent" or "received"
-	Timestamp time.Time
-	Type      string                 // text, binary, ping, pong, close
-	Data      string
-	Parsed    map[string]interface{} // JSON parsed if possible
+	Direction     string // "sent" or "received"
+	Timestamp     time.Time
+	Type          string // text, binary, ping, pong, close
+	Data          string
+	Parsed        map[string]interface{} // JSON parsed if possible
+	Truncated     bool                   // true if Data was cut off at maxMessageSize
+	URL           string                 // WebSocket URL this message belongs to (CDP path and AttachExternalConnection only)
+	Masked        bool                   // true if the frame was sent masked (CDP path only)
+	PayloadLength int                    // original payload length before any truncation (on the JS-shim path, the length after the shim's truncation)
 }
 
+// defaultMaxMessageSize mirrors the 64KB default grpc-websocket-proxy ships
+// with before WithMaxRespBodyBufferSize is used to raise it; payment
+// receipts and base64 3-D Secure blobs routinely exceed it.
+const defaultMaxMessageSize = 64 * 1024
+
 // WSInterceptor captures WebSocket traffic
 type WSInterceptor struct {
 	messages []WSMessage
 	mu       sync.RWMutex // Changed to RWMutex for read/write operations
 	active   bool
 	wsURL    string // NEW: Track WebSocket URL for security checks
+
+	maxMessageSize   int
+	reassembleFrames bool
+	messageHandler   func(WSMessage)
+	signalR          bool // parse SignalR's record-separator-delimited invocation framing
+
+	cdpSession  playwright.CDPSession // non-nil once Enable attaches over CDP
+	requestURLs map[string]string     // CDP requestId -> WebSocket URL
+
+	subscribers map[uint64]wsSubscriber // live Subscribe()/Unsubscribe() registrations
+	nextSubID   uint64
 }
 
+// signalRRecordSeparator is the ASCII Record Separator (0x1E) SignalR uses
+// to delimit each JSON-encoded invocation/handshake message within a single
+// text frame - a frame can and often does carry more than one.
+const signalRRecordSeparator = '\x1e'
+
 // NewWSInterceptor creates a new WebSocket interceptor
 func NewWSInterceptor() *WSInterceptor {
 	return &WSInterceptor{
-		messages: make([]WSMessage, 0),
-		active:   false,
+		messages:       make([]WSMessage, 0),
+		active:         false,
+		maxMessageSize: defaultMaxMessageSize,
 	}
 }
 
-// Enable starts intercepting WebSocket traffic
+// WithMaxMessageSize caps how large a single captured message may be before
+// it's flagged Truncated. A size of 0 disables the cap.
+func (wsi *WSInterceptor) WithMaxMessageSize(size int) *WSInterceptor {
+	wsi.maxMessageSize = size
+	return wsi
+}
+
+// WithFrameReassembly enables reassembly of app-level continuation frames
+// (messages carrying a {fin:false, data} envelope) into a single logical
+// payload before Data/the message handler ever sees them. Off by default
+// since most targets don't chunk at the application layer.
+func (wsi *WSInterceptor) WithFrameReassembly(enabled bool) *WSInterceptor {
+	wsi.reassembleFrames = enabled
+	return wsi
+}
+
+// WithSignalR enables splitting captured text frames on SignalR's record
+// separator into one WSMessage per invocation, so a hub method call like
+// SendPaymentStatus surfaces to GetPaymentMessages/CheckSecurity on its own
+// instead of being buried inside one multiplexed frame. Off by default
+// since most targets aren't SignalR hubs.
+func (wsi *WSInterceptor) WithSignalR(enabled bool) *WSInterceptor {
+	wsi.signalR = enabled
+	return wsi
+}
+
+// SetMessageHandler registers fn to be called synchronously as each message
+// is captured, so scanner.TestWebSocket* checks can react to payloads (e.g.
+// payment receipts) as they arrive instead of polling GetMessages.
+func (wsi *WSInterceptor) SetMessageHandler(fn func(WSMessage)) {
+	wsi.mu.Lock()
+	defer wsi.mu.Unlock()
+	wsi.messageHandler = fn
+}
+
+// Enable starts intercepting WebSocket traffic. It prefers attaching a CDP
+// session and subscribing to the browser's own WebSocket network events,
+// which see every frame in order as it crosses the wire. That only works on
+// Chromium, so on other browsers (or if CDP attachment fails for any other
+// reason) it falls back to the JS shim, which polls window state and can
+// drop frames under load or race with the page's own JS.
 func (wsi *WSInterceptor) Enable(page playwright.Page) error {
 	wsi.active = true
-	
-	// Inject WebSocket interceptor via CDP (Chrome DevTools Protocol) or JS shim
-	// This captures all WebSocket frames
-	_, err := page.Evaluate(`() => {
+
+	if err := wsi.enableCDP(page); err == nil {
+		return nil
+	}
+
+	return wsi.enableJSShim(page)
+}
+
+// enableCDP attaches a Chrome DevTools Protocol session to page and
+// subscribes to the raw WebSocket network events. Each event is handled
+// synchronously on the CDP session's own dispatch goroutine and turned
+// into a WSMessage via recordMessage - there's no polling interval, so
+// bursts of frames faster than 200ms are no longer dropped and frame
+// order is preserved.
+func (wsi *WSInterceptor) enableCDP(page playwright.Page) error {
+	session, err := page.Context().NewCDPSession(page)
+	if err != nil {
+		return fmt.Errorf("CDP not available: %w", err)
+	}
+
+	if _, err := session.Send("Network.enable", nil); err != nil {
+		session.Detach()
+		return fmt.Errorf("Network.enable failed: %w", err)
+	}
+
+	wsi.mu.Lock()
+	wsi.cdpSession = session
+	wsi.requestURLs = make(map[string]string)
+	wsi.mu.Unlock()
+
+	session.On("Network.webSocketCreated", wsi.onWebSocketCreated)
+	session.On("Network.webSocketHandshakeResponseReceived", wsi.onHandshakeResponse)
+	session.On("Network.webSocketFrameSent", func(params map[string]interface{}) { wsi.onFrame("sent", params) })
+	session.On("Network.webSocketFrameReceived", func(params map[string]interface{}) { wsi.onFrame("received", params) })
+	session.On("Network.webSocketClosed", wsi.onWebSocketClosed)
+
+	return nil
+}
+
+// onWebSocketCreated records the requestId->URL mapping CDP uses to tie
+// later frame/close events back to a connection, since those events only
+// carry the requestId.
+func (wsi *WSInterceptor) onWebSocketCreated(params map[string]interface{}) {
+	requestID, _ := params["requestId"].(string)
+	url, _ := params["url"].(string)
+	if requestID == "" {
+		return
+	}
+
+	wsi.mu.Lock()
+	wsi.requestURLs[requestID] = url
+	if wsi.wsURL == "" {
+		wsi.wsURL = url
+	}
+	wsi.mu.Unlock()
+}
+
+// onHandshakeResponse is currently just a hook point - the handshake itself
+// carries no payment-relevant frame data, but subscribing keeps the CDP
+// session from missing a connection's lifecycle entirely.
+func (wsi *WSInterceptor) onHandshakeResponse(params map[string]interface{}) {}
+
+func (wsi *WSInterceptor) onWebSocketClosed(params map[string]interface{}) {
+	requestID, _ := params["requestId"].(string)
+	wsi.mu.Lock()
+	delete(wsi.requestURLs, requestID)
+	wsi.mu.Unlock()
+}
+
+// onFrame handles Network.webSocketFrameSent/Received. response.opcode
+// follows RFC 6455 (1=text, 2=binary, 8=close, 9=ping, 10=pong); response.mask
+// reports whether the frame was masked on the wire.
+func (wsi *WSInterceptor) onFrame(direction string, params map[string]interface{}) {
+	requestID, _ := params["requestId"].(string)
+	response, ok := params["response"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	opcode := 1
+	if op, ok := response["opcode"].(float64); ok {
+		opcode = int(op)
+	}
+	masked, _ := response["mask"].(bool)
+	payloadData, _ := response["payloadData"].(string)
+
+	wsi.mu.RLock()
+	url := wsi.requestURLs[requestID]
+	wsi.mu.RUnlock()
+
+	data := payloadData
+	truncated := false
+	if wsi.maxMessageSize > 0 && len(data) > wsi.maxMessageSize {
+		truncated = true
+		data = data[:wsi.maxMessageSize]
+	}
+
+	msg := WSMessage{
+		Direction:     direction,
+		Timestamp:     time.Now(),
+		Type:          wsOpcodeType(opcode),
+		Data:          data,
+		Truncated:     truncated,
+		URL:           url,
+		Masked:        masked,
+		PayloadLength: len(payloadData),
+	}
+	if msg.Type == "text" {
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &parsed); err == nil {
+			msg.Parsed = parsed
+		}
+	}
+
+	wsi.recordFrame(msg)
+}
+
+// wsOpcodeType maps an RFC 6455 WebSocket opcode to the string WSMessage.Type
+// uses. Continuation frames (opcode 0) are not mapped to the type of the
+// message they continue, since CDP doesn't expose that without reassembly;
+// they fall into the binary default along with any other opcode we don't
+// expect.
+func wsOpcodeType(opcode int) string {
+	switch opcode {
+	case 1:
+		return "text"
+	case 2:
+		return "binary"
+	case 8:
+		return "close"
+	case 9:
+		return "ping"
+	case 10:
+		return "pong"
+	default:
+		return "binary"
+	}
+}
+
+// recordFrame is the common entry point both capture paths funnel a built
+// WSMessage through. When SignalR framing is enabled and msg is a text
+// frame containing the record separator, it's split into one WSMessage per
+// invocation before recording; otherwise msg is recorded as-is.
+func (wsi *WSInterceptor) recordFrame(msg WSMessage) {
+	if !wsi.signalR || msg.Type != "text" || !strings.ContainsRune(msg.Data, signalRRecordSeparator) {
+		wsi.recordMessage(msg)
+		return
+	}
+
+	parts := strings.Split(msg.Data, string(signalRRecordSeparator))
+	recorded := false
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		sub := msg
+		sub.Data = part
+		sub.Parsed = nil
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(part), &parsed); err == nil {
+			sub.Parsed = parsed
+		}
+		wsi.recordMessage(sub)
+		recorded = true
+	}
+
+	if !recorded {
+		wsi.recordMessage(msg)
+	}
+}
+
+// recordMessage appends msg, fires the message handler (if any), and
+// notifies any matching Subscribe() registrations. It's the single place
+// both the CDP path and the JS-shim fallback funnel through so
+// GetMessages/GetPaymentMessages/CheckSecurity/subscribers all see a
+// consistent feed regardless of which path captured a given message.
+func (wsi *WSInterceptor) recordMessage(msg WSMessage) {
+	wsi.mu.Lock()
+	wsi.messages = append(wsi.messages, msg)
+	handler := wsi.messageHandler
+	wsi.mu.Unlock()
+
+	wsi.notifySubscribers(msg)
+
+	if handler != nil {
+		handler(msg)
+	}
+}
+
+// enableJSShim is the pre-CDP capture path, kept as a fallback for browsers
+// (or contexts) CDP attachment doesn't work on. It injects a WebSocket
+// wrapper into the page and polls the last-seen send/receive on a 200ms
+// ticker, so it can drop frames under bursty traffic and doesn't preserve
+// strict send/receive ordering the way the CDP path does.
+func (wsi *WSInterceptor) enableJSShim(page playwright.Page) error {
+	// Inject WebSocket interceptor via a JS shim that records the last sent
+	// and received message on window, polled by pollMessages below.
+	_, err := page.Evaluate(fmt.Sprintf(`() => {
 		// Store original WebSocket
 		if (window._wsInterceptorInjected) return;
 		const OriginalWebSocket = window.WebSocket;
+		window._wsMaxMessageSize = %d;
+		window._wsReassemble = %t;
+		window._wsPartial = {};
 		
 		// Create interceptor
 		window.WebSocket = function(url, protocols) {
@@ -73,9 +338,36 @@ func (wsi *WSInterceptor) Enable(page playwright.Page) error {
 			// Intercept receive
 			ws.addEventListener('message', function(event) {
 				// console.log('[WS] RECV:', event.data);
+				let data = event.data;
+
+				// Reassemble app-level continuation frames: some payment
+				// gateways chunk large receipts/3-D Secure blobs into a
+				// {fin:false, data} envelope sequence instead of relying on
+				// a single 'message' event, which the browser's WebSocket
+				// API would otherwise hide from us anyway.
+				if (window._wsReassemble) {
+					let parsed = null;
+					try { parsed = JSON.parse(data); } catch (e) {}
+					if (parsed && parsed.fin === false && typeof parsed.data === 'string') {
+						window._wsPartial[url] = (window._wsPartial[url] || '') + parsed.data;
+						return;
+					}
+					if (parsed && parsed.fin === true && typeof parsed.data === 'string') {
+						data = (window._wsPartial[url] || '') + parsed.data;
+						delete window._wsPartial[url];
+					}
+				}
+
+				let truncated = false;
+				if (window._wsMaxMessageSize > 0 && data.length > window._wsMaxMessageSize) {
+					truncated = true;
+					data = data.slice(0, window._wsMaxMessageSize);
+				}
+
 				window._lastWSRecv = {
 					timestamp: Date.now(),
-					data: event.data
+					data: data,
+					truncated: truncated
 				};
 			});
 			
@@ -105,22 +397,22 @@ func (wsi *WSInterceptor) Enable(page playwright.Page) error {
 		window.WebSocket.prototype = OriginalWebSocket.prototype;
 		
 		window._wsInterceptorInjected = true;
-	}`)
-	
+	}`, wsi.maxMessageSize, wsi.reassembleFrames))
+
 	if err != nil {
 		return fmt.Errorf("failed to inject WS interceptor: %w", err)
 	}
-	
+
 	// Extract WebSocket URL if connection already exists
 	if urlInfo, err := page.Evaluate(`() => window._wsURL || ''`); err == nil {
 		if url, ok := urlInfo.(string); ok && url != "" {
 			wsi.wsURL = url
 		}
 	}
-	
+
 	// Start polling for messages
 	go wsi.pollMessages(page)
-	
+
 	return nil
 }
 
@@ -128,10 +420,10 @@ func (wsi *WSInterceptor) Enable(page playwright.Page) error {
 func (wsi *WSInterceptor) pollMessages(page playwright.Page) {
 	ticker := time.NewTicker(200 * time.Millisecond)
 	defer ticker.Stop()
-	
+
 	for wsi.active {
 		<-ticker.C
-		
+
 		// Check if page is closed
 		if page.IsClosed() {
 			wsi.active = false
@@ -148,7 +440,7 @@ func (wsi *WSInterceptor) pollMessages(page playwright.Page) {
 				wsi.addMessage("sent", msgMap)
 			}
 		}
-		
+
 		// Get received messages
 		if recv, err := page.Evaluate(`() => {
 			const msg = window._lastWSRecv;
@@ -163,40 +455,110 @@ func (wsi *WSInterceptor) pollMessages(page playwright.Page) {
 }
 
 func (wsi *WSInterceptor) addMessage(direction string, msgMap map[string]interface{}) {
-	wsi.mu.Lock()
-	defer wsi.mu.Unlock()
-	
 	dataStr := ""
 	if data, ok := msgMap["data"].(string); ok {
 		dataStr = data
 	}
-	
+
+	truncated := false
+	if t, ok := msgMap["truncated"].(bool); ok {
+		truncated = t
+	}
+
 	msg := WSMessage{
-		Direction: direction,
-		Timestamp: time.Now(),
-		Type:      "text",
-		Data:      dataStr,
+		Direction:     direction,
+		Timestamp:     time.Now(),
+		Type:          "text",
+		Data:          dataStr,
+		Truncated:     truncated,
+		PayloadLength: len(dataStr),
 	}
-	
+
 	// Try parse JSON
 	var parsed map[string]interface{}
 	if err := json.Unmarshal([]byte(dataStr), &parsed); err == nil {
 		msg.Parsed = parsed
 	}
-	
-	wsi.messages = append(wsi.messages, msg)
+
+	wsi.recordFrame(msg)
 }
 
-// Stop stops the interceptor
+// AttachExternalConnection takes ownership of an already-connected raw
+// WebSocket conn - e.g. a GraphQL subscription socket discovery dialed
+// directly, bypassing the browser entirely - and funnels every frame it
+// receives through the same recordFrame pipeline CDP/JS-shim traffic goes
+// through, so GetPaymentMessages/CheckSecurity see it too. It runs its own
+// read loop in a goroutine and returns immediately; conn is closed once the
+// loop ends (handshake close, error, or Stop never reaches it - the caller
+// is expected to close conn itself if it needs to end the connection).
+func (wsi *WSInterceptor) AttachExternalConnection(conn *websocket.Conn, url string) {
+	go func() {
+		defer conn.Close()
+		for {
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			msg := WSMessage{
+				Direction:     "received",
+				Timestamp:     time.Now(),
+				Type:          wsGorillaMessageType(msgType),
+				Data:          string(data),
+				URL:           url,
+				PayloadLength: len(data),
+			}
+			if msg.Type == "text" {
+				var parsed map[string]interface{}
+				if err := json.Unmarshal(data, &parsed); err == nil {
+					msg.Parsed = parsed
+				}
+			}
+
+			wsi.recordFrame(msg)
+		}
+	}()
+}
+
+// wsGorillaMessageType maps a gorilla/websocket message type constant to the
+// string WSMessage.Type uses, the same mapping wsOpcodeType applies to CDP's
+// RFC 6455 opcodes.
+func wsGorillaMessageType(t int) string {
+	switch t {
+	case websocket.TextMessage:
+		return "text"
+	case websocket.BinaryMessage:
+		return "binary"
+	case websocket.CloseMessage:
+		return "close"
+	case websocket.PingMessage:
+		return "ping"
+	case websocket.PongMessage:
+		return "pong"
+	default:
+		return "binary"
+	}
+}
+
+// Stop stops the interceptor and detaches the CDP session, if one is active.
 func (wsi *WSInterceptor) Stop() {
 	wsi.active = false
+
+	wsi.mu.Lock()
+	session := wsi.cdpSession
+	wsi.cdpSession = nil
+	wsi.mu.Unlock()
+
+	if session != nil {
+		session.Detach()
+	}
 }
 
 // GetMessages returns all captured messages
 func (wsi *WSInterceptor) GetMessages() []WSMessage {
 	wsi.mu.Lock()
 	defer wsi.mu.Unlock()
-	
+
 	// Return copy
 	messages := make([]WSMessage, len(wsi.messages))
 	copy(messages, wsi.messages)
@@ -207,7 +569,7 @@ func (wsi *WSInterceptor) GetMessages() []WSMessage {
 func (wsi *WSInterceptor) GetPaymentMessages() []WSMessage {
 	wsi.mu.Lock()
 	defer wsi.mu.Unlock()
-	
+
 	var paymentMsgs []WSMessage
 	for _, msg := range wsi.messages {
 		if isPaymentMessage(msg) {
@@ -228,10 +590,23 @@ func isPaymentMessage(msg WSMessage) bool {
 		}
 		return false
 	}
-	
+
+	keywords := []string{"payment", "transaction", "amount", "status", "balance"}
+
+	// SignalR invocation messages carry the hub method name as the value of
+	// "target" (e.g. "SendPaymentStatus") rather than as a field name, so
+	// it needs its own check instead of just scanning keys below.
+	if target, ok := msg.Parsed["target"].(string); ok {
+		targetLower := strings.ToLower(target)
+		for _, kw := range keywords {
+			if contains(targetLower, kw) {
+				return true
+			}
+		}
+	}
+
 	// Check JSON keys
 	for key := range msg.Parsed {
-		keywords := []string{"payment", "transaction", "amount", "status", "balance"}
 		for _, kw := range keywords {
 			if contains(key, kw) {
 				return true
@@ -252,15 +627,15 @@ func (wsi *WSInterceptor) GetConnectionInfo(page playwright.Page) map[string]int
 			extensions: window._ws?.extensions || ''
 		};
 	}`)
-	
+
 	if err != nil {
 		return nil
 	}
-	
+
 	if infoMap, ok := info.(map[string]interface{}); ok {
 		return infoMap
 	}
-	
+
 	return nil
 }
 
@@ -268,7 +643,7 @@ func (wsi *WSInterceptor) GetConnectionInfo(page playwright.Page) map[string]int
 func (wsi *WSInterceptor) ExtractSessionToken() string {
 	wsi.mu.Lock()
 	defer wsi.mu.Unlock()
-	
+
 	for _, msg := range wsi.messages {
 		if msg.Parsed != nil {
 			// Common token field names
@@ -280,7 +655,7 @@ func (wsi *WSInterceptor) ExtractSessionToken() string {
 			}
 		}
 	}
-	
+
 	return ""
 }
 
@@ -288,10 +663,10 @@ func (wsi *WSInterceptor) ExtractSessionToken() string {
 func (wsi *WSInterceptor) PrintSummary() {
 	wsi.mu.Lock()
 	defer wsi.mu.Unlock()
-	
+
 	fmt.Printf("\n[WebSocket Traffic Summary]\n")
 	fmt.Printf("Total messages: %d\n", len(wsi.messages))
-	
+
 	sent := 0
 	recv := 0
 	for _, msg := range wsi.messages {
@@ -301,40 +676,39 @@ func (wsi *WSInterceptor) PrintSummary() {
 			recv++
 		}
 	}
-	
+
 	fmt.Printf("Sent: %d | Received: %d\n", sent, recv)
-	
+
 	// Show last 5 messages
 	fmt.Printf("\nLast 5 messages:\n")
 	start := len(wsi.messages) - 5
 	if start < 0 {
 		start = 0
 	}
-	
+
 	for i := start; i < len(wsi.messages); i++ {
 		msg := wsi.messages[i]
 		direction := "←"
 		if msg.Direction == "sent" {
 			direction = "→"
 		}
-		
+
 		preview := msg.Data
 		if len(preview) > 60 {
 			preview = preview[:60] + "..."
 		}
-		
+
 		fmt.Printf("%s [%s] %s\n", direction, msg.Timestamp.Format("15:04:05"), preview)
 	}
 }
 
-
 // CheckSecurity performs security analysis on WebSocket connection
 func (wsi *WSInterceptor) CheckSecurity() []models.Vulnerability {
 	wsi.mu.Lock()
 	defer wsi.mu.Unlock()
-	
+
 	vulns := []models.Vulnerability{}
-	
+
 	// 1. WSS vs WS check (Cleartext Transmission)
 	if wsi.wsURL != "" && strings.HasPrefix(wsi.wsURL, "ws://") {
 		vulns = append(vulns, models.Vulnerability{
@@ -360,7 +734,7 @@ const ws = new WebSocket('wss://example.com/socket'); // Secure
 			},
 		})
 	}
-	
+
 	// 2. Check authentication token presence in messages
 	hasAuth := false
 	for _, msg := range wsi.messages {
@@ -369,7 +743,7 @@ const ws = new WebSocket('wss://example.com/socket'); // Secure
 			break
 		}
 	}
-	
+
 	if len(wsi.messages) > 0 && !hasAuth {
 		vulns = append(vulns, models.Vulnerability{
 			Type:        "WebSocket Authentication",
@@ -395,7 +769,7 @@ ws.send(JSON.stringify({
 			},
 		})
 	}
-	
+
 	// 3. Check message size (buffer overflow risk)
 	const maxMessageSize = 10 * 1024 * 1024 // 10MB
 	for _, msg := range wsi.messages {
@@ -416,11 +790,33 @@ ws.send(JSON.stringify({
 			break // Only report once
 		}
 	}
-	
+
+	// 3b. Flag captures truncated by maxMessageSize, since a partial
+	// payment receipt or 3-D Secure blob can hide the fields the rest of
+	// this report's findings are drawn from.
+	for _, msg := range wsi.messages {
+		if msg.Truncated {
+			vulns = append(vulns, models.Vulnerability{
+				Type:        "WebSocket Message Size",
+				Severity:    "LOW",
+				Title:       "WebSocket Capture Truncated",
+				Description: fmt.Sprintf("A WebSocket message exceeded the interceptor's %d-byte capture cap and was truncated before analysis. Raise WSInterceptor.WithMaxMessageSize or enable WithFrameReassembly if the target chunks large payloads.", wsi.maxMessageSize),
+				Proof:       fmt.Sprintf("Truncated message at %s", msg.Timestamp.Format(time.RFC3339)),
+				Timestamp:   time.Now(),
+				CWE:         "CWE-770",
+				CVSSScore:   3.1,
+				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N",
+				Confidence:  "Low",
+				Remediation: "Increase the interceptor's capture cap (or enable frame reassembly) so large payment payloads are fully analyzed rather than silently cut off.",
+			})
+			break // Only report once
+		}
+	}
+
 	// 4. Check for message injection without session
 	// (This would require actually testing, not just analyzing intercepted traffic)
 	// Leaving as TODO for future enhancement
-	
+
 	return vulns
 }
 
@@ -448,7 +844,7 @@ func containsAuthToken(msg WSMessage) bool {
 			}
 		}
 	}
-	
+
 	// Check in string data
 	authKeywords := []string{"token", "authtoken", "sessiontoken", "bearer"}
 	dataLower := strings.ToLower(msg.Data)
@@ -457,8 +853,6 @@ func containsAuthToken(msg WSMessage) bool {
 			return true
 		}
 	}
-	
+
 	return false
 }
-
-
@@ -0,0 +1,90 @@
+package browser
+
+// wsSubscriptionBuffer bounds how many unconsumed matching messages a
+// subscription channel holds before recordMessage starts dropping them
+// for that subscriber - a slow consumer shouldn't block frame capture for
+// everyone else.
+const wsSubscriptionBuffer = 32
+
+// wsSubscriber is one registered predicate/channel pair. Kept unexported
+// since callers only ever see it through the WSSubscription Subscribe
+// returns.
+type wsSubscriber struct {
+	predicate func(WSMessage) bool
+	ch        chan WSMessage
+}
+
+// WSSubscription is a live, predicate-filtered view over a WSInterceptor's
+// captured traffic - similar to a pub/sub JSON-RPC notification client.
+// Every message recordFrame processes from the moment Subscribe is called
+// onward is checked against the predicate; matches are delivered on
+// Channel until Unsubscribe closes it. Past messages are not replayed -
+// callers that also need history should pair it with GetMessages/
+// GetPaymentMessages.
+type WSSubscription struct {
+	Channel chan WSMessage
+
+	wsi *WSInterceptor
+	id  uint64
+}
+
+// Unsubscribe stops delivering messages to sub.Channel and closes it.
+// Safe to call more than once.
+func (sub *WSSubscription) Unsubscribe() {
+	sub.wsi.unsubscribe(sub.id)
+}
+
+// Subscribe registers predicate and returns a WSSubscription whose
+// Channel receives a copy of every subsequently captured message for
+// which predicate returns true, until Unsubscribe is called.
+func (wsi *WSInterceptor) Subscribe(predicate func(WSMessage) bool) *WSSubscription {
+	wsi.mu.Lock()
+	defer wsi.mu.Unlock()
+
+	if wsi.subscribers == nil {
+		wsi.subscribers = make(map[uint64]wsSubscriber)
+	}
+	wsi.nextSubID++
+	id := wsi.nextSubID
+
+	ch := make(chan WSMessage, wsSubscriptionBuffer)
+	wsi.subscribers[id] = wsSubscriber{predicate: predicate, ch: ch}
+
+	return &WSSubscription{Channel: ch, wsi: wsi, id: id}
+}
+
+// unsubscribe removes and closes the subscriber keyed by id, if it's
+// still registered.
+func (wsi *WSInterceptor) unsubscribe(id uint64) {
+	wsi.mu.Lock()
+	defer wsi.mu.Unlock()
+
+	sub, ok := wsi.subscribers[id]
+	if !ok {
+		return
+	}
+	delete(wsi.subscribers, id)
+	close(sub.ch)
+}
+
+// notifySubscribers delivers msg to every subscriber whose predicate
+// matches it. Sends are non-blocking - a full channel (a subscriber that
+// isn't draining fast enough) drops the message rather than stalling
+// frame capture.
+func (wsi *WSInterceptor) notifySubscribers(msg WSMessage) {
+	wsi.mu.Lock()
+	var matched []chan WSMessage
+	for _, sub := range wsi.subscribers {
+		if sub.predicate(msg) {
+			matched = append(matched, sub.ch)
+		}
+	}
+	wsi.mu.Unlock()
+
+	for _, ch := range matched {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package discovery
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// Aggregator fans out to every enabled PassiveSource concurrently, applying
+// a per-source rate limit and an on-disk response cache so repeated scans
+// don't hammer free third-party APIs, then merges and deduplicates the
+// combined results.
+type Aggregator struct {
+	Sources []PassiveSource
+
+	logger   *utils.Logger
+	cache    *passiveCache
+	rps      int
+	limiters map[string]*utils.RateLimiter
+}
+
+// NewAggregator builds an Aggregator over sources. rps is the per-source
+// token-bucket rate (defaults to 1 if <= 0); cacheDir is where responses
+// are cached, keyed by (source, domain, date) - see passiveCache. Each
+// source gets one *utils.RateLimiter, built here and shared across every
+// searchOne call for that source (Search can invoke the same source
+// concurrently once crt.sh chains extra domains into it), so the
+// per-source rate limit is actually enforced across concurrent requests
+// instead of each call getting its own fresh bucket.
+func NewAggregator(sources []PassiveSource, rps int, cacheDir string) *Aggregator {
+	if rps <= 0 {
+		rps = 1
+	}
+	limiters := make(map[string]*utils.RateLimiter, len(sources))
+	for _, source := range sources {
+		limiters[source.Name()] = utils.NewRateLimiter(rps)
+	}
+	return &Aggregator{
+		Sources:  sources,
+		logger:   utils.NewLogger(true),
+		cache:    newPassiveCache(cacheDir),
+		rps:      rps,
+		limiters: limiters,
+	}
+}
+
+// maxChainedSubdomains caps how many crt.sh-discovered subdomains get
+// re-queried against the other sources, so a domain with thousands of
+// certificate SANs doesn't turn one scan into thousands of API calls.
+const maxChainedSubdomains = 5
+
+// Search queries every source for domain concurrently (each under its own
+// rate limiter, so one slow/erroring source doesn't throttle the others),
+// checking the on-disk cache first and populating it after a live query.
+// If a CrtSh source is configured, it runs first and its discovered
+// subdomains are fed back into every other source as additional domains to
+// query. Errors from individual sources are logged, not returned - a
+// passive discovery source being down shouldn't fail the whole scan.
+func (a *Aggregator) Search(ctx context.Context, domain string) []models.Endpoint {
+	domains := []string{domain}
+
+	var crtSh PassiveSource
+	var rest []PassiveSource
+	for _, source := range a.Sources {
+		if _, ok := source.(*CrtSh); ok {
+			crtSh = source
+			continue
+		}
+		rest = append(rest, source)
+	}
+
+	var results []models.Endpoint
+	if crtSh != nil {
+		subdomainEndpoints := a.searchOne(ctx, crtSh, domain)
+		results = append(results, subdomainEndpoints...)
+		domains = append(domains, chainedSubdomains(subdomainEndpoints, domain)...)
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, d := range domains {
+		for _, source := range rest {
+			wg.Add(1)
+			go func(source PassiveSource, domain string) {
+				defer wg.Done()
+				eps := a.searchOne(ctx, source, domain)
+				mu.Lock()
+				results = append(results, eps...)
+				mu.Unlock()
+			}(source, d)
+		}
+	}
+
+	wg.Wait()
+	return DeduplicateEndpoints(results)
+}
+
+// chainedSubdomains extracts up to maxChainedSubdomains distinct subdomain
+// hostnames from crt.sh's "subdomain"-typed endpoints, excluding the bare
+// domain itself (already queried).
+func chainedSubdomains(endpoints []models.Endpoint, domain string) []string {
+	var subdomains []string
+	for _, ep := range endpoints {
+		host := strings.TrimPrefix(strings.TrimPrefix(ep.URL, "https://"), "http://")
+		if host == "" || host == domain {
+			continue
+		}
+		subdomains = append(subdomains, host)
+		if len(subdomains) >= maxChainedSubdomains {
+			break
+		}
+	}
+	return subdomains
+}
+
+// searchOne runs a single source's cache-then-live lookup.
+func (a *Aggregator) searchOne(ctx context.Context, source PassiveSource, domain string) []models.Endpoint {
+	name := source.Name()
+	if cached, ok := a.cache.get(name, domain); ok {
+		a.logger.Debug("%s: using cached results for %s", name, domain)
+		return cached
+	}
+
+	if limited, ok := source.(rateLimitedSource); ok {
+		limited.SetRateLimiter(a.limiters[name])
+	}
+
+	eps, err := source.Search(ctx, domain)
+	if err != nil {
+		a.logger.Error("%s search failed: %v", name, err)
+		return nil
+	}
+
+	if err := a.cache.set(name, domain, eps); err != nil {
+		a.logger.Debug("%s: failed to write cache: %v", name, err)
+	}
+	return eps
+}
+
+// rateLimitedSource is implemented by PassiveSources that accept a shared
+// *utils.RateLimiter (every one in this package does, via SetRateLimiter,
+// matching the convention discovery.Crawler/PathBruteForcer already use).
+type rateLimitedSource interface {
+	SetRateLimiter(rl *utils.RateLimiter)
+}
@@ -2,7 +2,12 @@ package discovery
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
@@ -13,13 +18,35 @@ import (
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
 )
 
+const (
+	// baselineSampleSize is how many random, almost-certainly-404 paths
+	// Start probes before brute forcing, to fingerprint the server's
+	// soft-404 response (status/length/body all identical across requests
+	// that don't correspond to a real path).
+	baselineSampleSize = 4
+	// maxRecursionDepth bounds how many directory-index hits deep Start
+	// will re-run the wordlist against, so a misbehaving target that
+	// serves an index at every level can't make this run forever.
+	maxRecursionDepth = 2
+	// maxBodyBytes caps how much of each response body is read for
+	// hashing/index-detection, so a huge response doesn't blow up memory
+	// for every candidate path.
+	maxBodyBytes = 64 * 1024
+)
+
 // PathBruteForcer handles common path discovery
 type PathBruteForcer struct {
-	BaseURL     string
+	BaseURL      string
 	WordlistPath string
-	Client      *http.Client
-	logger      *utils.Logger
-	concurrency int
+	// WordlistSources are additional wordlists merged in alongside
+	// WordlistPath and the built-in payment-oriented path list - each may
+	// be a local file path or an http(s) URL.
+	WordlistSources []string
+	Client          *http.Client
+	logger          *utils.Logger
+	concurrency     int
+	rateLimiter     *utils.RateLimiter
+	session         *models.Session
 }
 
 // NewPathBruteForcer creates a new brute forcer
@@ -32,95 +59,248 @@ func NewPathBruteForcer(baseURL, wordlistPath string) *PathBruteForcer {
 	}
 }
 
+// SetRateLimiter attaches a shared rate limiter so worker requests respect
+// the same adaptive throttling applied to the rest of the scan.
+func (p *PathBruteForcer) SetRateLimiter(rl *utils.RateLimiter) {
+	p.rateLimiter = rl
+}
+
+// SetSession attaches the session whose TLSConfig (client cert, CA bundle,
+// SNI override) worker requests should present, for targets that require
+// mutual TLS just to be scanned at all. A nil session behaves exactly like
+// not calling SetSession.
+func (p *PathBruteForcer) SetSession(session *models.Session) {
+	p.session = session
+}
+
+// SetWordlistSources attaches extra wordlist sources (local files or
+// http(s) URLs) merged in alongside WordlistPath and the built-in
+// payment-oriented path list.
+func (p *PathBruteForcer) SetWordlistSources(sources []string) {
+	p.WordlistSources = sources
+}
+
+// baselineSignature is the (status, length, body hash) fingerprint of a
+// response, used both to record what a soft-404 looks like on this target
+// and to describe a real candidate hit for the same comparison.
+type baselineSignature struct {
+	statusCode    int
+	contentLength int64
+	bodyHash      string
+}
+
 // Start begins the brute force process
 func (p *PathBruteForcer) Start() ([]models.Endpoint, error) {
 	p.logger.Info("Starting common path discovery on %s", p.BaseURL)
-	
-	paths, err := p.loadWordlist()
+
+	paths, err := p.loadPaths()
 	if err != nil {
 		return nil, err
 	}
-	
-	p.logger.Info("Loaded %d paths from wordlist", len(paths))
-	
+
+	p.logger.Info("Loaded %d paths from %d source(s)", len(paths), 1+len(p.WordlistSources))
+
+	client := utils.NewHTTPClientForSession(10*time.Second, p.session)
+
+	baseline := p.calibrateBaseline(client)
+	p.logger.Debug("Calibrated %d baseline soft-404 signature(s)", len(baseline))
+
+	endpoints := p.bruteForceDir(client, baseline, "", paths, 0)
+
+	p.logger.Success("Common path discovery found %d endpoints", len(endpoints))
+	return endpoints, nil
+}
+
+// calibrateBaseline requests a handful of random, almost-certainly-404
+// paths up front and records their response signature, so bruteForceDir can
+// reject candidates that come back looking identical (the classic
+// ffuf-style soft-404 filter for servers that return 200 for everything).
+func (p *PathBruteForcer) calibrateBaseline(client *http.Client) []baselineSignature {
+	var signatures []baselineSignature
+
+	for i := 0; i < baselineSampleSize; i++ {
+		url := p.BaseURL + randomUnlikelyPath()
+		p.rateLimiter.Wait(url)
+		resp, err := client.Get(url)
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+		resp.Body.Close()
+		p.rateLimiter.RecordResponse(url, resp.StatusCode)
+
+		signatures = append(signatures, baselineSignature{
+			statusCode:    resp.StatusCode,
+			contentLength: responseLength(resp, body),
+			bodyHash:      hashBody(body),
+		})
+	}
+
+	return signatures
+}
+
+// bruteForceDir brute forces paths under prefix (relative to p.BaseURL) and,
+// for any hit that looks like a directory index, recurses into it up to
+// maxRecursionDepth.
+func (p *PathBruteForcer) bruteForceDir(client *http.Client, baseline []baselineSignature, prefix string, paths []string, depth int) []models.Endpoint {
 	endpoints := make([]models.Endpoint, 0)
-	
-	// Worker pool
+
 	jobs := make(chan string, len(paths))
 	results := make(chan *models.Endpoint, len(paths))
+	recurseDirs := make(chan string, len(paths))
 	var wg sync.WaitGroup
-	
-	// Start workers
+
 	for i := 0; i < p.concurrency; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			client := utils.NewHTTPClient(10 * time.Second)
-			
+
 			for path := range jobs {
-				url := fmt.Sprintf("%s%s", p.BaseURL, path)
-				
-				// Use HEAD request first for speed
-				resp, err := client.Head(url)
+				url := fmt.Sprintf("%s%s%s", p.BaseURL, prefix, path)
+
+				p.rateLimiter.Wait(url)
+				resp, err := client.Get(url)
 				if err != nil {
-					// Fallback to GET if HEAD fails (some servers block HEAD)
-					resp, err = client.Get(url)
-					if err != nil {
-						continue
-					}
+					continue
+				}
+				body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+				resp.Body.Close()
+				p.rateLimiter.RecordResponse(url, resp.StatusCode)
+
+				if resp.StatusCode == 404 {
+					continue
+				}
+
+				sig := baselineSignature{
+					statusCode:    resp.StatusCode,
+					contentLength: responseLength(resp, body),
+					bodyHash:      hashBody(body),
+				}
+				if matchesBaseline(baseline, sig) {
+					continue
 				}
-				defer resp.Body.Close()
-				
-				// Check for valid status codes (200, 401, 403, 302, etc)
-				// 404 usually means not found, but sometimes custom 404s exist. 
-				// For now, assume 404 is not found.
-				if resp.StatusCode != 404 {
-					p.logger.Debug("Found: %s [%d]", url, resp.StatusCode)
-					
-					results <- &models.Endpoint{
-						URL:          url,
-						Method:       "GET", // Default assumption
-						Type:         "common_path",
-						Source:       "wordlist",
-						DiscoveredAt: time.Now(),
-					}
+
+				p.logger.Debug("Found: %s [%d]", url, resp.StatusCode)
+
+				results <- &models.Endpoint{
+					URL:           url,
+					Method:        "GET", // Default assumption
+					Type:          "common_path",
+					Source:        "wordlist",
+					DiscoveredAt:  time.Now(),
+					ContentLength: sig.contentLength,
+					BodyHash:      sig.bodyHash,
+					ServerHeader:  resp.Header.Get("Server"),
+				}
+
+				if resp.StatusCode == 200 && looksLikeDirectoryIndex(body) {
+					recurseDirs <- prefix + strings.TrimSuffix(path, "/") + "/"
 				}
 			}
 		}()
 	}
-	
-	// Send jobs
+
 	for _, path := range paths {
 		jobs <- path
 	}
 	close(jobs)
-	
-	// Wait for workers in separate goroutine
+
 	go func() {
 		wg.Wait()
 		close(results)
+		close(recurseDirs)
 	}()
-	
-	// Collect results
+
 	for ep := range results {
 		if ep != nil {
 			endpoints = append(endpoints, *ep)
 		}
 	}
-	
-	p.logger.Success("Common path discovery found %d endpoints", len(endpoints))
-	return endpoints, nil
+
+	var subdirs []string
+	for dir := range recurseDirs {
+		subdirs = append(subdirs, dir)
+	}
+
+	if depth+1 < maxRecursionDepth {
+		for _, dir := range subdirs {
+			endpoints = append(endpoints, p.bruteForceDir(client, baseline, dir, paths, depth+1)...)
+		}
+	}
+
+	return endpoints
+}
+
+// loadPaths merges WordlistPath, the built-in payment-oriented path list,
+// and every WordlistSources entry into one deduplicated path list.
+func (p *PathBruteForcer) loadPaths() ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+
+	add := func(candidates []string) {
+		for _, path := range candidates {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	local, err := p.loadLocalFile(p.WordlistPath)
+	if err != nil {
+		return nil, err
+	}
+	add(local)
+	add(embeddedPaymentPaths)
+
+	for _, source := range p.WordlistSources {
+		extra, err := p.loadSource(source)
+		if err != nil {
+			p.logger.Warn("Skipping wordlist source %s: %v", source, err)
+			continue
+		}
+		add(extra)
+	}
+
+	return paths, nil
 }
 
-func (p *PathBruteForcer) loadWordlist() ([]string, error) {
-	file, err := os.Open(p.WordlistPath)
+func (p *PathBruteForcer) loadSource(source string) ([]string, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return p.loadRemoteFile(source)
+	}
+	return p.loadLocalFile(source)
+}
+
+func (p *PathBruteForcer) loadLocalFile(path string) ([]string, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open wordlist: %w", err)
 	}
 	defer file.Close()
-	
+
+	return parseWordlist(file)
+}
+
+func (p *PathBruteForcer) loadRemoteFile(url string) ([]string, error) {
+	client := utils.NewHTTPClientForSession(10*time.Second, p.session)
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching wordlist %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching wordlist %s: status %d", url, resp.StatusCode)
+	}
+
+	return parseWordlist(resp.Body)
+}
+
+// parseWordlist reads one path per line, skipping blanks and #-comments
+// and normalizing every entry to start with "/".
+func parseWordlist(r io.Reader) ([]string, error) {
 	var paths []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line != "" && !strings.HasPrefix(line, "#") {
@@ -130,6 +310,56 @@ func (p *PathBruteForcer) loadWordlist() ([]string, error) {
 			paths = append(paths, line)
 		}
 	}
-	
+
 	return paths, scanner.Err()
 }
+
+// randomUnlikelyPath generates a path calibrateBaseline can safely assume
+// doesn't exist on the target, so its response describes a soft-404.
+func randomUnlikelyPath() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "/" + hex.EncodeToString(b) + "-scanner-probe-404"
+}
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseLength prefers the declared Content-Length (so a HEAD-like
+// truncated body read doesn't understate it), falling back to the bytes
+// actually read when the header is absent or chunked (-1).
+func responseLength(resp *http.Response, body []byte) int64 {
+	if resp.ContentLength >= 0 {
+		return resp.ContentLength
+	}
+	return int64(len(body))
+}
+
+func matchesBaseline(baseline []baselineSignature, sig baselineSignature) bool {
+	for _, b := range baseline {
+		if b == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// directoryIndexMarkers are substrings that show up in the default
+// autoindex pages Apache/nginx/etc. generate for a bare directory listing.
+var directoryIndexMarkers = [][]byte{
+	[]byte("Index of /"),
+	[]byte("<title>Index of"),
+	[]byte("Directory Listing For"),
+	[]byte("Parent Directory</a>"),
+}
+
+func looksLikeDirectoryIndex(body []byte) bool {
+	for _, marker := range directoryIndexMarkers {
+		if bytes.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,109 +6,165 @@ import (
 
 	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/reporter"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/playwright-community/playwright-go"
 )
 
+// crawlJob is one URL/depth pair waiting to be crawled.
+type crawlJob struct {
+	url   string
+	depth int
+}
+
 // Crawler handles website crawling to discover endpoints
 type Crawler struct {
 	BaseURL     string
 	MaxDepth    int
 	Concurrency int
-	Browser     *browser.Browser
+	Pool        *browser.ContextPool
 	visited     sync.Map
 	endpoints   []models.Endpoint
 	mu          sync.Mutex
+	jobsWG      sync.WaitGroup
 	logger      *utils.Logger
+	events      chan<- reporter.Event
 }
 
-// NewCrawler creates a new crawler instance
-func NewCrawler(baseURL string, maxDepth int, br *browser.Browser) *Crawler {
+// NewCrawler creates a new crawler instance. It no longer holds a raw
+// *browser.Browser - navigation happens through pool, a ContextPool of
+// isolated browser contexts, so concurrency workers can crawl URLs
+// in parallel without fighting over a single page.
+func NewCrawler(baseURL string, maxDepth int, concurrency int, pool *browser.ContextPool) *Crawler {
+	if concurrency < 1 {
+		concurrency = 1
+	}
 	return &Crawler{
 		BaseURL:     baseURL,
 		MaxDepth:    maxDepth,
-		Concurrency: 5,
-		Browser:     br,
+		Concurrency: concurrency,
+		Pool:        pool,
 		logger:      utils.NewLogger(true), // Default to verbose for now
 		endpoints:   make([]models.Endpoint, 0),
 	}
 }
 
+// SetEventStream wires a channel that addEndpoint publishes to as each
+// endpoint is discovered, so a reporter.StreamWriter can emit it before the
+// crawl finishes instead of only at the end via the []models.Endpoint
+// Start returns.
+func (c *Crawler) SetEventStream(events chan<- reporter.Event) {
+	c.events = events
+}
+
 // Start begins the crawling process
 func (c *Crawler) Start() ([]models.Endpoint, error) {
-	c.logger.Info("Starting crawl on %s (Depth: %d)", c.BaseURL, c.MaxDepth)
-	
-	// Normalize base URL
+	c.logger.Info("Starting crawl on %s (Depth: %d, Concurrency: %d)", c.BaseURL, c.MaxDepth, c.Concurrency)
+
 	baseURL := utils.NormalizeURL(c.BaseURL)
-	
-	// Start crawling from base URL
-	c.crawlURL(baseURL, 0)
-	
+
+	jobs := make(chan crawlJob, c.Concurrency*4)
+
+	var workers sync.WaitGroup
+	for i := 0; i < c.Concurrency; i++ {
+		workers.Add(1)
+		go c.worker(jobs, &workers)
+	}
+
+	c.enqueue(jobs, baseURL, 0)
+
+	// Close the jobs channel once every in-flight job (including ones
+	// enqueued while crawling) has finished, so workers exit cleanly.
+	go func() {
+		c.jobsWG.Wait()
+		close(jobs)
+	}()
+
+	workers.Wait()
+
 	return c.endpoints, nil
 }
 
-func (c *Crawler) crawlURL(targetURL string, depth int) {
-	// Check depth limit
+func (c *Crawler) worker(jobs <-chan crawlJob, workers *sync.WaitGroup) {
+	defer workers.Done()
+	for job := range jobs {
+		c.crawlURL(jobs, job.url, job.depth)
+		c.jobsWG.Done()
+	}
+}
+
+// enqueue records url as visited and schedules it for crawling. Sending
+// happens in its own goroutine so a full jobs channel never blocks a
+// worker that's trying to enqueue the links it just found.
+func (c *Crawler) enqueue(jobs chan<- crawlJob, targetURL string, depth int) {
 	if depth > c.MaxDepth {
 		return
 	}
-	
-	// Check if already visited
 	if _, loaded := c.visited.LoadOrStore(targetURL, true); loaded {
 		return
 	}
-	
+
+	c.jobsWG.Add(1)
+	go func() {
+		jobs <- crawlJob{url: targetURL, depth: depth}
+	}()
+}
+
+func (c *Crawler) crawlURL(jobs chan<- crawlJob, targetURL string, depth int) {
+	page := c.Pool.Acquire()
+	defer c.Pool.Release(page)
+
 	c.logger.Debug("Crawling: %s", targetURL)
-	
-	// Navigate to page
-	// Note: In a real concurrent crawler, we'd need multiple browser contexts/pages
-	// For this single-browser implementation, we crawl sequentially or need a pool
-	err := c.Browser.Navigate(targetURL)
+
+	// Navigate page
+	_, err := page.Goto(targetURL, playwright.PageGotoOptions{
+		WaitUntil: playwright.WaitUntilStateNetworkidle,
+		Timeout:   playwright.Float(30000),
+	})
 	if err != nil {
 		c.logger.Error("Failed to navigate to %s: %v", targetURL, err)
 		return
 	}
-	
+
 	// Add current page as endpoint
 	c.addEndpoint(targetURL, "GET", "page")
-	
+
 	// Extract links
-	links, err := c.extractLinks()
+	links, err := c.extractLinks(page)
 	if err != nil {
 		c.logger.Error("Failed to extract links from %s: %v", targetURL, err)
 		return
 	}
-	
+
 	// Process links
 	for _, link := range links {
 		// Normalize
 		link = utils.NormalizeURL(link)
-		
+
 		// Only follow links in same domain
 		if utils.IsSameDomain(c.BaseURL, link) {
 			// Check if payment related
 			if utils.IsPaymentRelated(link) {
 				c.addEndpoint(link, "GET", "payment_page")
 			}
-			
-			// Recurse
-			c.crawlURL(link, depth+1)
+
+			// Schedule for crawling by whichever worker picks it up next
+			c.enqueue(jobs, link, depth+1)
 		}
 	}
 }
 
-func (c *Crawler) extractLinks() ([]string, error) {
-	page := c.Browser.GetPage()
-	
+func (c *Crawler) extractLinks(page playwright.Page) ([]string, error) {
 	// Execute JS to get all hrefs
 	result, err := page.Evaluate(`() => {
 		const links = Array.from(document.querySelectorAll('a'));
 		return links.map(a => a.href).filter(href => href.startsWith('http'));
 	}`)
-	
+
 	if err != nil {
 		return nil, err
 	}
-	
+
 	var links []string
 	if linkList, ok := result.([]interface{}); ok {
 		for _, l := range linkList {
@@ -117,19 +173,16 @@ func (c *Crawler) extractLinks() ([]string, error) {
 			}
 		}
 	}
-	
+
 	return links, nil
 }
 
 func (c *Crawler) addEndpoint(urlStr, method, eType string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	
 	// Check if payment related if type is generic
 	if eType == "page" && utils.IsPaymentRelated(urlStr) {
 		eType = "payment_page"
 	}
-	
+
 	endpoint := models.Endpoint{
 		URL:          urlStr,
 		Method:       method,
@@ -137,8 +190,14 @@ func (c *Crawler) addEndpoint(urlStr, method, eType string) {
 		Source:       "crawl",
 		DiscoveredAt: time.Now(),
 	}
-	
+
+	c.mu.Lock()
 	c.endpoints = append(c.endpoints, endpoint)
+	c.mu.Unlock()
+
+	if c.events != nil {
+		c.events <- reporter.Event{Kind: reporter.EventEndpoint, Endpoint: &endpoint}
+	}
 }
 
 // Helper to get all discovered endpoints
@@ -0,0 +1,107 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// CrtSh harvests subdomains for a domain from crt.sh's certificate
+// transparency log search, rather than finding endpoints directly.
+// Aggregator queries it first and re-runs the other sources against each
+// subdomain it turns up, the same way a manual recon pass would chain
+// "find subdomains" into "find endpoints per subdomain".
+type CrtSh struct {
+	Client      *http.Client
+	logger      *utils.Logger
+	rateLimiter *utils.RateLimiter
+}
+
+// NewCrtSh creates a new crt.sh client.
+func NewCrtSh() *CrtSh {
+	return &CrtSh{
+		Client: utils.NewHTTPClient(30 * time.Second),
+		logger: utils.NewLogger(true),
+	}
+}
+
+// SetRateLimiter attaches a shared rate limiter, same convention as
+// WaybackMachine.SetRateLimiter.
+func (c *CrtSh) SetRateLimiter(rl *utils.RateLimiter) {
+	c.rateLimiter = rl
+}
+
+// Name identifies this source for Aggregator's cache keys and logs.
+func (c *CrtSh) Name() string {
+	return "crtsh"
+}
+
+type crtShEntry struct {
+	NameValue string `json:"name_value"`
+}
+
+// Search returns one models.Endpoint per distinct subdomain crt.sh has
+// logged a certificate for, Type "subdomain" - these aren't endpoints to
+// scan directly, but input for Aggregator to re-query the other sources
+// with.
+func (c *CrtSh) Search(ctx context.Context, domain string) ([]models.Endpoint, error) {
+	c.logger.Info("Querying crt.sh for subdomains of %s...", domain)
+
+	apiURL := fmt.Sprintf("https://crt.sh/?q=%%25.%s&output=json", domain)
+
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.WaitContext(ctx, apiURL); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query crt.sh: %w", err)
+	}
+	defer resp.Body.Close()
+	if c.rateLimiter != nil {
+		c.rateLimiter.RecordResponse(apiURL, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("crt.sh returned status %d", resp.StatusCode)
+	}
+
+	var entries []crtShEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode crt.sh response: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	endpoints := make([]models.Endpoint, 0)
+	for _, entry := range entries {
+		for _, name := range strings.Split(entry.NameValue, "\n") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			name = strings.TrimPrefix(name, "*.")
+			if name == "" || seen[name] || !strings.HasSuffix(name, domain) {
+				continue
+			}
+			seen[name] = true
+			endpoints = append(endpoints, models.Endpoint{
+				URL:          "https://" + name,
+				Method:       "GET",
+				Type:         "subdomain",
+				Source:       c.Name(),
+				DiscoveredAt: time.Now(),
+			})
+		}
+	}
+
+	c.logger.Success("crt.sh found %d subdomains", len(endpoints))
+	return endpoints, nil
+}
@@ -1,27 +1,66 @@
 package discovery
 
 import (
+	"net/url"
+	"sort"
+	"strings"
+
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
 )
 
-// DeduplicateEndpoints removes duplicate endpoints from the list
+// DeduplicateEndpoints removes duplicate endpoints from the list. Two
+// endpoints are the same if they share a Method and a normalizeURL result -
+// so "GET /pay?b=2&a=1#top" and "GET /pay?a=1&b=2" collapse to one entry
+// even though passive sources rarely agree on fragment/query-order.
 func DeduplicateEndpoints(endpoints []models.Endpoint) []models.Endpoint {
 	seen := make(map[string]bool)
 	unique := make([]models.Endpoint, 0)
-	
+
 	for _, ep := range endpoints {
-		// Create a unique key based on URL and Method
-		key := ep.Method + ":" + ep.URL
-		
+		key := ep.Method + ":" + normalizeURL(ep.URL)
+
 		if !seen[key] {
 			seen[key] = true
 			unique = append(unique, ep)
 		}
 	}
-	
+
 	return unique
 }
 
+// normalizeURL strips the fragment and sorts query parameters so
+// differently-ordered/fragment-bearing copies of the same URL (common
+// across CommonCrawl/URLScan/Wayback, which each record crawl artifacts
+// like #! or reordered UTM params) dedupe together. Falls back to the
+// original string if it doesn't parse as a URL.
+func normalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Fragment = ""
+
+	if u.RawQuery != "" {
+		values := u.Query()
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var pairs []string
+		for _, k := range keys {
+			sort.Strings(values[k])
+			for _, v := range values[k] {
+				pairs = append(pairs, url.QueryEscape(k)+"="+url.QueryEscape(v))
+			}
+		}
+		u.RawQuery = strings.Join(pairs, "&")
+	}
+
+	return u.String()
+}
+
 // MergeEndpoints merges multiple slices of endpoints and deduplicates them
 func MergeEndpoints(endpointSlices ...[]models.Endpoint) []models.Endpoint {
 	var allEndpoints []models.Endpoint
@@ -3,19 +3,44 @@ package discovery
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/gorilla/websocket"
 )
 
+// graphqlSubscriptionProtocols are tried in order against every discovered
+// GraphQL endpoint: graphql-ws's successor, graphql-transport-ws, first
+// (it's what current Apollo Server/graphql-ws deployments speak), falling
+// back to the legacy subscriptions-transport-ws protocol name.
+var graphqlSubscriptionProtocols = []string{"graphql-transport-ws", "graphql-ws"}
+
+// graphqlSubscriptionQuery is the cheapest possible subscription - it asks
+// for nothing but the resolved type name, so acceptance alone is the signal
+// we care about, not any particular payload shape.
+const graphqlSubscriptionQuery = `subscription { __typename }`
+
+// graphqlWSMessage is the envelope both graphql-transport-ws and the legacy
+// graphql-ws protocol use, with incompatible Type values for the same
+// lifecycle events (see startMessageType).
+type graphqlWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
 // GraphQLScanner discovers GraphQL endpoints
 type GraphQLScanner struct {
 	BaseURL string
 	Client  *http.Client
 	logger  *utils.Logger
+	wsi     *browser.WSInterceptor
 }
 
 // NewGraphQLScanner creates a new scanner
@@ -27,10 +52,19 @@ func NewGraphQLScanner(baseURL string) *GraphQLScanner {
 	}
 }
 
+// SetWSInterceptor wires a browser.WSInterceptor so any GraphQL subscription
+// socket Discover opens is handed off to it, letting payment-related
+// subscription pushes (orderStatusChanged, paymentCompleted, ...) surface
+// through GetPaymentMessages/CheckSecurity alongside browser-originated
+// frames instead of just being probed and dropped.
+func (g *GraphQLScanner) SetWSInterceptor(wsi *browser.WSInterceptor) {
+	g.wsi = wsi
+}
+
 // Discover finds GraphQL endpoints
 func (g *GraphQLScanner) Discover() ([]models.Endpoint, error) {
 	endpoints := []models.Endpoint{}
-	
+
 	// Common GraphQL paths
 	commonPaths := []string{
 		"/graphql",
@@ -43,35 +77,35 @@ func (g *GraphQLScanner) Discover() ([]models.Endpoint, error) {
 		"/graphiql",
 		"/console",
 	}
-	
+
 	for _, path := range commonPaths {
 		url := g.BaseURL + path
-		
+
 		// Test for GraphQL endpoint with introspection query
 		introspectionQuery := map[string]string{
 			"query": `query { __schema { types { name } } }`,
 		}
-		
+
 		jsonData, _ := json.Marshal(introspectionQuery)
 		req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
-		
+
 		resp, err := g.Client.Do(req)
 		if err != nil {
 			continue
 		}
-		
+
 		// Check if it's a GraphQL endpoint
 		if resp.StatusCode == 200 {
 			bodyBytes := make([]byte, 4096)
 			n, _ := resp.Body.Read(bodyBytes)
 			resp.Body.Close()
 			body := string(bodyBytes[:n])
-			
+
 			// Look for GraphQL signatures
 			if strings.Contains(body, "__schema") || strings.Contains(body, "__type") || strings.Contains(body, "data") {
 				g.logger.Success("GraphQL endpoint found: %s", url)
-				
+
 				endpoints = append(endpoints, models.Endpoint{
 					URL:          url,
 					Method:       "POST",
@@ -79,11 +113,161 @@ func (g *GraphQLScanner) Discover() ([]models.Endpoint, error) {
 					Source:       "graphql_discovery",
 					DiscoveredAt: time.Now(),
 				})
+
+				if sub, err := g.probeSubscription(url); err != nil {
+					g.logger.Debug("GraphQL subscription probe %s: %v", url, err)
+				} else if sub != nil {
+					endpoints = append(endpoints, *sub)
+				}
 			}
 		} else {
 			resp.Body.Close()
 		}
 	}
-	
+
 	return endpoints, nil
 }
+
+// probeSubscription tries each protocol in graphqlSubscriptionProtocols
+// against httpURL's WebSocket equivalent, running the connection_init ->
+// connection_ack handshake and a throwaway subscribe for
+// graphqlSubscriptionQuery. The first protocol the server acks and accepts
+// a subscription over wins. If none do, the endpoint is nil and the error
+// is the last dial or connection_init failure (nil if every attempt got
+// that far and was simply refused). On
+// success the live socket is hung off g.wsi, if set, so subsequent
+// subscription pushes are captured the same way browser-originated
+// WebSocket traffic is.
+func (g *GraphQLScanner) probeSubscription(httpURL string) (*models.Endpoint, error) {
+	wsURL, err := toWebSocketURL(httpURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, protocol := range graphqlSubscriptionProtocols {
+		conn, accepted, err := g.negotiateSubscription(wsURL, protocol)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !accepted {
+			conn.Close()
+			continue
+		}
+
+		g.logger.Success("GraphQL subscription endpoint found: %s (%s)", wsURL, protocol)
+
+		if g.wsi != nil {
+			g.wsi.AttachExternalConnection(conn, wsURL)
+		} else {
+			conn.Close()
+		}
+
+		return &models.Endpoint{
+			URL:    wsURL,
+			Method: "GET",
+			Type:   "graphql-subscription",
+			Source: "graphql_discovery",
+			Parameters: map[string]string{
+				"protocol": protocol,
+			},
+			DiscoveredAt: time.Now(),
+		}, nil
+	}
+
+	return nil, lastErr
+}
+
+// negotiateSubscription dials wsURL with the given subprotocol and runs the
+// connection_init/connection_ack handshake followed by a subscribe for
+// graphqlSubscriptionQuery, reporting whether the server accepted the
+// subscription. The caller owns the returned connection (on success) and
+// is responsible for closing it or handing it to a WSInterceptor.
+func (g *GraphQLScanner) negotiateSubscription(wsURL, protocol string) (*websocket.Conn, bool, error) {
+	dialer := websocket.Dialer{
+		Subprotocols:     []string{protocol},
+		HandshakeTimeout: 10 * time.Second,
+	}
+
+	conn, _, err := dialer.Dial(wsURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	initMsg, _ := json.Marshal(graphqlWSMessage{Type: "connection_init", Payload: json.RawMessage(`{}`)})
+	if err := conn.WriteMessage(websocket.TextMessage, initMsg); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if !readsMessageOfType(conn, "connection_ack") {
+		return conn, false, nil
+	}
+
+	payload, _ := json.Marshal(map[string]string{"query": graphqlSubscriptionQuery})
+	subscribeMsg, _ := json.Marshal(graphqlWSMessage{
+		ID:      "1",
+		Type:    startMessageType(protocol),
+		Payload: payload,
+	})
+	if err := conn.WriteMessage(websocket.TextMessage, subscribeMsg); err != nil {
+		return conn, false, nil
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, reply, err := conn.ReadMessage()
+	if err != nil {
+		return conn, false, nil
+	}
+
+	var replyMsg graphqlWSMessage
+	if err := json.Unmarshal(reply, &replyMsg); err != nil {
+		return conn, false, nil
+	}
+
+	return conn, replyMsg.Type != "error" && replyMsg.Type != "connection_error", nil
+}
+
+// startMessageType returns the message type used to begin a subscription
+// under protocol: "subscribe" for graphql-transport-ws, "start" for the
+// legacy graphql-ws (subscriptions-transport-ws) protocol.
+func startMessageType(protocol string) string {
+	if protocol == "graphql-ws" {
+		return "start"
+	}
+	return "subscribe"
+}
+
+// readsMessageOfType reads a single message off conn and reports whether
+// its "type" field matches want.
+func readsMessageOfType(conn *websocket.Conn, want string) bool {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return false
+	}
+	var msg graphqlWSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return false
+	}
+	return msg.Type == want
+}
+
+// toWebSocketURL rewrites an http(s):// GraphQL endpoint URL to its ws(s)://
+// equivalent, same host and path, on the assumption that the subscription
+// transport is served from the same route as the query/mutation endpoint
+// (the convention graphql-ws/Apollo Server examples follow).
+func toWebSocketURL(httpURL string) (string, error) {
+	u, err := url.Parse(httpURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// harFile mirrors the subset of the HAR 1.2 format (http://www.softwareishard.com/blog/har-12-spec/)
+// that we care about - just enough to recover request method/URL/headers/body.
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// ImportHAR parses a HAR file - as produced by browser devtools or
+// Playwright's RecordHarPath option - and returns the endpoints it
+// recorded. The result is compatible with DeduplicateEndpoints/
+// MergeEndpoints, so it can seed a scan alongside crawl/wayback results
+// without re-crawling flows that need manual navigation (3DS, OTP).
+func ImportHAR(path string) ([]models.Endpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HAR file: %w", err)
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR file: %w", err)
+	}
+
+	endpoints := make([]models.Endpoint, 0, len(har.Log.Entries))
+	for _, entry := range har.Log.Entries {
+		req := entry.Request
+		if req.URL == "" {
+			continue
+		}
+
+		headers := make(map[string]string, len(req.Headers))
+		for _, h := range req.Headers {
+			headers[h.Name] = h.Value
+		}
+
+		epType := "page"
+		if utils.IsPaymentRelated(req.URL) {
+			epType = "payment_related"
+		}
+
+		endpoints = append(endpoints, models.Endpoint{
+			URL:          req.URL,
+			Method:       strings.ToUpper(req.Method),
+			Type:         epType,
+			Headers:      headers,
+			Body:         req.PostData.Text,
+			RequestBody:  []byte(req.PostData.Text),
+			ContentType:  headerValue(headers, "Content-Type"),
+			Source:       "har_import",
+			DiscoveredAt: time.Now(),
+		})
+	}
+
+	return endpoints, nil
+}
+
+// headerValue looks up name in headers case-insensitively, since HAR
+// captures preserve whatever casing the browser sent (usually
+// "Content-Type", but not guaranteed).
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
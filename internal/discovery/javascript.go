@@ -1,10 +1,15 @@
 package discovery
 
 import (
+	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+
 	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
@@ -24,22 +29,32 @@ func NewJSAnalyzer(br *browser.Browser) *JSAnalyzer {
 	}
 }
 
+// sourceMappingURLRegex matches the trailing "//# sourceMappingURL=..."
+// directive bundlers append to emitted JS.
+var sourceMappingURLRegex = regexp.MustCompile(`//[#@]\s*sourceMappingURL=(\S+)`)
+
+// routeTableRegex pulls path strings out of React Router (`<Route path="...">`)
+// and Vue Router (`path: '...'`) route tables. These live inside JSX/object
+// literals that a plain ECMAScript parser can't walk, so they're still
+// matched textually rather than via the AST - everything else in this file
+// now goes through goja's parser instead of a single endpoint-wide regex.
+var routeTableRegex = regexp.MustCompile(`(?:<Route\s+[^>]*\bpath\s*=\s*|\bpath\s*:\s*)["'\x60]([^"'\x60]+)["'\x60]`)
+
 // AnalyzePage scans the current page's loaded JS files for API endpoints
 func (j *JSAnalyzer) AnalyzePage() ([]models.Endpoint, error) {
 	j.logger.Info("Analyzing JavaScript files on current page...")
-	
-	// Get all script src
+
 	page := j.Browser.GetPage()
 	result, err := page.Evaluate(`() => {
 		return Array.from(document.scripts)
 			.map(s => s.src)
 			.filter(src => src && src.length > 0);
 	}`)
-	
+
 	if err != nil {
 		return nil, err
 	}
-	
+
 	var scripts []string
 	if list, ok := result.([]interface{}); ok {
 		for _, item := range list {
@@ -48,58 +63,435 @@ func (j *JSAnalyzer) AnalyzePage() ([]models.Endpoint, error) {
 			}
 		}
 	}
-	
+
 	endpoints := make([]models.Endpoint, 0)
 	client := utils.NewHTTPClient(10 * time.Second)
-	
-	// Regex for finding endpoints/paths
-	// Looks for strings starting with / or http, followed by path chars
-	// This is a heuristic and may produce false positives
-	pathRegex := regexp.MustCompile(`["'](\/[a-zA-Z0-9_\-\/]+|https?:\/\/[^"']+)["']`)
-	
+
 	for _, scriptURL := range scripts {
-		// Only analyze scripts from same domain or CDN?
-		// For now, analyze all
-		
 		j.logger.Debug("Fetching script: %s", scriptURL)
 		resp, err := client.Get(scriptURL)
 		if err != nil {
 			continue
 		}
-		
+
 		body, err := utils.ReadResponseBody(resp)
 		if err != nil {
 			continue
 		}
-		
-		content := string(body)
-		matches := pathRegex.FindAllStringSubmatch(content, -1)
-		
-		for _, match := range matches {
-			if len(match) > 1 {
-				path := match[1]
-				
-				// Filter out common false positives
-				if isFalsePositive(path) {
-					continue
+
+		endpoints = append(endpoints, j.analyzeScript(scriptURL, string(body), client)...)
+	}
+
+	j.logger.Success("JS Analysis found %d potential endpoints", len(endpoints))
+	return endpoints, nil
+}
+
+// analyzeScript extracts endpoints from a single script's source: an AST
+// walk for HTTP call sites, a textual pass for router tables JSX/object
+// literals hide from the parser, and (when the script carries a source map)
+// resolution of each call site back to its original file/line.
+func (j *JSAnalyzer) analyzeScript(scriptURL, content string, client *http.Client) []models.Endpoint {
+	var endpoints []models.Endpoint
+
+	sm := j.loadSourceMap(scriptURL, content, client)
+
+	program, err := parser.ParseFile(nil, scriptURL, content, 0)
+	if err != nil {
+		j.logger.Debug("JS parse failed for %s, falling back to route-table scan only: %v", scriptURL, err)
+	} else {
+		w := &callSiteWalker{content: content, sourceMap: sm, scriptURL: scriptURL}
+		w.walkStatements(program.Body)
+		endpoints = append(endpoints, w.found...)
+	}
+
+	endpoints = append(endpoints, j.extractRouteTable(scriptURL, content, sm)...)
+	return endpoints
+}
+
+// extractRouteTable textually matches React Router/Vue Router path strings,
+// since they live inside JSX/object literals this ECMAScript-only parser
+// doesn't understand.
+func (j *JSAnalyzer) extractRouteTable(scriptURL, content string, sm *sourceMap) []models.Endpoint {
+	var endpoints []models.Endpoint
+	for _, match := range routeTableRegex.FindAllStringSubmatchIndex(content, -1) {
+		path := content[match[2]:match[3]]
+		if isFalsePositive(path) {
+			continue
+		}
+		file, line := resolveSource(sm, scriptURL, lineForOffset(content, match[0]))
+		endpoints = append(endpoints, models.Endpoint{
+			URL:          path,
+			Method:       "GET",
+			Type:         "js_extracted",
+			Source:       "js_analysis",
+			SourceFile:   file,
+			SourceLine:   line,
+			DiscoveredAt: time.Now(),
+		})
+	}
+	return endpoints
+}
+
+// loadSourceMap fetches and parses content's "//# sourceMappingURL="
+// target, if any. Returns nil if there's no directive or the map can't be
+// fetched/parsed - callers fall back to reporting the fetched script itself
+// as the source.
+func (j *JSAnalyzer) loadSourceMap(scriptURL, content string, client *http.Client) *sourceMap {
+	match := sourceMappingURLRegex.FindStringSubmatch(content)
+	if match == nil {
+		return nil
+	}
+
+	mapURL := resolveRelativeURL(scriptURL, match[1])
+	resp, err := client.Get(mapURL)
+	if err != nil {
+		j.logger.Debug("fetching source map %s: %v", mapURL, err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := utils.ReadResponseBody(resp)
+	if err != nil {
+		return nil
+	}
+
+	sm, err := parseSourceMap(body)
+	if err != nil {
+		j.logger.Debug("parsing source map %s: %v", mapURL, err)
+		return nil
+	}
+	return sm
+}
+
+// resolveRelativeURL resolves a source map reference (almost always a bare
+// "app.js.map" filename) against the script URL it was found in.
+func resolveRelativeURL(scriptURL, ref string) string {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return ref
+	}
+	if idx := strings.LastIndex(scriptURL, "/"); idx >= 0 {
+		return scriptURL[:idx+1] + ref
+	}
+	return ref
+}
+
+// resolveSource resolves a generated-code line through sm, falling back to
+// (scriptURL, generatedLine) when sm is nil or has no mapping for that line.
+func resolveSource(sm *sourceMap, scriptURL string, generatedLine int) (string, int) {
+	if sm != nil {
+		if file, line, ok := sm.resolveOriginalPosition(generatedLine-1, 0); ok {
+			return file, line
+		}
+	}
+	return scriptURL, generatedLine
+}
+
+// lineForOffset converts a byte offset into src to a 1-based line number.
+func lineForOffset(src string, offset int) int {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(src) {
+		offset = len(src)
+	}
+	return strings.Count(src[:offset], "\n") + 1
+}
+
+// httpCallMethods maps a known HTTP call site's property/function name to
+// the HTTP method it implies, so TestIdempotency and friends don't have to
+// assume GET for everything JS analysis finds.
+var httpCallMethods = map[string]string{
+	"get": "GET", "post": "POST", "put": "PUT",
+	"patch": "PATCH", "delete": "DELETE", "head": "HEAD",
+}
+
+// callSiteWalker walks a parsed script's AST looking for known HTTP call
+// sites (fetch, axios.*, XMLHttpRequest.open, navigator.sendBeacon, new
+// Request(...)), recording the enclosing function name and an inferred
+// HTTP method alongside each extracted path.
+type callSiteWalker struct {
+	content   string
+	sourceMap *sourceMap
+	scriptURL string
+	funcNames []string
+	found     []models.Endpoint
+}
+
+func (w *callSiteWalker) currentFunctionName() string {
+	if len(w.funcNames) == 0 {
+		return ""
+	}
+	return w.funcNames[len(w.funcNames)-1]
+}
+
+func (w *callSiteWalker) walkStatements(list []ast.Statement) {
+	for _, stmt := range list {
+		w.walkStatement(stmt)
+	}
+}
+
+func (w *callSiteWalker) walkStatement(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case nil:
+		return
+	case *ast.ExpressionStatement:
+		w.walkExpression(s.Expression)
+	case *ast.BlockStatement:
+		w.walkStatements(s.List)
+	case *ast.ReturnStatement:
+		w.walkExpression(s.Argument)
+	case *ast.IfStatement:
+		w.walkExpression(s.Test)
+		w.walkStatement(s.Consequent)
+		w.walkStatement(s.Alternate)
+	case *ast.ForStatement:
+		w.walkExpression(s.Test)
+		w.walkStatement(s.Body)
+	case *ast.WhileStatement:
+		w.walkExpression(s.Test)
+		w.walkStatement(s.Body)
+	case *ast.VariableStatement:
+		for _, v := range s.List {
+			w.walkExpression(v)
+		}
+	case *ast.FunctionDeclaration:
+		w.walkFunction(s.Function)
+	case *ast.TryStatement:
+		w.walkStatement(s.Body)
+		if s.Catch != nil {
+			w.walkStatement(s.Catch.Body)
+		}
+		if s.Finally != nil {
+			w.walkStatement(s.Finally)
+		}
+	}
+}
+
+func (w *callSiteWalker) walkFunction(fn *ast.FunctionLiteral) {
+	if fn == nil {
+		return
+	}
+	name := ""
+	if fn.Name != nil {
+		name = string(fn.Name.Name)
+	}
+	w.funcNames = append(w.funcNames, name)
+	if body, ok := fn.Body.(*ast.BlockStatement); ok {
+		w.walkStatements(body.List)
+	}
+	w.funcNames = w.funcNames[:len(w.funcNames)-1]
+}
+
+func (w *callSiteWalker) walkExpression(expr ast.Expression) {
+	switch e := expr.(type) {
+	case nil:
+		return
+	case *ast.CallExpression:
+		w.inspectCall(e.Callee, e.ArgumentList, e)
+		w.walkExpression(e.Callee)
+		for _, arg := range e.ArgumentList {
+			w.walkExpression(arg)
+		}
+	case *ast.NewExpression:
+		w.inspectCall(e.Callee, e.ArgumentList, e)
+		for _, arg := range e.ArgumentList {
+			w.walkExpression(arg)
+		}
+	case *ast.AssignExpression:
+		w.walkExpression(e.Left)
+		w.walkExpression(e.Right)
+	case *ast.BinaryExpression:
+		w.walkExpression(e.Left)
+		w.walkExpression(e.Right)
+	case *ast.ConditionalExpression:
+		w.walkExpression(e.Test)
+		w.walkExpression(e.Consequent)
+		w.walkExpression(e.Alternate)
+	case *ast.FunctionLiteral:
+		w.walkFunction(e)
+	case *ast.ArrowFunctionLiteral:
+		if body, ok := e.Body.(*ast.BlockStatement); ok {
+			w.funcNames = append(w.funcNames, "")
+			w.walkStatements(body.List)
+			w.funcNames = w.funcNames[:len(w.funcNames)-1]
+		}
+	case *ast.DotExpression:
+		w.walkExpression(e.Left)
+	case *ast.SequenceExpression:
+		for _, sub := range e.Sequence {
+			w.walkExpression(sub)
+		}
+	}
+}
+
+// inspectCall checks whether callee is one of the HTTP call sites this
+// analyzer knows (fetch/axios.*/XMLHttpRequest.open/navigator.sendBeacon/
+// new Request), and if so extracts its URL argument as an endpoint.
+func (w *callSiteWalker) inspectCall(callee ast.Expression, args []ast.Expression, node ast.Node) {
+	name, method, urlArgIndex, methodArgIndex := classifyCallee(callee)
+	if name == "" || urlArgIndex >= len(args) {
+		return
+	}
+
+	path, params := extractPathLiteral(args[urlArgIndex])
+	if path == "" || isFalsePositive(path) {
+		return
+	}
+	if !utils.IsPaymentRelated(path) && !strings.Contains(path, "/api/") {
+		return
+	}
+
+	if methodArgIndex >= 0 && methodArgIndex < len(args) {
+		if m := stringLiteralValue(args[methodArgIndex]); m != "" {
+			method = strings.ToUpper(m)
+		}
+	} else if m := methodFromOptionsArg(args); m != "" {
+		method = m
+	}
+
+	line := lineForOffset(w.content, int(node.Idx0()))
+	file, resolvedLine := resolveSource(w.sourceMap, w.scriptURL, line)
+
+	endpoint := models.Endpoint{
+		URL:          path,
+		Method:       method,
+		Type:         "js_extracted",
+		Source:       "js_analysis",
+		SourceFile:   file,
+		SourceLine:   resolvedLine,
+		FunctionName: w.currentFunctionName(),
+		DiscoveredAt: time.Now(),
+	}
+	if len(params) > 0 {
+		endpoint.Parameters = params
+	}
+	w.found = append(w.found, endpoint)
+}
+
+// classifyCallee recognizes a known HTTP call site and returns its display
+// name, default HTTP method, which argument carries the URL, and which
+// argument carries an explicit method (-1 if none; XMLHttpRequest.open is
+// the only one that passes method as a positional argument).
+func classifyCallee(callee ast.Expression) (name, method string, urlArgIndex, methodArgIndex int) {
+	switch c := callee.(type) {
+	case *ast.Identifier:
+		if string(c.Name) == "fetch" {
+			return "fetch", "GET", 0, -1
+		}
+	case *ast.DotExpression:
+		prop := string(c.Identifier.Name)
+		if obj, ok := c.Left.(*ast.Identifier); ok {
+			switch string(obj.Name) {
+			case "axios":
+				if m, known := httpCallMethods[prop]; known {
+					return "axios." + prop, m, 0, -1
 				}
-				
-				// If it looks like an API endpoint
-				if utils.IsPaymentRelated(path) || strings.Contains(path, "/api/") {
-					endpoints = append(endpoints, models.Endpoint{
-						URL:          path, // Note: might be relative
-						Method:       "GET", // Assumption
-						Type:         "js_extracted",
-						Source:       "js_analysis",
-						DiscoveredAt: time.Now(),
-					})
+			case "navigator":
+				if prop == "sendBeacon" {
+					return "navigator.sendBeacon", "POST", 0, -1
 				}
 			}
 		}
+		if prop == "open" {
+			// XMLHttpRequest.open(method, url, ...) - matched on property
+			// name alone since the receiver is usually a local variable
+			// ("xhr", "req"), not the literal identifier "XMLHttpRequest".
+			return "XMLHttpRequest.open", "GET", 1, 0
+		}
 	}
-	
-	j.logger.Success("JS Analysis found %d potential endpoints", len(endpoints))
-	return endpoints, nil
+
+	if id, ok := callee.(*ast.Identifier); ok && string(id.Name) == "Request" {
+		return "Request", "GET", 0, -1
+	}
+	return "", "", 0, -1
+}
+
+// methodFromOptionsArg looks for a fetch(url, { method: "POST" })-style
+// options object among args and returns its method, uppercased, or "" if
+// none of the args is such an object (or it has no method property).
+func methodFromOptionsArg(args []ast.Expression) string {
+	for _, arg := range args {
+		obj, ok := arg.(*ast.ObjectLiteral)
+		if !ok {
+			continue
+		}
+		for _, prop := range obj.Value {
+			key, ok := propertyKey(prop)
+			if !ok || !strings.EqualFold(key, "method") {
+				continue
+			}
+			if m := stringLiteralValue(propertyValue(prop)); m != "" {
+				return strings.ToUpper(m)
+			}
+		}
+	}
+	return ""
+}
+
+// propertyKey returns an object literal property's key name, for the
+// property shapes goja's parser produces for plain identifier/string keys.
+func propertyKey(prop ast.Property) (string, bool) {
+	switch k := prop.Key.(type) {
+	case *ast.Identifier:
+		return string(k.Name), true
+	case *ast.StringLiteral:
+		return string(k.Value), true
+	}
+	return "", false
+}
+
+func propertyValue(prop ast.Property) ast.Expression {
+	return prop.Value
+}
+
+// extractPathLiteral reads a call argument as either a plain string
+// literal or a template literal, returning its path (with each
+// interpolated expression rendered as a "{param}" placeholder) and a
+// parameters map recording each placeholder's inferred name.
+func extractPathLiteral(expr ast.Expression) (string, map[string]string) {
+	switch e := expr.(type) {
+	case *ast.StringLiteral:
+		return string(e.Value), nil
+	case *ast.TemplateLiteral:
+		var b strings.Builder
+		params := map[string]string{}
+		for i, elem := range e.Elements {
+			if elem != nil {
+				b.WriteString(elem.Literal)
+			}
+			if i < len(e.Expressions) {
+				paramName := templateParamName(e.Expressions[i], i)
+				b.WriteString("{" + paramName + "}")
+				params[paramName] = ""
+			}
+		}
+		return b.String(), params
+	}
+	return "", nil
+}
+
+// templateParamName names a template literal's i-th interpolated
+// expression: the identifier itself when it's a bare variable
+// ("`/orders/${orderId}`" -> "orderId"), the final property name for a
+// member access ("`/orders/${req.params.id}`" -> "id"), or a positional
+// fallback otherwise.
+func templateParamName(expr ast.Expression, i int) string {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		return string(e.Name)
+	case *ast.DotExpression:
+		return string(e.Identifier.Name)
+	}
+	return fmt.Sprintf("param%d", i)
+}
+
+// stringLiteralValue returns expr's literal string value, or "" if expr
+// isn't a plain string literal (a variable, concatenation, etc.).
+func stringLiteralValue(expr ast.Expression) string {
+	if s, ok := expr.(*ast.StringLiteral); ok {
+		return string(s.Value)
+	}
+	return ""
 }
 
 func isFalsePositive(path string) bool {
@@ -109,16 +501,16 @@ func isFalsePositive(path string) bool {
 		".js", ".css", ".png", ".jpg", ".svg", ".woff",
 		"//", "http://www.w3.org",
 	}
-	
+
 	for _, c := range common {
 		if strings.Contains(path, c) {
 			return true
 		}
 	}
-	
+
 	if len(path) < 4 { // Too short
 		return true
 	}
-	
+
 	return false
 }
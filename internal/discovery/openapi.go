@@ -0,0 +1,81 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// openAPISpec mirrors the subset of an OpenAPI 3 document needed to
+// enumerate routes: servers (for the base URL) and each path's operations.
+type openAPISpec struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths map[string]map[string]struct {
+		Parameters []struct {
+			Name string `json:"name"`
+			In   string `json:"in"`
+		} `json:"parameters"`
+	} `json:"paths"`
+}
+
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// ImportOpenAPI parses an OpenAPI 3 spec (JSON) and returns one endpoint
+// per documented operation, letting a scan guarantee coverage of
+// documented routes without relying on the crawler to find them.
+func ImportOpenAPI(path string) ([]models.Endpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI spec: %w", err)
+	}
+
+	var spec openAPISpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	baseURL := ""
+	if len(spec.Servers) > 0 {
+		baseURL = strings.TrimRight(spec.Servers[0].URL, "/")
+	}
+
+	endpoints := make([]models.Endpoint, 0)
+	for route, operations := range spec.Paths {
+		fullURL := baseURL + route
+
+		for _, method := range openAPIMethods {
+			op, ok := operations[method]
+			if !ok {
+				continue
+			}
+
+			params := make(map[string]string, len(op.Parameters))
+			for _, p := range op.Parameters {
+				params[p.Name] = p.In
+			}
+
+			epType := "api"
+			if utils.IsPaymentRelated(fullURL) {
+				epType = "payment_related"
+			}
+
+			endpoints = append(endpoints, models.Endpoint{
+				URL:          fullURL,
+				Method:       strings.ToUpper(method),
+				Type:         epType,
+				Parameters:   params,
+				Source:       "openapi_import",
+				DiscoveredAt: time.Now(),
+			})
+		}
+	}
+
+	return endpoints, nil
+}
@@ -0,0 +1,104 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// OTX queries AlienVault OTX's passive-DNS-backed URL list for a domain.
+// APIKey is optional - OTX serves url_list anonymously, a key just raises
+// the rate limit.
+type OTX struct {
+	APIKey      string
+	Client      *http.Client
+	logger      *utils.Logger
+	rateLimiter *utils.RateLimiter
+}
+
+// NewOTX creates a new AlienVault OTX client.
+func NewOTX(apiKey string) *OTX {
+	return &OTX{
+		APIKey: apiKey,
+		Client: utils.NewHTTPClient(30 * time.Second),
+		logger: utils.NewLogger(true),
+	}
+}
+
+// SetRateLimiter attaches a shared rate limiter, same convention as
+// WaybackMachine.SetRateLimiter.
+func (o *OTX) SetRateLimiter(rl *utils.RateLimiter) {
+	o.rateLimiter = rl
+}
+
+// Name identifies this source for Aggregator's cache keys and logs.
+func (o *OTX) Name() string {
+	return "otx"
+}
+
+type otxURLListResponse struct {
+	URLList []struct {
+		URL string `json:"url"`
+	} `json:"url_list"`
+	HasNext bool `json:"has_next"`
+}
+
+// Search queries OTX's indicators/domain/{d}/url_list endpoint for URLs
+// seen under domain.
+func (o *OTX) Search(ctx context.Context, domain string) ([]models.Endpoint, error) {
+	o.logger.Info("Querying AlienVault OTX for %s...", domain)
+
+	apiURL := fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/domain/%s/url_list", domain)
+
+	if o.rateLimiter != nil {
+		if err := o.rateLimiter.WaitContext(ctx, apiURL); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if o.APIKey != "" {
+		req.Header.Set("X-OTX-API-KEY", o.APIKey)
+	}
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OTX: %w", err)
+	}
+	defer resp.Body.Close()
+	if o.rateLimiter != nil {
+		o.rateLimiter.RecordResponse(apiURL, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OTX returned status %d", resp.StatusCode)
+	}
+
+	var parsed otxURLListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode OTX response: %w", err)
+	}
+
+	endpoints := make([]models.Endpoint, 0)
+	for _, entry := range parsed.URLList {
+		if utils.IsPaymentRelated(entry.URL) || isInteresting(entry.URL) {
+			endpoints = append(endpoints, models.Endpoint{
+				URL:          entry.URL,
+				Method:       "GET",
+				Type:         determineType(entry.URL),
+				Source:       o.Name(),
+				DiscoveredAt: time.Now(),
+			})
+		}
+	}
+
+	o.logger.Success("OTX found %d potential endpoints", len(endpoints))
+	return endpoints, nil
+}
@@ -0,0 +1,70 @@
+package discovery
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// passiveCache persists each PassiveSource's response to disk, keyed by
+// (source, domain, date), so repeated scans against the same target within
+// a day don't re-hit rate-limited free-tier APIs like CommonCrawl/URLScan.
+type passiveCache struct {
+	dir string
+}
+
+func newPassiveCache(dir string) *passiveCache {
+	return &passiveCache{dir: dir}
+}
+
+// get returns the cached endpoints for (source, domain) recorded today, if
+// any. ok is false on a cache miss or read/parse failure - callers should
+// just query the source live in that case.
+func (c *passiveCache) get(source, domain string) (endpoints []models.Endpoint, ok bool) {
+	data, err := os.ReadFile(c.path(source, domain))
+	if err != nil {
+		return nil, false
+	}
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return nil, false
+	}
+	return endpoints, true
+}
+
+// set records endpoints as (source, domain)'s result for today.
+func (c *passiveCache) set(source, domain string, endpoints []models.Endpoint) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(endpoints)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(source, domain), data, 0644)
+}
+
+// path builds the cache file for (source, domain, today) - one file per
+// day so the cache self-expires without needing a TTL sweep.
+func (c *passiveCache) path(source, domain string) string {
+	date := time.Now().Format("20060102")
+	filename := sanitizeCacheKey(source) + "_" + sanitizeCacheKey(domain) + "_" + date + ".json"
+	return filepath.Join(c.dir, filename)
+}
+
+// sanitizeCacheKey replaces path-unsafe characters so a domain/source name
+// is always a valid single filename component.
+func sanitizeCacheKey(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
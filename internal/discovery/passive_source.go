@@ -0,0 +1,22 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// PassiveSource is a discovery source that infers endpoints from data a
+// third party has already collected (a web archive, a crawl index, a
+// certificate transparency log) rather than talking to the target
+// directly. Aggregator fans out to every enabled PassiveSource
+// concurrently and merges the results.
+type PassiveSource interface {
+	// Name identifies the source in logs, cache keys, and per-source config
+	// (e.g. "wayback", "commoncrawl", "urlscan", "otx", "crtsh").
+	Name() string
+
+	// Search returns the endpoints/subdomains a source has on file for
+	// domain. ctx bounds how long Aggregator waits on a single source.
+	Search(ctx context.Context, domain string) ([]models.Endpoint, error)
+}
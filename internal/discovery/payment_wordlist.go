@@ -0,0 +1,35 @@
+package discovery
+
+// embeddedPaymentPaths are payment/webhook-shaped paths merged into every
+// PathBruteForcer run alongside whatever WordlistPath/WordlistSources the
+// caller configured, since generic wordlists (common.txt, raft, etc.)
+// rarely carry payment-gateway-specific routes.
+var embeddedPaymentPaths = []string{
+	"/checkout",
+	"/checkout/session",
+	"/checkout/confirm",
+	"/api/v1/payments",
+	"/api/v2/payments",
+	"/api/v3/payments",
+	"/api/payments/webhook",
+	"/api/payments/callback",
+	"/payment",
+	"/payment/callback",
+	"/payment/return",
+	"/payment/webhook",
+	"/payment/notify",
+	"/payments/webhook",
+	"/webhooks/stripe",
+	"/webhooks/paypal",
+	"/webhooks/adyen",
+	"/webhooks/braintree",
+	"/webhooks/checkout",
+	"/ipn",
+	"/ipn/paypal",
+	"/ipn/notify",
+	"/3ds/callback",
+	"/billing/webhook",
+	"/billing/callback",
+	"/order/confirm",
+	"/orders/webhook",
+}
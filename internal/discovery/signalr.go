@@ -0,0 +1,153 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// commonHubPaths covers both classic ASP.NET SignalR's single `/signalr`
+// endpoint and ASP.NET Core SignalR's one-hub-per-route convention, plus the
+// payment-specific hub names worth checking directly.
+var commonHubPaths = []string{
+	"/signalr/negotiate",
+	"/notifications/hub/negotiate",
+	"/payment-hub/negotiate",
+	"/hubs/payment/negotiate",
+	"/hubs/notifications/negotiate",
+	"/hubs/chat/negotiate",
+}
+
+// signalrTransport is one entry of a negotiate response's
+// availableTransports array.
+type signalrTransport struct {
+	Transport       string   `json:"transport"`
+	TransferFormats []string `json:"transferFormats"`
+}
+
+// signalrNegotiateResponse is the JSON body a SignalR hub's `/negotiate`
+// endpoint returns - connectionToken is used by ASP.NET Core SignalR,
+// connectionId by the classic ASP.NET SignalR, so both are read and
+// whichever is non-empty is used to build transport URLs.
+type signalrNegotiateResponse struct {
+	ConnectionID        string             `json:"connectionId"`
+	ConnectionToken     string             `json:"connectionToken"`
+	NegotiateVersion    int                `json:"negotiateVersion"`
+	AvailableTransports []signalrTransport `json:"availableTransports"`
+}
+
+// SignalRScanner probes common SignalR hub paths and turns each advertised
+// transport (WebSockets, ServerSentEvents, LongPolling) into a
+// models.Endpoint, the same way Payment dashboards built on .NET commonly
+// expose SignalR hubs that PathBruteForcer would otherwise only see as a
+// bare 200/404 on the negotiate URL itself.
+type SignalRScanner struct {
+	BaseURL     string
+	Client      *http.Client
+	logger      *utils.Logger
+	rateLimiter *utils.RateLimiter
+}
+
+// NewSignalRScanner creates a new SignalR hub scanner.
+func NewSignalRScanner(baseURL string) *SignalRScanner {
+	return &SignalRScanner{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  utils.NewHTTPClient(15 * time.Second),
+		logger:  utils.NewLogger(true),
+	}
+}
+
+// SetRateLimiter attaches a shared rate limiter so negotiate requests
+// respect the same adaptive throttling applied to the rest of the scan.
+func (s *SignalRScanner) SetRateLimiter(rl *utils.RateLimiter) {
+	s.rateLimiter = rl
+}
+
+// Scan probes every path in commonHubPaths and returns one models.Endpoint
+// per transport advertised by whichever hubs actually respond.
+func (s *SignalRScanner) Scan() ([]models.Endpoint, error) {
+	endpoints := make([]models.Endpoint, 0)
+
+	for _, path := range commonHubPaths {
+		eps, err := s.probe(path)
+		if err != nil {
+			s.logger.Debug("SignalR probe %s: %v", path, err)
+			continue
+		}
+		endpoints = append(endpoints, eps...)
+	}
+
+	s.logger.Success("SignalR discovery found %d transport endpoint(s)", len(endpoints))
+	return endpoints, nil
+}
+
+func (s *SignalRScanner) probe(path string) ([]models.Endpoint, error) {
+	negotiateURL := fmt.Sprintf("%s%s?negotiateVersion=1", s.BaseURL, path)
+
+	s.rateLimiter.Wait(negotiateURL)
+	resp, err := s.Client.Post(negotiateURL, "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	s.rateLimiter.RecordResponse(negotiateURL, resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("negotiate returned status %d", resp.StatusCode)
+	}
+
+	var negotiated signalrNegotiateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&negotiated); err != nil {
+		return nil, fmt.Errorf("failed to decode negotiate response: %w", err)
+	}
+	if len(negotiated.AvailableTransports) == 0 {
+		return nil, fmt.Errorf("no transports advertised")
+	}
+
+	token := negotiated.ConnectionToken
+	if token == "" {
+		token = negotiated.ConnectionID
+	}
+	hubName := strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/negotiate")
+
+	endpoints := make([]models.Endpoint, 0, len(negotiated.AvailableTransports))
+	for _, t := range negotiated.AvailableTransports {
+		endpoints = append(endpoints, models.Endpoint{
+			URL:    s.transportURL(path, t.Transport, token),
+			Method: "GET",
+			Type:   "signalr_hub",
+			Source: "signalr",
+			Parameters: map[string]string{
+				"hub":             hubName,
+				"transport":       t.Transport,
+				"connectionToken": token,
+			},
+			DiscoveredAt: time.Now(),
+		})
+	}
+
+	s.logger.Success("SignalR hub %s: %d transport(s)", hubName, len(endpoints))
+	return endpoints, nil
+}
+
+// transportURL builds the URL a client would actually connect to for a
+// given transport, following the convention SignalR clients use: the
+// negotiate path's hub segment with the WebSockets transport upgraded to
+// ws(s):// and the connection token attached as a query parameter.
+func (s *SignalRScanner) transportURL(negotiatePath, transport, token string) string {
+	hubPath := strings.TrimSuffix(negotiatePath, "/negotiate")
+	base := s.BaseURL + hubPath
+
+	if transport == "WebSockets" {
+		base = strings.Replace(base, "https://", "wss://", 1)
+		base = strings.Replace(base, "http://", "ws://", 1)
+		return fmt.Sprintf("%s?id=%s", base, token)
+	}
+
+	return fmt.Sprintf("%s?id=%s&transport=%s", base, token, transport)
+}
@@ -0,0 +1,142 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// sourceMap is the subset of the Source Map v3 spec (sourcemap.info) that
+// resolving a generated line/column back to original source needs.
+type sourceMap struct {
+	Version  int      `json:"version"`
+	Sources  []string `json:"sources"`
+	Mappings string   `json:"mappings"`
+
+	// decoded mappings, one per generated line, built by decodeMappings.
+	lines [][]sourceMapSegment
+}
+
+// sourceMapSegment is one VLQ-decoded mapping group: the generated column
+// it starts at, and (if present) the original source/line/column it maps
+// to. Name fields aren't needed for endpoint resolution, so they're
+// decoded (to keep the running state correct) and discarded.
+type sourceMapSegment struct {
+	generatedColumn int
+	sourceIndex     int
+	originalLine    int
+	originalColumn  int
+	hasSource       bool
+}
+
+// parseSourceMap decodes a source map's "mappings" field into per-line
+// segments, ready for resolveOriginalPosition lookups.
+func parseSourceMap(body []byte) (*sourceMap, error) {
+	sm := &sourceMap{}
+	if err := json.Unmarshal(body, sm); err != nil {
+		return nil, fmt.Errorf("parsing source map: %w", err)
+	}
+	sm.lines = decodeMappings(sm.Mappings)
+	return sm, nil
+}
+
+// resolveOriginalPosition maps a 0-based generated line/column to the
+// original source file and 1-based line, per the closest segment starting
+// at or before column on that line. Returns ok=false if line has no
+// segments or none of them carry a source.
+func (sm *sourceMap) resolveOriginalPosition(line, column int) (file string, originalLine int, ok bool) {
+	if line < 0 || line >= len(sm.lines) {
+		return "", 0, false
+	}
+	segments := sm.lines[line]
+	var best *sourceMapSegment
+	for i := range segments {
+		if segments[i].generatedColumn <= column {
+			best = &segments[i]
+		} else {
+			break
+		}
+	}
+	if best == nil || !best.hasSource {
+		return "", 0, false
+	}
+	src := ""
+	if best.sourceIndex >= 0 && best.sourceIndex < len(sm.Sources) {
+		src = sm.Sources[best.sourceIndex]
+	}
+	return src, best.originalLine + 1, true
+}
+
+// decodeMappings decodes the base64-VLQ "mappings" string into per-line
+// segments. Each segment's fields are deltas from the previous segment on
+// the same line (column) or the previous segment overall (source index,
+// original line/column), per the Source Map v3 spec.
+func decodeMappings(mappings string) [][]sourceMapSegment {
+	var lines [][]sourceMapSegment
+	genColumn, srcIndex, origLine, origColumn := 0, 0, 0, 0
+
+	for _, lineStr := range strings.Split(mappings, ";") {
+		genColumn = 0
+		var segments []sourceMapSegment
+
+		for _, group := range strings.Split(lineStr, ",") {
+			if group == "" {
+				continue
+			}
+			values, ok := decodeVLQ(group)
+			if !ok || len(values) == 0 {
+				continue
+			}
+
+			genColumn += values[0]
+			seg := sourceMapSegment{generatedColumn: genColumn}
+			if len(values) >= 4 {
+				srcIndex += values[1]
+				origLine += values[2]
+				origColumn += values[3]
+				seg.sourceIndex = srcIndex
+				seg.originalLine = origLine
+				seg.originalColumn = origColumn
+				seg.hasSource = true
+			}
+			segments = append(segments, seg)
+		}
+
+		lines = append(lines, segments)
+	}
+	return lines
+}
+
+const base64VLQChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes a single comma-separated group of a mappings line into
+// its signed integer fields (the Base64 VLQ encoding source maps use).
+func decodeVLQ(s string) ([]int, bool) {
+	var values []int
+	shift, result := uint(0), 0
+
+	for _, c := range s {
+		digit := strings.IndexRune(base64VLQChars, c)
+		if digit < 0 {
+			return nil, false
+		}
+		continuation := digit&0x20 != 0
+		digit &= 0x1f
+		result += digit << shift
+
+		if continuation {
+			shift += 5
+			continue
+		}
+
+		negate := result&1 != 0
+		value := result >> 1
+		if negate {
+			value = -value
+		}
+		values = append(values, value)
+
+		shift, result = 0, 0
+	}
+	return values, true
+}
@@ -0,0 +1,114 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// URLScan queries urlscan.io's search API for pages it's crawled under a
+// domain. APIKey is optional - anonymous search works against urlscan's
+// public index, a key just raises the rate limit/result cap.
+type URLScan struct {
+	APIKey      string
+	Client      *http.Client
+	logger      *utils.Logger
+	rateLimiter *utils.RateLimiter
+}
+
+// NewURLScan creates a new urlscan.io client.
+func NewURLScan(apiKey string) *URLScan {
+	return &URLScan{
+		APIKey: apiKey,
+		Client: utils.NewHTTPClient(30 * time.Second),
+		logger: utils.NewLogger(true),
+	}
+}
+
+// SetRateLimiter attaches a shared rate limiter, same convention as
+// WaybackMachine.SetRateLimiter.
+func (u *URLScan) SetRateLimiter(rl *utils.RateLimiter) {
+	u.rateLimiter = rl
+}
+
+// Name identifies this source for Aggregator's cache keys and logs.
+func (u *URLScan) Name() string {
+	return "urlscan"
+}
+
+type urlscanSearchResponse struct {
+	Results []struct {
+		Page struct {
+			URL string `json:"url"`
+		} `json:"page"`
+		Task struct {
+			URL string `json:"url"`
+		} `json:"task"`
+	} `json:"results"`
+}
+
+// Search queries urlscan.io's search API for pages crawled under domain.
+func (u *URLScan) Search(ctx context.Context, domain string) ([]models.Endpoint, error) {
+	u.logger.Info("Querying URLScan.io for %s...", domain)
+
+	apiURL := fmt.Sprintf("https://urlscan.io/api/v1/search/?q=domain:%s", domain)
+
+	if u.rateLimiter != nil {
+		if err := u.rateLimiter.WaitContext(ctx, apiURL); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if u.APIKey != "" {
+		req.Header.Set("API-Key", u.APIKey)
+	}
+
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query urlscan.io: %w", err)
+	}
+	defer resp.Body.Close()
+	if u.rateLimiter != nil {
+		u.rateLimiter.RecordResponse(apiURL, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("urlscan.io returned status %d", resp.StatusCode)
+	}
+
+	var parsed urlscanSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode urlscan.io response: %w", err)
+	}
+
+	endpoints := make([]models.Endpoint, 0)
+	for _, r := range parsed.Results {
+		pageURL := r.Page.URL
+		if pageURL == "" {
+			pageURL = r.Task.URL
+		}
+		if pageURL == "" {
+			continue
+		}
+		if utils.IsPaymentRelated(pageURL) || isInteresting(pageURL) {
+			endpoints = append(endpoints, models.Endpoint{
+				URL:          pageURL,
+				Method:       "GET",
+				Type:         determineType(pageURL),
+				Source:       u.Name(),
+				DiscoveredAt: time.Now(),
+			})
+		}
+	}
+
+	u.logger.Success("URLScan.io found %d potential endpoints", len(endpoints))
+	return endpoints, nil
+}
@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -12,8 +13,9 @@ import (
 
 // WaybackMachine handles querying the Wayback Machine API
 type WaybackMachine struct {
-	Client *http.Client
-	logger *utils.Logger
+	Client      *http.Client
+	logger      *utils.Logger
+	rateLimiter *utils.RateLimiter
 }
 
 // NewWaybackMachine creates a new Wayback Machine client
@@ -24,43 +26,66 @@ func NewWaybackMachine() *WaybackMachine {
 	}
 }
 
+// SetRateLimiter attaches a shared rate limiter so CDX API requests respect
+// the same adaptive throttling applied to the rest of the scan.
+func (w *WaybackMachine) SetRateLimiter(rl *utils.RateLimiter) {
+	w.rateLimiter = rl
+}
+
+// Name identifies this source for Aggregator's cache keys and logs.
+func (w *WaybackMachine) Name() string {
+	return "wayback"
+}
+
 // Search queries the Wayback Machine for URLs matching the domain
-func (w *WaybackMachine) Search(domain string) ([]models.Endpoint, error) {
+func (w *WaybackMachine) Search(ctx context.Context, domain string) ([]models.Endpoint, error) {
 	w.logger.Info("Querying Wayback Machine for %s...", domain)
-	
+
 	// CDX API URL
 	// Filter for status 200 and collapse by urlkey to reduce duplicates
 	apiURL := fmt.Sprintf("http://web.archive.org/cdx/search/cdx?url=%s/*&output=json&fl=original,mimetype,statuscode&filter=statuscode:200&collapse=urlkey", domain)
-	
-	resp, err := w.Client.Get(apiURL)
+
+	if w.rateLimiter != nil {
+		if err := w.rateLimiter.WaitContext(ctx, apiURL); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := w.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query wayback machine: %w", err)
 	}
 	defer resp.Body.Close()
-	
+	if w.rateLimiter != nil {
+		w.rateLimiter.RecordResponse(apiURL, resp.StatusCode)
+	}
+
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("wayback machine returned status %d", resp.StatusCode)
 	}
-	
+
 	var results [][]string
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
 		return nil, fmt.Errorf("failed to decode wayback response: %w", err)
 	}
-	
+
 	endpoints := make([]models.Endpoint, 0)
-	
+
 	// Skip header row (index 0)
 	if len(results) > 0 {
 		results = results[1:]
 	}
-	
+
 	for _, row := range results {
 		if len(row) < 1 {
 			continue
 		}
-		
+
 		urlStr := row[0]
-		
+
 		// Filter relevant endpoints (payment, api, etc)
 		if utils.IsPaymentRelated(urlStr) || isInteresting(urlStr) {
 			endpoints = append(endpoints, models.Endpoint{
@@ -72,7 +97,7 @@ func (w *WaybackMachine) Search(domain string) ([]models.Endpoint, error) {
 			})
 		}
 	}
-	
+
 	w.logger.Success("Wayback Machine found %d potential endpoints", len(endpoints))
 	return endpoints, nil
 }
@@ -98,7 +123,7 @@ func determineType(urlStr string) string {
 }
 
 func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || 
+	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
 		(len(s) > 0 && len(substr) > 0 && findSubstring(s, substr)))
 }
 
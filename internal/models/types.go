@@ -1,142 +1,388 @@
 package models
 
-import "time"
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
 
 // ScanConfig holds the configuration for a security scan
 type ScanConfig struct {
 	// Target configuration
-	LoginURL    string
-	TargetURL   string
-	PaymentURL  string
-	Domain      string
-	
+	LoginURL   string
+	TargetURL  string
+	PaymentURL string
+	Domain     string
+
 	// Browser settings
-	Browser       string        // "firefox", "chromium", "webkit"
-	Headless      bool
+	Browser        string // "firefox", "chromium", "webkit"
+	Headless       bool
 	BrowserTimeout time.Duration
-	
+
 	// Scan settings
 	ConcurrentReqs int
 	Timeout        time.Duration
 	MaxDepth       int
 	WordlistPath   string
-	AutoDiscovery  bool
-	
+	// WordlistSources are extra wordlists merged into the common-path brute
+	// force alongside WordlistPath and the built-in payment-oriented path
+	// list - each may be a local file path or an http(s) URL.
+	WordlistSources []string
+	AutoDiscovery   bool
+
+	// Rate limiting: RateRPS is the starting/fixed rate; when WAFAdaptive is
+	// set, the actual rate adapts between RateMin and RateMax (AIMD-style)
+	// and is additionally floored by whichever WAF gets fingerprinted.
+	RateRPS     int
+	RateMin     int
+	RateMax     int
+	WAFAdaptive bool
+
 	// Test selection
-	EnableRaceCondition      bool
-	EnablePriceManipulation  bool
-	EnableIDOR               bool
-	EnableOTPSecurity        bool
-	EnableCallbackAuth       bool
-	EnableAmountValidation   bool
-	EnableIdempotency        bool
-	
+	// RaceMode selects how TestRaceCondition fires its concurrent burst:
+	// "barrier" (goroutine barrier over pooled connections), "single-packet"
+	// (James Kettle's single-packet attack over one HTTP/2 connection, or
+	// HTTP/1.1 pipelining if the endpoint doesn't negotiate h2), or "both".
+	RaceMode                string
+	EnableRaceCondition     bool
+	EnablePriceManipulation bool
+	EnableIDOR              bool
+	EnableOTPSecurity       bool
+	EnableCallbackAuth      bool
+	EnableAmountValidation  bool
+	EnableIdempotency       bool
+
 	// Discovery settings
 	EnableCrawl       bool
 	EnableWayback     bool
 	EnableCommonPaths bool
 	EnableJSAnalysis  bool
-	
+	EnableSignalR     bool
+	HARImportPath     string // seed endpoints from a saved HAR file instead of/alongside crawling
+	OpenAPIImportPath string // seed endpoints from an OpenAPI 3 spec
+	HARRecordPath     string // record the authenticated browser session to a HAR file for later import
+
+	// Passive discovery sources beyond Wayback, all fanned out concurrently
+	// by discovery.Aggregator: CommonCrawl's CDX index, URLScan.io, AlienVault
+	// OTX, and crt.sh (certificate-transparency subdomain harvesting, whose
+	// results feed back into the other sources as extra domains to query).
+	// URLScanAPIKey/OTXAPIKey are optional - both APIs serve useful results
+	// anonymously but accept a key for their paid/higher-rate tiers.
+	EnableCommonCrawl bool
+	EnableURLScan     bool
+	EnableOTX         bool
+	EnableCrtSh       bool
+	URLScanAPIKey     string
+	OTXAPIKey         string
+
+	// PassiveSourceRPS is the per-source token-bucket rate discovery.Aggregator
+	// applies to each passive source independently (so a slow/erroring
+	// CommonCrawl doesn't throttle URLScan). Defaults to 1 request/sec when
+	// zero - these are free third-party APIs, not the target itself.
+	PassiveSourceRPS int
+
+	// PassiveCacheDir caches each passive source's response on disk, keyed by
+	// (source, domain, date), so repeated scans against the same target
+	// within a day don't re-hit free-tier APIs.
+	PassiveCacheDir string
+
 	// Output settings
 	OutputDir     string
-	ReportFormats []string // "json", "html", "console"
+	ReportFormats []string // "json", "html", "console", "sarif", "har"
+	LogFormat     string   // "text" (default) or "json", for CI/SIEM ingestion
 	Verbose       bool
+
+	// mTLS: presented on every outgoing scanner request when the target
+	// (typically a bank/payment gateway) requires a client certificate to
+	// even complete a TLS handshake. Loaded once into Session.TLSConfig by
+	// establishSession; see utils.NewHTTPClientForSession.
+	ClientCertPath string
+	ClientKeyPath  string
+	CABundlePath   string // verify the server cert against this CA instead of the system pool
+	TLSInsecure    bool   // skip server certificate verification entirely (default, matching the scanner's historical behavior against self-signed test targets)
+
+	// JWT weak-secret cracking: JWTSecretsWordlist, if set, is merged
+	// alongside the embedded default leaked-secret list; JWTMaxCrackDuration
+	// bounds how long testJWTWeakSecret spends per token so scans stay
+	// deterministic; JWTJWKSURI overrides where the RS256->HS256
+	// algorithm-confusion test fetches the signing key's JWKS from (falls
+	// back to discovering it via /.well-known/openid-configuration).
+	JWTSecretsWordlist  string
+	JWTMaxCrackDuration time.Duration
+	JWTJWKSURI          string
+	JWTRedactSecrets    bool // mask cracked secrets in Vulnerability.Proof down to their first/last two characters
+
+	// PriceFieldPattern is the regex (case-insensitive, matched against
+	// unqualified field/attribute/tag names) TestPriceManipulation uses to
+	// find which fields of a captured request body to mutate. Empty uses
+	// scanner.defaultPriceFieldPattern.
+	PriceFieldPattern string
+}
+
+// Cookie represents a browser cookie with its security attributes, as
+// returned by Playwright's BrowserContext.Cookies(). Session.Cookies keeps
+// the flattened name->value map for convenience; CookieDetails preserves
+// the attributes needed to audit cookie hardening.
+type Cookie struct {
+	Name     string
+	Value    string
+	Domain   string
+	Path     string
+	Expires  time.Time
+	HttpOnly bool
+	Secure   bool
+	SameSite string // "Strict", "Lax", "None", or "" if not set
 }
 
 // Session represents an authenticated browser session
 type Session struct {
-	Cookies       map[string]string
-	Headers       map[string]string
-	LocalStorage  map[string]string
+	Cookies        map[string]string
+	CookieDetails  []Cookie // Rich cookie metadata (SameSite/Secure/HttpOnly) for cookie security checks
+	Headers        map[string]string
+	LocalStorage   map[string]string
 	SessionStorage map[string]string
-	
+
 	// WebSocket session support
-	WebSocketURL   string
-	SessionToken   string
-	URLToken       string  // Token from URL path
-	
+	WebSocketURL string
+	SessionToken string
+	URLToken     string // Token from URL path
+
+	// BearerToken is the HTTP API bearer credential, kept separate from
+	// SessionToken (which is WebSocket-oriented) so internal/session can
+	// refresh one without disturbing the other.
+	BearerToken string
+
+	// RefreshToken is the OAuth2 refresh token an internal/auth.Provider
+	// login returned alongside BearerToken, if the grant issued one.
+	// internal/auth's background refresh loop uses it to rotate
+	// BearerToken/Headers["Authorization"] before ExpiresAt without a full
+	// re-login. Empty for sessions that didn't come from internal/auth.
+	RefreshToken string
+
+	// IDToken is the OpenID Connect ID token an internal/auth.Provider
+	// login returned alongside BearerToken, if the provider is an OIDC one.
+	// It's not used for API authentication (BearerToken/Headers carry
+	// that) - it's kept so a scan can inspect/report on the claims the
+	// identity provider actually asserted about the authenticated user.
+	// Empty for sessions from non-OIDC grants (password, client-credentials)
+	// or that didn't come from internal/auth.
+	IDToken string
+
+	// ExpiresAt is derived from cookie Max-Age or JWT `exp` by whatever
+	// created the session, rather than a single global TTL constant.
+	// Zero means unknown/unset.
+	ExpiresAt time.Time
+
+	// LoginURL/TargetURL/User identify which login this session belongs
+	// to, so internal/session can key its cache and re-login without
+	// extra parameters being threaded through Validate.
+	LoginURL  string
+	TargetURL string
+	User      string
+
 	Authenticated bool
 	UserAgent     string
 	CreatedAt     time.Time
+
+	// ClientCert is the mTLS client certificate/key pair to present when
+	// connecting to a target that requires mutual TLS (e.g. a
+	// client-cert-secured webhook endpoint). nil means no client cert is
+	// sent, same as the zero-value http.Transport. Set directly by probes
+	// that need a specific (often deliberately wrong) cert for a single
+	// request; TLSConfig below is what the scanner's own outgoing traffic
+	// is configured with.
+	ClientCert *tls.Certificate
+
+	// TLSConfig is how this session reaches a target that requires mutual
+	// TLS just to be scanned at all (common for bank/payment gateways),
+	// loaded once from --client-cert/--client-key/--ca-bundle/--tls-insecure
+	// by establishSession. nil means plain utils.NewHTTPClient behavior.
+	TLSConfig *TLSConfig
+}
+
+// TLSConfig carries the client certificate, CA bundle, and SNI override a
+// Session or Endpoint needs to reach an mTLS-protected target, resolved
+// once (from PEM or PKCS#12 files) by utils.LoadTLSConfig so every
+// utils.NewHTTPClientForSession/NewHTTPClientForEndpoint call shares the
+// same already-parsed tls.Certificate/x509.CertPool instead of re-reading
+// the files on every request.
+type TLSConfig struct {
+	Cert       *tls.Certificate
+	RootCAs    *x509.CertPool
+	ServerName string // SNI override, for a gateway fronted by an IP or a name the cert doesn't cover
+	Insecure   bool   // skip server certificate verification entirely
 }
 
 // Endpoint represents a discovered API endpoint or page
 type Endpoint struct {
-	URL         string
-	Method      string            // GET, POST, PUT, DELETE, etc.
-	Type        string            // "payment", "checkout", "order", "webhook", etc.
-	Parameters  map[string]string
-	Headers     map[string]string
-	Body        string
-	Source      string            // "crawl", "wayback", "wordlist", "js_analysis"
+	URL          string
+	Method       string // GET, POST, PUT, DELETE, etc.
+	Type         string // "payment", "checkout", "order", "webhook", etc.
+	Parameters   map[string]string
+	Headers      map[string]string
+	Body         string
+	Source       string // "crawl", "wayback", "wordlist", "js_analysis"
 	DiscoveredAt time.Time
+
+	// RequestBody/ContentType are the endpoint's original request body as
+	// captured bytes and its exact Content-Type header (e.g.
+	// "multipart/form-data; boundary=..."), used by TestPriceManipulation's
+	// schema-aware mutation to parse and re-serialize the real body instead
+	// of posting a guessed {amount,price,cost} object. Body above remains
+	// the plain-text form HAR import and csrf.go's replay use; RequestBody
+	// is the binary-safe version for formats (multipart) Body can't
+	// round-trip. Empty when no request body was captured for this
+	// endpoint.
+	RequestBody []byte
+	ContentType string
+
+	// TLSConfig overrides the session's TLSConfig for this endpoint alone,
+	// for targets where different paths sit behind different mTLS-enforcing
+	// gateways (e.g. a HAR/OpenAPI import that records per-endpoint client
+	// certs). nil means fall back to the session's TLSConfig.
+	TLSConfig *TLSConfig
+
+	// ContentLength/BodyHash/ServerHeader are per-path telemetry recorded
+	// by PathBruteForcer's baseline-calibrated brute force, so later
+	// scanners can prioritize payment-shaped endpoints without
+	// re-requesting them. Zero/empty for endpoints from other sources.
+	ContentLength int64
+	BodyHash      string
+	ServerHeader  string
+
+	// SourceFile/SourceLine/FunctionName locate where JSAnalyzer's
+	// AST walk found this endpoint in the *original* (pre-bundle/minify)
+	// source, resolved through the script's "//# sourceMappingURL=" map
+	// when one is present. SourceFile/SourceLine are empty/zero when the
+	// endpoint wasn't extracted from JS, or no source map was available
+	// (in which case they describe the fetched script itself).
+	SourceFile   string
+	SourceLine   int
+	FunctionName string
 }
 
 // Vulnerability represents a discovered security vulnerability
 type Vulnerability struct {
 	ID          string
-	Type        string    // "Race Condition", "Price Manipulation", etc.
-	Severity    string    // "CRITICAL", "HIGH", "MEDIUM", "LOW"
+	Type        string // "Race Condition", "Price Manipulation", etc.
+	Severity    string // "CRITICAL", "HIGH", "MEDIUM", "LOW"
 	Title       string
 	Description string
 	Endpoint    string
 	Method      string
-	Proof       string    // Evidence/PoC
+	Proof       string // Evidence/PoC
 	Impact      string
 	Remediation string
 	CVSSScore   float64
-	CVSS        string    // CVSS vector string
+	CVSS        string // CVSS vector string
+	CVSSVector  string // CVSS vector string; same shape as CVSS, used by newer probes
+	CWE         string // e.g. "CWE-347", surfaced in SARIF rule IDs (see reporter.sarifRuleID)
+	Confidence  string // "High", "Medium", "Low"
+	References  []string
 	Timestamp   time.Time
 	Verified    bool
-	
+
 	// Additional details
 	Request  string
 	Response string
 	Payload  string
+
+	// Evidence is the full captured request/response exchange that
+	// produced this finding, as recorded by utils.EvidenceRecorder. nil
+	// means the probe that found this didn't record through it.
+	Evidence *Evidence
+}
+
+// Evidence is the full request/response exchange an EvidenceRecorder
+// captured for a probe, kept separate from the summary Request/Response/
+// Payload strings on Vulnerability so report exporters (HAR, curl
+// reproducers) have structured headers/timing to work with instead of
+// having to re-parse raw strings.
+type Evidence struct {
+	Method         string
+	URL            string
+	RequestHeaders map[string][]string
+	RequestBody    string
+
+	StatusCode      int
+	ResponseHeaders map[string][]string
+	ResponseBody    string
+	// ResponseBodyTruncated is set when the body was longer than the
+	// recorder's cap and ResponseBody only holds the first part of it.
+	ResponseBodyTruncated bool
+	// ResponseBodyBinary means ResponseBody holds base64 rather than raw
+	// text, because the body didn't look like valid UTF-8 text.
+	ResponseBodyBinary bool
+
+	TLSVersion     string // e.g. "TLS 1.3", empty if the exchange was plain HTTP
+	TLSCipherSuite string
+
+	StartedAt time.Time
+	Duration  time.Duration
 }
 
 // ScanResult represents the complete results of a security scan
 type ScanResult struct {
-	ScanID      string
-	Target      string
-	StartTime   time.Time
-	EndTime     time.Time
-	Duration    time.Duration
-	
+	ScanID    string
+	Target    string
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+
 	// Discovery results
-	EndpointsFound     int
-	Endpoints          []Endpoint
-	
+	EndpointsFound int
+	Endpoints      []Endpoint
+
 	// Testing results
 	VulnerabilitiesFound int
 	Vulnerabilities      []Vulnerability
 	VulnsBySeverity      map[string]int // "CRITICAL": 2, "HIGH": 5, etc.
-	
+
 	// Statistics
-	RequestsSent       int
-	ResponsesReceived  int
-	ErrorsEncountered  int
-	TestsRun           int
-	
+	RequestsSent      int
+	ResponsesReceived int
+	ErrorsEncountered int
+	TestsRun          int
+
+	// CertificateChain is the target's server certificate chain, captured
+	// during discovery by scanner.CheckCertificateHealth. Weak signature
+	// algorithms or short-lived/near-expiry certs in it are also reported
+	// as informational Vulnerabilities; this field keeps the raw chain
+	// around for anyone auditing the TLS posture directly. Empty if the
+	// handshake failed or the target wasn't HTTPS.
+	CertificateChain []CertificateInfo
+
 	// Configuration used
 	Config ScanConfig
 }
 
+// CertificateInfo is the subset of an x509.Certificate that's useful to
+// report on without re-parsing the DER bytes: identity, validity window,
+// and the signature algorithm used to sign it.
+type CertificateInfo struct {
+	Subject            string
+	Issuer             string
+	SerialNumber       string
+	SignatureAlgorithm string
+	NotBefore          time.Time
+	NotAfter           time.Time
+}
+
 // TestResult represents the result of a single vulnerability test
 type TestResult struct {
-	TestName    string
-	Endpoint    string
-	Success     bool
-	Vulnerable  bool
-	Details     string
-	Evidence    string
-	Duration    time.Duration
-	Error       error
+	TestName   string
+	Endpoint   string
+	Success    bool
+	Vulnerable bool
+	Details    string
+	Evidence   string
+	Duration   time.Duration
+	Error      error
 }
 
 // ScanProgress tracks the progress of an ongoing scan
 type ScanProgress struct {
-	Phase           string  // "discovery", "testing", "verification", "reporting"
+	Phase           string // "discovery", "testing", "verification", "reporting"
 	CurrentTask     string
 	TotalEndpoints  int
 	TestedEndpoints int
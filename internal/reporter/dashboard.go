@@ -0,0 +1,82 @@
+package reporter
+
+import "net/http"
+
+// ServeDashboard registers a minimal live-scan dashboard and its SSE feed
+// on mux: GET / renders dashboardTemplate, which subscribes to
+// /events?topic=vulnerabilities and /events?topic=endpoints via EventSource
+// and renders them live using the same severity color scheme as
+// GenerateHTMLReport's htmlTemplate. GET /events is h.Subscribe itself.
+func ServeDashboard(mux *http.ServeMux, h *Hub) {
+	mux.HandleFunc("/events", h.Subscribe)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(dashboardTemplate))
+	})
+}
+
+const dashboardTemplate = `
+<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>Web Payment Scanner - Live Scan</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; line-height: 1.6; color: #333; max-width: 1200px; margin: 0 auto; padding: 20px; background-color: #f5f5f5; }
+        .header { background: #fff; padding: 20px; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); margin-bottom: 20px; }
+        .vuln-card { background: #fff; padding: 20px; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); margin-bottom: 15px; border-left: 5px solid #ccc; }
+        .severity-CRITICAL { border-left-color: #d32f2f; }
+        .severity-HIGH { border-left-color: #f57c00; }
+        .severity-MEDIUM { border-left-color: #fbc02d; }
+        .severity-LOW { border-left-color: #388e3c; }
+        .badge { display: inline-block; padding: 4px 8px; border-radius: 4px; color: #fff; font-weight: bold; font-size: 0.8em; }
+        .bg-CRITICAL { background-color: #d32f2f; }
+        .bg-HIGH { background-color: #f57c00; }
+        .bg-MEDIUM { background-color: #fbc02d; }
+        .bg-LOW { background-color: #388e3c; }
+        #endpoints { color: #666; font-size: 0.9em; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>Web Payment Scanner - Live Scan</h1>
+        <p>Vulnerabilities: <span id="vuln-count">0</span> &middot; Endpoints discovered: <span id="endpoint-count">0</span></p>
+    </div>
+    <div id="vulns"></div>
+    <p id="endpoints"></p>
+    <script>
+        let vulnCount = 0, endpointCount = 0;
+
+        function subscribe(topic, onEvent) {
+            const lastId = localStorage.getItem('lastEventId-' + topic) || '';
+            const es = new EventSource('/events?topic=' + topic + (lastId ? '&lastEventId=' + lastId : ''));
+            es.addEventListener(topic, function(e) {
+                localStorage.setItem('lastEventId-' + topic, e.lastEventId);
+                onEvent(JSON.parse(e.data));
+            });
+            return es;
+        }
+
+        subscribe('vulnerabilities', function(ev) {
+            const v = ev.vulnerability;
+            if (!v) return;
+            vulnCount++;
+            document.getElementById('vuln-count').textContent = vulnCount;
+            const card = document.createElement('div');
+            card.className = 'vuln-card severity-' + v.Severity;
+            card.innerHTML = '<span class="badge bg-' + v.Severity + '">' + v.Severity + '</span> ' +
+                '<strong>' + v.Title + '</strong><br>' +
+                '<small>' + v.Endpoint + '</small><p>' + v.Description + '</p>';
+            document.getElementById('vulns').prepend(card);
+        });
+
+        subscribe('endpoints', function(ev) {
+            if (!ev.endpoint) return;
+            endpointCount++;
+            document.getElementById('endpoint-count').textContent = endpointCount;
+            document.getElementById('endpoints').textContent = 'Last discovered: ' + ev.endpoint.URL;
+        });
+    </script>
+</body>
+</html>
+`
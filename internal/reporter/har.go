@@ -0,0 +1,252 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// HAR (HTTP Archive) 1.2 structures, trimmed to the fields this scanner
+// actually populates. See http://www.softwareishard.com/blog/har-12-spec/.
+
+type harLog struct {
+	Log harLogBody `json:"log"`
+}
+
+type harLogBody struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"` // milliseconds
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	QueryString []harHeader `json:"queryString"`
+	PostData    *harContent `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	Content     harContent  `json:"content"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// WriteHAR writes every Vulnerability in result that carries Evidence as a
+// HAR 1.2 entry to path, so the exchange that produced a finding can be
+// replayed/inspected in any HAR viewer (or fixture-fed back into the
+// scanner) without re-running the scan live. Vulnerabilities without
+// Evidence are skipped rather than represented as empty entries.
+func WriteHAR(result models.ScanResult, path string) error {
+	entries := make([]harEntry, 0, len(result.Vulnerabilities))
+
+	for _, v := range result.Vulnerabilities {
+		if v.Evidence == nil {
+			continue
+		}
+		entries = append(entries, harEntryFromEvidence(v))
+	}
+
+	log := harLog{Log: harLogBody{
+		Version: "1.2",
+		Creator: harCreator{Name: "web-payment-scanner", Version: "1.0"},
+		Entries: entries,
+	}}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func harEntryFromEvidence(v models.Vulnerability) harEntry {
+	ev := v.Evidence
+
+	var postData *harContent
+	if ev.RequestBody != "" {
+		postData = &harContent{
+			Size:     len(ev.RequestBody),
+			MimeType: headerValue(ev.RequestHeaders, "Content-Type"),
+			Text:     ev.RequestBody,
+		}
+	}
+
+	content := harContent{
+		Size:     len(ev.ResponseBody),
+		MimeType: headerValue(ev.ResponseHeaders, "Content-Type"),
+		Text:     ev.ResponseBody,
+	}
+	if ev.ResponseBodyBinary {
+		content.Encoding = "base64"
+	}
+
+	return harEntry{
+		StartedDateTime: ev.StartedAt.Format("2006-01-02T15:04:05.000Z07:00"),
+		Time:            float64(ev.Duration.Microseconds()) / 1000.0,
+		Request: harRequest{
+			Method:      ev.Method,
+			URL:         ev.URL,
+			HTTPVersion: "HTTP/1.1",
+			Headers:     harHeaders(ev.RequestHeaders),
+			QueryString: []harHeader{},
+			PostData:    postData,
+			BodySize:    len(ev.RequestBody),
+		},
+		Response: harResponse{
+			Status:      ev.StatusCode,
+			StatusText:  "",
+			HTTPVersion: "HTTP/1.1",
+			Headers:     harHeaders(ev.ResponseHeaders),
+			Content:     content,
+			BodySize:    len(ev.ResponseBody),
+		},
+		Timings: harTimings{
+			Send:    0,
+			Wait:    float64(ev.Duration.Microseconds()) / 1000.0,
+			Receive: 0,
+		},
+		Comment: fmt.Sprintf("%s: %s", v.Type, v.Title),
+	}
+}
+
+func harHeaders(h map[string][]string) []harHeader {
+	headers := make([]harHeader, 0, len(h))
+	for name, values := range h {
+		for _, v := range values {
+			headers = append(headers, harHeader{Name: name, Value: v})
+		}
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+	return headers
+}
+
+func headerValue(h map[string][]string, name string) string {
+	for k, values := range h {
+		if strings.EqualFold(k, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// CurlReproducer renders v's captured Evidence as a standalone `curl`
+// command, so a finding can be handed to someone without scanner access to
+// reproduce it. Returns "" if v has no Evidence to reproduce from.
+func CurlReproducer(v models.Vulnerability) string {
+	ev := v.Evidence
+	if ev == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("curl -sS")
+	if ev.Method != "" && ev.Method != "GET" {
+		fmt.Fprintf(&b, " -X %s", ev.Method)
+	}
+
+	for _, name := range sortedHeaderNames(ev.RequestHeaders) {
+		for _, value := range ev.RequestHeaders[name] {
+			fmt.Fprintf(&b, " \\\n  -H %s", shellQuote(fmt.Sprintf("%s: %s", name, value)))
+		}
+	}
+
+	if ev.RequestBody != "" {
+		fmt.Fprintf(&b, " \\\n  -d %s", shellQuote(ev.RequestBody))
+	}
+
+	fmt.Fprintf(&b, " \\\n  %s", shellQuote(ev.URL))
+
+	return b.String()
+}
+
+func sortedHeaderNames(h map[string][]string) []string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// shellQuote wraps s in single quotes for safe inclusion in a POSIX shell
+// command line, escaping any single quotes already in s.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// WriteCurlReproducers writes a shell script to path containing one curl
+// command per Vulnerability in result that carries Evidence, each preceded
+// by a comment naming the finding, so a report reader can reproduce any
+// finding with no scanner access. Skips writing a file if nothing in
+// result has Evidence to reproduce from.
+func WriteCurlReproducers(result models.ScanResult, path string) error {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# Reproducers for findings captured by web-payment-scanner.\n")
+	b.WriteString("# Each command replays the exact request that produced its finding.\n")
+
+	found := false
+	for _, v := range result.Vulnerabilities {
+		repro := CurlReproducer(v)
+		if repro == "" {
+			continue
+		}
+		found = true
+		fmt.Fprintf(&b, "\n# %s: %s\n", v.Type, v.Title)
+		b.WriteString(repro)
+		b.WriteString("\n")
+	}
+
+	if !found {
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0755)
+}
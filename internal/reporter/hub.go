@@ -0,0 +1,153 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// hubBufferSize is how many recent events Hub keeps per topic so a client
+// reconnecting with Last-Event-ID can replay what it missed. Older events
+// are simply gone - Hub is a live dashboard, not a durable event log.
+const hubBufferSize = 256
+
+// hubHeartbeatInterval is how often Subscribe writes an SSE comment line to
+// each connected client, so intermediating proxies (and browsers) don't
+// time the connection out during a quiet scan phase.
+const hubHeartbeatInterval = 15 * time.Second
+
+// hubEvent is one entry in a topic's ring buffer: an Event tagged with the
+// monotonically increasing ID used as the SSE "id:" field.
+type hubEvent struct {
+	id    uint64
+	event Event
+}
+
+// Hub is a small Mercure-style SSE hub: Publish fans an Event out to every
+// subscriber of its topic, and Subscribe serves GET /events as
+// text/event-stream. Endpoint/vulnerability/progress events published
+// during a scan land here the same way they land in StreamWriter, so a scan
+// can be watched live from a browser instead of only tailed as NDJSON.
+type Hub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	buffers     map[string][]hubEvent // topic -> ring buffer, oldest first
+	subscribers map[string]map[chan hubEvent]struct{}
+}
+
+// NewHub creates an empty Hub, ready to Publish/Subscribe.
+func NewHub() *Hub {
+	return &Hub{
+		buffers:     make(map[string][]hubEvent),
+		subscribers: make(map[string]map[chan hubEvent]struct{}),
+	}
+}
+
+// Publish fans e out to every subscriber currently watching topic (e.g.
+// "vulnerabilities", "endpoints", "progress") and appends it to that
+// topic's replay buffer. Safe to call concurrently, and non-blocking:
+// subscriber channels are buffered, and a slow/gone subscriber only drops
+// its own events rather than stalling the scan.
+func (h *Hub) Publish(topic string, e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	he := hubEvent{id: h.nextID, event: e}
+
+	buf := append(h.buffers[topic], he)
+	if len(buf) > hubBufferSize {
+		buf = buf[len(buf)-hubBufferSize:]
+	}
+	h.buffers[topic] = buf
+
+	for ch := range h.subscribers[topic] {
+		select {
+		case ch <- he:
+		default:
+		}
+	}
+}
+
+// Subscribe serves a single SSE client: it replays any buffered events for
+// ?topic= newer than the Last-Event-ID (request header or query param),
+// then streams new ones as Publish sends them, with a heartbeat comment
+// every hubHeartbeatInterval. It blocks until the client disconnects.
+func (h *Hub) Subscribe(w http.ResponseWriter, r *http.Request) {
+	topic := r.URL.Query().Get("topic")
+	if topic == "" {
+		http.Error(w, "missing ?topic=", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	lastID := lastEventID(r)
+	ch := make(chan hubEvent, 64)
+
+	h.mu.Lock()
+	for _, he := range h.buffers[topic] {
+		if he.id > lastID {
+			select {
+			case ch <- he:
+			default:
+			}
+		}
+	}
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = make(map[chan hubEvent]struct{})
+	}
+	h.subscribers[topic][ch] = struct{}{}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.subscribers[topic], ch)
+		h.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(hubHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case he := <-ch:
+			data, err := json.Marshal(he.event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", he.id, topic, data)
+			flusher.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// lastEventID reads the browser-supplied Last-Event-ID, preferring the
+// standard SSE reconnect header and falling back to ?lastEventId= for
+// clients opening the stream fresh (e.g. a dashboard restoring state from
+// localStorage across a page reload).
+func lastEventID(r *http.Request) uint64 {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("lastEventId")
+	}
+	id, _ := strconv.ParseUint(raw, 10, 64)
+	return id
+}
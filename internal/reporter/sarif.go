@@ -0,0 +1,211 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// sarifSchemaURI pins the exact SARIF 2.1.0 schema so downstream consumers
+// (GitHub code scanning, GitLab SAST, DefectDojo) can validate the log
+// without guessing a version.
+const sarifSchemaURI = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name,omitempty"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string                 `json:"ruleId"`
+	Level            string                 `json:"level"`
+	Message          sarifMessage           `json:"message"`
+	Locations        []sarifLocation        `json:"locations,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Properties       map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifLogicalLocation carries the HTTP method a finding's endpoint was
+// reached with - SARIF has no dedicated "HTTP method" field, so this rides
+// in logicalLocations the way tools that scan non-file-based targets
+// (APIs, containers) conventionally record non-source-location context.
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// WriteSARIF writes result as a SARIF 2.1.0 log to path, one result per
+// Vulnerability, so findings can be ingested by GitHub code scanning,
+// GitLab SAST, or DefectDojo without a separate conversion step.
+func WriteSARIF(result models.ScanResult, path string) error {
+	rules := make([]sarifRule, 0)
+	seenRules := make(map[string]bool)
+	results := make([]sarifResult, 0, len(result.Vulnerabilities))
+
+	for _, v := range result.Vulnerabilities {
+		ruleID := sarifRuleID(v)
+		if !seenRules[ruleID] {
+			seenRules[ruleID] = true
+			rules = append(rules, sarifRule{
+				ID:               ruleID,
+				Name:             v.Type,
+				HelpURI:          sarifHelpURI(v),
+				ShortDescription: sarifMessage{Text: v.Type},
+				FullDescription:  sarifMessage{Text: v.Remediation},
+			})
+		}
+
+		var locations []sarifLocation
+		if v.Endpoint != "" {
+			locations = []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: v.Endpoint},
+				},
+			}}
+		}
+
+		var logicalLocations []sarifLogicalLocation
+		if v.Method != "" {
+			logicalLocations = []sarifLogicalLocation{{Name: v.Method, Kind: "httpMethod"}}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:           ruleID,
+			Level:            sarifLevel(v.Severity),
+			Message:          sarifMessage{Text: v.Description},
+			Locations:        locations,
+			LogicalLocations: logicalLocations,
+			Properties: map[string]interface{}{
+				"cvss": map[string]interface{}{
+					"score":  v.CVSSScore,
+					"vector": v.CVSSVector,
+					"cwe":    v.CWE,
+				},
+				"confidence": v.Confidence,
+				"payload":    v.Payload,
+				"proof":      v.Proof,
+			},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchemaURI,
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:           "web-payment-scanner",
+					InformationURI: "https://github.com/SpaceLeam/web-Payment-scanner",
+					Rules:          rules,
+				},
+			},
+			Results: results,
+		}},
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// GenerateSARIFReport writes result as a SARIF 2.1.0 log into outputDir,
+// the same outputDir/timestamped-filename convention GenerateJSONReport and
+// GenerateHTMLReport use, and returns the file it wrote.
+func GenerateSARIFReport(result models.ScanResult, outputDir string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", err
+	}
+
+	timestamp := time.Now().Format("20060102_150405")
+	filename := filepath.Join(outputDir, fmt.Sprintf("scan_report_%s.sarif", timestamp))
+
+	if err := WriteSARIF(result, filename); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// sarifRuleID derives a stable rule identifier from a vulnerability's CWE
+// when known - the usual SARIF convention of keying rules off CWE IDs -
+// falling back to a slug of its Type.
+func sarifRuleID(v models.Vulnerability) string {
+	if v.CWE != "" {
+		return fmt.Sprintf("%s/%s", v.CWE, slugifyVulnType(v.Type))
+	}
+	return slugifyVulnType(v.Type)
+}
+
+func slugifyVulnType(t string) string {
+	return strings.ReplaceAll(strings.ToLower(t), " ", "-")
+}
+
+// sarifHelpURI returns the first reference URL a finding carries, if any,
+// to populate the rule's helpUri.
+func sarifHelpURI(v models.Vulnerability) string {
+	if len(v.References) > 0 {
+		return v.References[0]
+	}
+	return ""
+}
+
+// sarifLevel maps a finding's Severity to a SARIF result level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "CRITICAL", "HIGH":
+		return "error"
+	case "MEDIUM":
+		return "warning"
+	case "LOW":
+		return "note"
+	default:
+		return "warning"
+	}
+}
@@ -0,0 +1,73 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// EventKind identifies what an Event carries.
+type EventKind string
+
+const (
+	EventEndpoint      EventKind = "endpoint"
+	EventVulnerability EventKind = "vulnerability"
+	EventSummary       EventKind = "summary"
+	EventProgress      EventKind = "progress"
+)
+
+// Event is one line of a streamed NDJSON report: an endpoint as it's
+// discovered, a vulnerability as it's found, a progress tick, or the final
+// summary. Exactly one of Endpoint/Vulnerability/Progress/Summary is set,
+// matching Kind.
+type Event struct {
+	Kind          EventKind             `json:"kind"`
+	Endpoint      *models.Endpoint      `json:"endpoint,omitempty"`
+	Vulnerability *models.Vulnerability `json:"vulnerability,omitempty"`
+	Progress      *models.ScanProgress  `json:"progress,omitempty"`
+	Summary       *models.ScanResult    `json:"summary,omitempty"`
+}
+
+// HubTopic maps an EventKind to the Hub topic a dashboard subscribes to
+// with GET /events?topic=..., pluralizing endpoint/vulnerability to match
+// the topic names from the live-dashboard design (progress/summary already
+// read the same singular either way).
+func HubTopic(kind EventKind) string {
+	switch kind {
+	case EventEndpoint:
+		return "endpoints"
+	case EventVulnerability:
+		return "vulnerabilities"
+	default:
+		return string(kind)
+	}
+}
+
+// StreamWriter emits one JSON object per line (NDJSON) as Events arrive, so
+// CI pipelines can tee findings into log aggregators or fail fast on the
+// first Critical severity without waiting for the final report.
+type StreamWriter struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewStreamWriter wraps w (e.g. os.Stdout, a file, or a pipe feeding `tee`).
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{w: w}
+}
+
+// Write appends e as a single NDJSON line.
+func (s *StreamWriter) Write(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(data)
+	return err
+}
@@ -4,55 +4,160 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/shopspring/decimal"
 )
 
 // TestAmountValidation tests for amount validation vulnerabilities
-func TestAmountValidation(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func TestAmountValidation(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Original tests
-	vulns = append(vulns, testAmountPrecision(endpoint, session)...)
-	vulns = append(vulns, testAmountOverflow(endpoint, session)...)
-	
+	vulns = append(vulns, testAmountPrecision(endpoint, session, rl)...)
+	vulns = append(vulns, testAmountOverflow(endpoint, session, rl)...)
+
 	// P1 Enhanced tests
-	vulns = append(vulns, testCurrencyMismatch(endpoint, session)...)
-	vulns = append(vulns, testNegativeZero(endpoint, session)...)
-	vulns = append(vulns, testScientificNotation(endpoint, session)...)
-	vulns = append(vulns, testUnicodeDigits(endpoint, session)...)
-	vulns = append(vulns, testFloatingPointPrecision(endpoint, session)...)
-	
+	vulns = append(vulns, testCurrencyMismatch(endpoint, session, rl)...)
+	vulns = append(vulns, testNegativeZero(endpoint, session, rl)...)
+
+	// Mutator-driven edge cases: IEEE-754 boundaries and Unicode digit
+	// confusables, each only reported once the response shows the
+	// "dangerous" value was actually used rather than just a 2xx status.
+	mutators := []AmountMutator{ieee754EdgeCaseMutator{}, unicodeDigitMutator{}}
+	for _, mutator := range mutators {
+		vulns = append(vulns, testAmountMutator(endpoint, session, rl, mutator)...)
+	}
+
+	return vulns
+}
+
+// testAmountMutator drives every AmountMutation a mutator generates against
+// endpoint and reports a vulnerability only when the response echoes back
+// the mutation's dangerous value - not merely a successful status code.
+// This replaces the old one-hardcoded-payload-per-function probes
+// (testScientificNotation, testUnicodeDigits, testFloatingPointPrecision),
+// which flagged any 2xx response regardless of what the server did with it.
+func testAmountMutator(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, mutator AmountMutator) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+
+	for _, m := range mutator.Mutations() {
+		body := fmt.Sprintf(`{"amount": %s}`, m.Payload)
+
+		req, err := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBufferString(body))
+		if err != nil {
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		addAuthHeaders(req, session)
+
+		rl.Wait(endpoint.URL)
+		resp, err := client.Do(req)
+		if err != nil {
+			continue
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		rl.RecordHTTPResponse(endpoint.URL, resp)
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			continue
+		}
+
+		used, ok := extractAmountFromBody(respBody)
+		if !ok || !used.Equal(m.Dangerous) {
+			// Either the response didn't echo back a usable amount field
+			// (e.g. confirmation happens through a separate receipt/order
+			// lookup callback) or it used the expected value rather than
+			// the dangerous one - either way there's no proof the mutation
+			// did anything, so don't report it.
+			continue
+		}
+
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "Amount Validation",
+			Severity:    "HIGH",
+			Title:       fmt.Sprintf("%s Normalized To Dangerous Value", m.Name),
+			Description: m.Description,
+			Endpoint:    endpoint.URL,
+			Method:      endpoint.Method,
+			Payload:     body,
+			Proof:       fmt.Sprintf("Sent amount=%s, expected the server to reject it or store %s, but the response echoed back %s", m.Payload, m.Expected.String(), used.String()),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-1339",
+			CVSSScore:   7.5,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N",
+			Confidence:  "High",
+			Remediation: "Parse amounts with a fixed-point decimal type (e.g. shopspring/decimal), reject anything outside the Unicode Nd category, and reject the IEEE-754 edge cases (subnormals, NaN, values past 2^53) before persisting - never trust a JSON number or string field at face value.",
+			References: []string{
+				"https://cwe.mitre.org/data/definitions/1339.html",
+			},
+		})
+	}
+
 	return vulns
 }
 
+// extractAmountFromBody looks for a numeric amount echoed back in a
+// top-level field of a JSON response body - the first of amount, price,
+// cost or total that is present and numeric - and parses it as a
+// decimal.Decimal so callers can compare it exactly against an
+// AmountMutation's Dangerous value instead of trusting a bare status code.
+func extractAmountFromBody(body []byte) (decimal.Decimal, bool) {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return decimal.Decimal{}, false
+	}
+
+	for _, field := range []string{"amount", "price", "cost", "total"} {
+		raw, ok := parsed[field]
+		if !ok {
+			continue
+		}
+		switch v := raw.(type) {
+		case string:
+			if d, err := decimal.NewFromString(v); err == nil {
+				return d, true
+			}
+		case float64:
+			return decimal.NewFromFloat(v), true
+		}
+	}
+
+	return decimal.Decimal{}, false
+}
+
 // testCurrencyMismatch tests if server validates currency conversion
-func testCurrencyMismatch(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testCurrencyMismatch(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Send request with USD but expect system uses IDR
 	payload := map[string]interface{}{
 		"amount":   100,
 		"currency": "USD", // Send USD
 		// Server might process as IDR without conversion
 	}
-	
+
 	payloadJSON, _ := json.Marshal(payload)
-	client := utils.NewHTTPClient(10 * time.Second)
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
 	req, _ := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBuffer(payloadJSON))
 	req.Header.Set("Content-Type", "application/json")
 	addAuthHeaders(req, session)
-	
+
+	rl.Wait(endpoint.URL)
 	resp, err := client.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	// If accepted without proper conversion validation
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		vulns = append(vulns, models.Vulnerability{
@@ -84,31 +189,33 @@ if request.Currency == "USD" {
 			},
 		})
 	}
-	
+
 	return vulns
 }
 
 // testNegativeZero tests if server handles -0.00 correctly
-func testNegativeZero(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testNegativeZero(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Test negative zero (IEEE 754 allows this)
 	payload := map[string]interface{}{
 		"amount": -0.00, // Negative zero
 	}
-	
+
 	payloadJSON, _ := json.Marshal(payload)
-	client := utils.NewHTTPClient(10 * time.Second)
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
 	req, _ := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBuffer(payloadJSON))
 	req.Header.Set("Content-Type", "application/json")
 	addAuthHeaders(req, session)
-	
+
+	rl.Wait(endpoint.URL)
 	resp, err := client.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	// Negative zero should be rejected or normalized
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		vulns = append(vulns, models.Vulnerability{
@@ -127,155 +234,17 @@ func testNegativeZero(endpoint models.Endpoint, session *models.Session) []model
 			Remediation: "Reject or normalize negative zero to positive zero",
 		})
 	}
-	
-	return vulns
-}
 
-// testScientificNotation tests if server properly validates scientific notation
-func testScientificNotation(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
-	vulns := []models.Vulnerability{}
-	
-	// Test cases: 1e10, 1.23e-4, etc.
-	testCases := []struct {
-		value       string
-		description string
-	}{
-		{"1e10", "10 billion (very large)"},
-		{"1e-10", "0.0000000001 (very small)"},
-		{"9.999e99", "extremely large number"},
-	}
-	
-	for _, tc := range testCases {
-		payload := fmt.Sprintf(`{"amount": %s}`, tc.value)
-		
-		client := utils.NewHTTPClient(10 * time.Second)
-		req, _ := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBufferString(payload))
-		req.Header.Set("Content-Type", "application/json")
-		addAuthHeaders(req, session)
-		
-		resp, err := client.Do(req)
-		if err != nil {
-			continue
-		}
-		resp.Body.Close()
-		
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			vulns = append(vulns, models.Vulnerability{
-				Type:        "Amount Validation",
-				Severity:    "MEDIUM",
-				Title:       "Scientific Notation Not Validated",
-				Description: fmt.Sprintf("Server accepts scientific notation (%s = %s) which may bypass amount validation", tc.value, tc.description),
-				Endpoint:    endpoint.URL,
-				Method:      endpoint.Method,
-				Proof:       fmt.Sprintf("Sent amount=%s, received %d", tc.value, resp.StatusCode),
-				Timestamp:   time.Now(),
-				CWE:         "CWE-20",
-				CVSSScore:   6.5,
-				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N",
-				Confidence:  "Medium",
-				Remediation: "Validate amount format and reject scientific notation in payment amounts",
-			})
-			break // Only report once
-		}
-	}
-	
-	return vulns
-}
-
-// testUnicodeDigits tests if server validates unicode digits
-func testUnicodeDigits(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
-	vulns := []models.Vulnerability{}
-	
-	// Unicode digits: ١٢٣ (Arabic), १२३ (Devanagari), 일이삼 (Korean), etc.
-	// For simplicity, test fullwidth digits
-	unicodeAmount := "１２３" // Fullwidth 123
-	normalAmount := "123"
-	
-	// First check if unicode is normalized to normal digits
-	payload := fmt.Sprintf(`{"amount": "%s"}`, unicodeAmount)
-	
-	client := utils.NewHTTPClient(10 * time.Second)
-	req, _ := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBufferString(payload))
-	req.Header.Set("Content-Type", "application/json")
-	addAuthHeaders(req, session)
-	
-	resp, err := client.Do(req)
-	if err != nil {
-		return vulns
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		vulns = append(vulns, models.Vulnerability{
-			Type:        "Amount Validation",
-			Severity:    "MEDIUM",
-			Title:       "Unicode Digits Not Normalized",
-			Description: fmt.Sprintf("Server accepts unicode digits (%s) which may bypass validation. Could be normalized to %s or cause parsing errors.", unicodeAmount, normalAmount),
-			Endpoint:    endpoint.URL,
-			Method:      endpoint.Method,
-			Proof:       fmt.Sprintf("Sent amount='%s' (unicode), received %d", unicodeAmount, resp.StatusCode),
-			Timestamp:   time.Now(),
-			CWE:         "CWE-20",
-			CVSSScore:   5.3,
-			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:N",
-			Confidence:  "Low",
-			Remediation: "Normalize unicode digits to ASCII or reject non-ASCII digits in amount fields",
-		})
-	}
-	
-	return vulns
-}
-
-// testFloatingPointPrecision tests floating point rounding exploits
-func testFloatingPointPrecision(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
-	vulns := []models.Vulnerability{}
-	
-	// Test precision edge cases
-	payload := map[string]interface{}{
-		"amount": 0.999999999999, // Many 9s, might round to 1.00
-	}
-	
-	payloadJSON, _ := json.Marshal(payload)
-	client := utils.NewHTTPClient(10 * time.Second)
-	req, _ := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBuffer(payloadJSON))
-	req.Header.Set("Content-Type", "application/json")
-	addAuthHeaders(req, session)
-	
-	resp, err := client.Do(req)
-	if err != nil {
-		return vulns
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		vulns = append(vulns, models.Vulnerability{
-			Type:        "Amount Validation",
-			Severity:    "LOW",
-			Title:       "Floating Point Precision Not Validated",
-			Description: "Server may have floating point rounding issues. Amount 0.999999999999 could round to 1.00 causing small discrepancies",
-			Endpoint:    endpoint.URL,
-			Method:      endpoint.Method,
-			Proof:       "Sent amount=0.999999999999, received " + strconv.Itoa(resp.StatusCode),
-			Timestamp:   time.Now(),
-			CWE:         "CWE-682",
-			CVSSScore:   3.7,
-			CVSSVector:  "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:L/A:N",
-			Confidence:  "Low",
-			Remediation: "Use fixed-point arithmetic (integers) for monetary values. Store cents/pence instead of dollars.",
-		})
-	}
-	
 	return vulns
 }
 
 // Original tests (kept for backward compatibility)
-func testAmountPrecision(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testAmountPrecision(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	// Existing implementation returns empty for now
 	return []models.Vulnerability{}
 }
 
-func testAmountOverflow(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testAmountOverflow(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	// Existing implementation returns empty for now
 	return []models.Vulnerability{}
 }
-
@@ -0,0 +1,188 @@
+package scanner
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// AmountMutation is one edge-case payload an AmountMutator generates: the
+// raw JSON value to send, the decimal value the scanner expects a correct
+// server to reject or store unchanged, and the "dangerous" value a server
+// that mishandles the edge case might end up using instead.
+type AmountMutation struct {
+	Name        string
+	Payload     string // raw JSON literal to splice into {"amount": <Payload>}
+	Expected    decimal.Decimal
+	Dangerous   decimal.Decimal
+	Description string
+}
+
+// AmountMutator generates the edge cases testAmountMutator drives against an
+// endpoint. Separating the edge-case tables from the HTTP plumbing lets new
+// mutation sources (e.g. a currency-specific rounding table) be added
+// without touching testAmountMutator itself.
+type AmountMutator interface {
+	Mutations() []AmountMutation
+}
+
+// ieee754EdgeCaseMutator generates amount payloads at the boundaries of
+// float64 representability, where a server parsing "amount" as a float
+// before validating it can end up storing something other than what it
+// appears to have received.
+type ieee754EdgeCaseMutator struct{}
+
+func (ieee754EdgeCaseMutator) Mutations() []AmountMutation {
+	subnormal := math.SmallestNonzeroFloat64
+	roundingSum := 0.1 + 0.2
+	maxInt53 := float64(1<<53 - 1)
+	pastMaxInt53 := math.Nextafter(maxInt53, math.Inf(1))
+	huge := 1e308
+
+	return []AmountMutation{
+		{
+			Name:      "Subnormal Float Amount",
+			Payload:   formatFloatPayload(subnormal),
+			Expected:  decimal.NewFromFloat(subnormal),
+			Dangerous: decimal.Zero,
+			Description: "Smallest positive float64 subnormal (~4.9e-324 sent as amount): a " +
+				"naive \"amount > 0\" check in a runtime that flushes subnormals to zero " +
+				"treats this as a free transaction.",
+		},
+		{
+			Name:      "0.1 + 0.2 Rounding Amount",
+			Payload:   formatFloatPayload(roundingSum),
+			Expected:  decimal.NewFromFloat(0.3),
+			Dangerous: decimal.NewFromFloat(roundingSum),
+			Description: "0.1 + 0.2 = 0.30000000000000004 in float64: a server comparing the " +
+				"raw float against 0.3 instead of rounding to the currency's minor unit " +
+				"first either rejects a legitimate amount or silently stores a value that " +
+				"never equaled the 0.30 it appears to.",
+		},
+		{
+			Name:      "Past MaxInt53 Amount",
+			Payload:   formatFloatPayload(pastMaxInt53),
+			Expected:  decimal.NewFromInt(1<<53 - 1),
+			Dangerous: decimal.NewFromFloat(pastMaxInt53),
+			Description: "One float64 step past 2^53-1, the largest integer a JSON number can " +
+				"round-trip through float64 (and so through JavaScript's Number) without " +
+				"loss: a client built on that assumption can no longer tell this amount " +
+				"apart from 2^53.",
+		},
+		{
+			Name:      "1e308 Overflow Amount",
+			Payload:   formatFloatPayload(huge),
+			Expected:  decimal.RequireFromString("1e308"),
+			Dangerous: decimal.NewFromInt(-1),
+			Description: "Near float64's maximum magnitude: a server that narrows the amount " +
+				"into float32 or a 64-bit integer cents count before persisting it overflows, " +
+				"and integer overflow wraps the stored amount negative (CWE-190) instead of " +
+				"rejecting the request.",
+		},
+		{
+			Name:      "NaN String Amount",
+			Payload:   `"NaN"`,
+			Expected:  decimal.Zero,
+			Dangerous: decimal.Zero,
+			Description: "The string \"NaN\" isn't valid JSON number syntax, but a server that " +
+				"string-converts the amount field (Python float(\"NaN\"), Ruby \"NaN\".to_f) " +
+				"gets IEEE NaN, and every comparison against NaN (amount <= 0, amount > " +
+				"balance) evaluates false - silently bypassing validation instead of raising one.",
+		},
+	}
+}
+
+// formatFloatPayload renders f as the shortest float64-round-tripping JSON
+// number literal, matching how encoding/json itself would encode it.
+func formatFloatPayload(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// digitScript is one Unicode Nd-category (or confusable) digit range:
+// base is the codepoint one below that script's digit 1, so
+// base+1..base+9 are that script's digits 1-9 (and base+0 its zero, where
+// the script has one).
+type digitScript struct {
+	Name string
+	Base rune
+}
+
+// digitScripts is the Unicode digit table unicodeDigitMutator fuzzes with:
+// every Nd-category block in common use for payment amounts, plus Ethiopic
+// as a confusable that looks like Nd but has no positional zero.
+func digitScripts() []digitScript {
+	return []digitScript{
+		{"Arabic-Indic", 0x0660},
+		{"Extended Arabic-Indic", 0x06F0},
+		{"Devanagari", 0x0966},
+		{"Bengali", 0x09E6},
+		{"Thai", 0x0E50},
+		{"Fullwidth", 0xFF10},
+		{"Mathematical Bold", 0x1D7CE},
+		{"Ethiopic", 0x1368}, // no zero glyph - fine here since canonicalDigits never needs one
+	}
+}
+
+const canonicalDigits = "123"
+
+// convertDigits renders s (ASCII digits) in the given script by offsetting
+// each digit from the script's base codepoint.
+func convertDigits(s string, base rune) string {
+	var b strings.Builder
+	for _, c := range s {
+		b.WriteRune(base + (c - '0'))
+	}
+	return b.String()
+}
+
+// unicodeDigitMutator generates non-ASCII digit renderings of the same
+// canonical amount, so a server that accepts them as-is (rather than
+// normalizing to ASCII or rejecting them outright) can be caught storing a
+// value it never actually validated.
+type unicodeDigitMutator struct{}
+
+func (unicodeDigitMutator) Mutations() []AmountMutation {
+	canonicalValue := decimal.NewFromInt(123)
+
+	var muts []AmountMutation
+	for _, ds := range digitScripts() {
+		payload := convertDigits(canonicalDigits, ds.Base)
+		muts = append(muts, AmountMutation{
+			Name:      ds.Name + " Digits Amount",
+			Payload:   mustQuoteJSON(payload),
+			Expected:  decimal.Zero,
+			Dangerous: canonicalValue,
+			Description: ds.Name + " digit rendering of " + canonicalDigits + " (" + payload + "): " +
+				"a server that accepts non-ASCII digits in the amount field without " +
+				"normalizing or rejecting them may be trusting a value it never parsed.",
+		})
+	}
+
+	// Confusables that aren't a simple positional digit substitution.
+	muts = append(muts, AmountMutation{
+		Name:      "Roman Numerals Amount",
+		Payload:   `"CXXIII"`,
+		Expected:  decimal.Zero,
+		Dangerous: canonicalValue,
+		Description: "Roman numeral rendering of " + canonicalDigits + " (CXXIII): like the " +
+			"Nd-category scripts above, a server that accepts this as-is in the amount " +
+			"field is trusting a value it never parsed.",
+	})
+
+	return muts
+}
+
+// mustQuoteJSON encodes s as a JSON string literal payload. Unlike
+// strconv.Quote, this escapes the way encoding/json would for characters
+// outside Go's string-literal escaping rules (e.g. U+2028/U+2029), which
+// matters for the astral-plane Mathematical Bold digits above.
+func mustQuoteJSON(s string) string {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return strconv.Quote(s)
+	}
+	return string(encoded)
+}
@@ -17,55 +17,71 @@ import (
 )
 
 // TestCallbackAuth tests webhook/callback authentication vulnerabilities
-func TestCallbackAuth(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func TestCallbackAuth(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Original basic tests
-	vulns = append(vulns, testMissingSignature(endpoint, session)...)
-	vulns = append(vulns, testInvalidSignature(endpoint, session)...)
-	
+	vulns = append(vulns, testMissingSignature(endpoint, session, rl)...)
+	vulns = append(vulns, testInvalidSignature(endpoint, session, rl)...)
+
 	// NEW: Enhanced tests from P0
-	vulns = append(vulns, testTimestampReplay(endpoint, session)...)
-	vulns = append(vulns, testFutureTimestamp(endpoint, session)...)
-	vulns = append(vulns, testMissingTimestamp(endpoint, session)...)
-	vulns = append(vulns, testIPSpoofing(endpoint, session)...)
-	vulns = append(vulns, testMultipleSignatureAlgorithms(endpoint, session)...)
-	vulns = append(vulns, testSignatureStripEncoding(endpoint, session)...)
-	
+	vulns = append(vulns, testTimestampReplay(endpoint, session, rl)...)
+	vulns = append(vulns, testFutureTimestamp(endpoint, session, rl)...)
+	vulns = append(vulns, testMissingTimestamp(endpoint, session, rl)...)
+	vulns = append(vulns, testIPSpoofing(endpoint, session, rl)...)
+	vulns = append(vulns, testMultipleSignatureAlgorithms(endpoint, session, rl)...)
+	vulns = append(vulns, testSignatureStripEncoding(endpoint, session, rl)...)
+
+	// SigV4/SigV4A webhook authentication probes
+	vulns = append(vulns, testSigV4CanonicalizationMismatch(endpoint, session, rl)...)
+	vulns = append(vulns, testSigV4ScopeConfusion(endpoint, session, rl)...)
+	vulns = append(vulns, testPresignedURLReplay(endpoint, session, rl)...)
+	vulns = append(vulns, testSigV4AKeyDowngrade(endpoint, session, rl)...)
+
+	// Certificate/mTLS-based webhook authentication probes
+	vulns = append(vulns, testMTLSWebhookAuth(endpoint, session, rl)...)
+
+	// Replay/idempotency probes that go beyond the timestamp window
+	vulns = append(vulns, testNonceReuse(endpoint, session, rl)...)
+	vulns = append(vulns, testIdempotencyKeyBypass(endpoint, session, rl)...)
+	vulns = append(vulns, testMonotonicSequenceGap(endpoint, session, rl)...)
+
 	return vulns
 }
 
 // testTimestampReplay tests if server accepts old callbacks (replay attack)
-func testTimestampReplay(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testTimestampReplay(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Create payload with 10-minute old timestamp
 	oldTimestamp := time.Now().Add(-10 * time.Minute).Unix()
 	payload := map[string]interface{}{
-		"event":       "payment.success",
-		"timestamp":   oldTimestamp,
-		"amount":      1000,
-		"order_id":    "test_replay_001",
-		"status":      "paid",
+		"event":     "payment.success",
+		"timestamp": oldTimestamp,
+		"amount":    1000,
+		"order_id":  "test_replay_001",
+		"status":    "paid",
 	}
-	
+
 	// Generate valid signature with old timestamp
 	payloadJSON, _ := json.Marshal(payload)
 	signature := generateHMACSHA256(payloadJSON, "test_secret_key")
-	
+
 	// Send request
-	client := utils.NewHTTPClient(10 * time.Second)
+	rec := utils.NewEvidenceRecorder(utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint))
 	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Signature", signature)
 	addAuthHeaders(req, session)
-	
-	resp, err := client.Do(req)
+
+	rl.Wait(endpoint.URL)
+	resp, ev, err := rec.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	// If server accepts old timestamp (200/201/204)
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		vulns = append(vulns, models.Vulnerability{
@@ -75,6 +91,7 @@ func testTimestampReplay(endpoint models.Endpoint, session *models.Session) []mo
 			Description: fmt.Sprintf("Server accepted webhook with 10-minute old timestamp (timestamp: %d). Standard practice is to reject webhooks older than 5 minutes.", oldTimestamp),
 			Proof:       fmt.Sprintf("POST %s with timestamp=%d, received %d", endpoint.URL, oldTimestamp, resp.StatusCode),
 			Timestamp:   time.Now(),
+			Evidence:    ev,
 			CWE:         "CWE-294", // Authentication Bypass by Capture-replay
 			CVSSScore:   7.5,
 			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N",
@@ -93,14 +110,14 @@ if time.Since(webhookTime) > maxAge {
 			},
 		})
 	}
-	
+
 	return vulns
 }
 
 // testFutureTimestamp tests if server accepts future timestamps
-func testFutureTimestamp(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testFutureTimestamp(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Create payload with future timestamp (1 hour ahead)
 	futureTimestamp := time.Now().Add(1 * time.Hour).Unix()
 	payload := map[string]interface{}{
@@ -109,30 +126,33 @@ func testFutureTimestamp(endpoint models.Endpoint, session *models.Session) []mo
 		"amount":    1000,
 		"order_id":  "test_future_001",
 	}
-	
+
 	payloadJSON, _ := json.Marshal(payload)
 	signature := generateHMACSHA256(payloadJSON, "test_secret_key")
-	
-	client := utils.NewHTTPClient(10 * time.Second)
+
+	rec := utils.NewEvidenceRecorder(utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint))
 	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Signature", signature)
 	addAuthHeaders(req, session)
-	
-	resp, err := client.Do(req)
+
+	rl.Wait(endpoint.URL)
+	resp, ev, err := rec.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		vulns = append(vulns, models.Vulnerability{
 			Type:        "Callback Timestamp Validation",
 			Severity:    "MEDIUM",
 			Title:       "Webhook Accepts Future Timestamps",
-			Description: fmt.Sprintf("Server accepted webhook with future timestamp (1 hour ahead). This could enable timing attacks.", ),
+			Description: fmt.Sprintf("Server accepted webhook with future timestamp (1 hour ahead). This could enable timing attacks."),
 			Proof:       fmt.Sprintf("POST %s with future timestamp=%d, received %d", endpoint.URL, futureTimestamp, resp.StatusCode),
 			Timestamp:   time.Now(),
+			Evidence:    ev,
 			CWE:         "CWE-696", // Incorrect Behavior Order
 			CVSSScore:   5.3,
 			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:N",
@@ -140,36 +160,38 @@ func testFutureTimestamp(endpoint models.Endpoint, session *models.Session) []mo
 			Remediation: "Reject webhooks with timestamps more than 5 minutes in the future",
 		})
 	}
-	
+
 	return vulns
 }
 
 // testMissingTimestamp tests if server accepts webhooks without timestamp
-func testMissingTimestamp(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testMissingTimestamp(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	payload := map[string]interface{}{
 		"event":    "payment.success",
 		"amount":   1000,
 		"order_id": "test_no_timestamp",
 		// NO timestamp field
 	}
-	
+
 	payloadJSON, _ := json.Marshal(payload)
 	signature := generateHMACSHA256(payloadJSON, "test_secret_key")
-	
-	client := utils.NewHTTPClient(10 * time.Second)
+
+	rec := utils.NewEvidenceRecorder(utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint))
 	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Signature", signature)
 	addAuthHeaders(req, session)
-	
-	resp, err := client.Do(req)
+
+	rl.Wait(endpoint.URL)
+	resp, ev, err := rec.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		vulns = append(vulns, models.Vulnerability{
 			Type:        "Callback Timestamp Validation",
@@ -178,6 +200,7 @@ func testMissingTimestamp(endpoint models.Endpoint, session *models.Session) []m
 			Description: "Server accepted webhook without timestamp field, allowing unlimited replay attacks",
 			Proof:       fmt.Sprintf("POST %s without timestamp, received %d", endpoint.URL, resp.StatusCode),
 			Timestamp:   time.Now(),
+			Evidence:    ev,
 			CWE:         "CWE-20", // Improper Input Validation
 			CVSSScore:   6.5,
 			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N",
@@ -185,47 +208,49 @@ func testMissingTimestamp(endpoint models.Endpoint, session *models.Session) []m
 			Remediation: "Require timestamp field in all webhook payloads and validate it",
 		})
 	}
-	
+
 	return vulns
 }
 
 // testIPSpoofing tests IP whitelist bypass via X-Forwarded-For
-func testIPSpoofing(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testIPSpoofing(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	payload := map[string]interface{}{
 		"event":     "payment.success",
 		"timestamp": time.Now().Unix(),
 		"amount":    1000,
 		"order_id":  "test_ip_spoof",
 	}
-	
+
 	payloadJSON, _ := json.Marshal(payload)
 	signature := generateHMACSHA256(payloadJSON, "test_secret_key")
-	
+
 	// Test various IP spoofing headers
 	spoofHeaders := map[string]string{
-		"X-Forwarded-For":   "127.0.0.1",
-		"X-Real-IP":         "127.0.0.1",
-		"X-Originating-IP":  "127.0.0.1",
-		"X-Client-IP":       "127.0.0.1",
-		"True-Client-IP":    "127.0.0.1",
+		"X-Forwarded-For":  "127.0.0.1",
+		"X-Real-IP":        "127.0.0.1",
+		"X-Originating-IP": "127.0.0.1",
+		"X-Client-IP":      "127.0.0.1",
+		"True-Client-IP":   "127.0.0.1",
 	}
-	
+
 	for headerName, headerValue := range spoofHeaders {
-		client := utils.NewHTTPClient(10 * time.Second)
+		rec := utils.NewEvidenceRecorder(utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint))
 		req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("X-Signature", signature)
 		req.Header.Set(headerName, headerValue)
 		addAuthHeaders(req, session)
-		
-		resp, err := client.Do(req)
+
+		rl.Wait(endpoint.URL)
+		resp, ev, err := rec.Do(req)
 		if err != nil {
 			continue
 		}
 		resp.Body.Close()
-		
+		rl.RecordHTTPResponse(endpoint.URL, resp)
+
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			vulns = append(vulns, models.Vulnerability{
 				Type:        "Callback IP Whitelist Bypass",
@@ -234,6 +259,7 @@ func testIPSpoofing(endpoint models.Endpoint, session *models.Session) []models.
 				Description: fmt.Sprintf("Server trusts %s header for IP whitelisting, allowing attackers to bypass IP restrictions", headerName),
 				Proof:       fmt.Sprintf("POST %s with %s: 127.0.0.1, received %d", endpoint.URL, headerName, resp.StatusCode),
 				Timestamp:   time.Now(),
+				Evidence:    ev,
 				CWE:         "CWE-290", // Authentication Bypass by Spoofing
 				CVSSScore:   8.1,
 				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
@@ -247,46 +273,48 @@ func testIPSpoofing(endpoint models.Endpoint, session *models.Session) []models.
 			break // Only report once if any header works
 		}
 	}
-	
+
 	return vulns
 }
 
 // testMultipleSignatureAlgorithms tests different HMAC algorithms
-func testMultipleSignatureAlgorithms(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testMultipleSignatureAlgorithms(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	payload := map[string]interface{}{
 		"event":     "payment.success",
 		"timestamp": time.Now().Unix(),
 		"amount":    1000,
 		"order_id":  "test_multi_algo",
 	}
-	
+
 	payloadJSON, _ := json.Marshal(payload)
-	
+
 	// Test different algorithms
 	algorithms := map[string]func([]byte, string) string{
 		"HMAC-SHA256": generateHMACSHA256,
 		"HMAC-SHA512": generateHMACSHA512,
 		"Weak-MD5":    generateWeakSignature,
 	}
-	
+
 	for algoName, signFunc := range algorithms {
 		signature := signFunc(payloadJSON, "test_secret_key")
-		
-		client := utils.NewHTTPClient(10 * time.Second)
+
+		rec := utils.NewEvidenceRecorder(utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint))
 		req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("X-Signature", signature)
 		req.Header.Set("X-Signature-Algorithm", algoName)
 		addAuthHeaders(req, session)
-		
-		resp, err := client.Do(req)
+
+		rl.Wait(endpoint.URL)
+		resp, ev, err := rec.Do(req)
 		if err != nil {
 			continue
 		}
 		resp.Body.Close()
-		
+		rl.RecordHTTPResponse(endpoint.URL, resp)
+
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 && algoName == "Weak-MD5" {
 			vulns = append(vulns, models.Vulnerability{
 				Type:        "Callback Weak Signature",
@@ -295,6 +323,7 @@ func testMultipleSignatureAlgorithms(endpoint models.Endpoint, session *models.S
 				Description: "Server accepts MD5-based signatures which are cryptographically weak",
 				Proof:       fmt.Sprintf("POST %s with MD5 signature, received %d", endpoint.URL, resp.StatusCode),
 				Timestamp:   time.Now(),
+				Evidence:    ev,
 				CWE:         "CWE-327", // Use of Broken Cryptographic Algorithm
 				CVSSScore:   5.9,
 				CVSSVector:  "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:H/A:N",
@@ -303,39 +332,41 @@ func testMultipleSignatureAlgorithms(endpoint models.Endpoint, session *models.S
 			})
 		}
 	}
-	
+
 	return vulns
 }
 
 // testSignatureStripEncoding tests signature bypass via double URL encoding
-func testSignatureStripEncoding(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testSignatureStripEncoding(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	payload := map[string]interface{}{
 		"event":     "payment.success",
 		"timestamp": time.Now().Unix(),
 		"amount":    1000,
 		"order_id":  "test_encoding",
 	}
-	
+
 	payloadJSON, _ := json.Marshal(payload)
 	validSignature := generateHMACSHA256(payloadJSON, "test_secret_key")
-	
+
 	// Try double URL encoding signature
 	doubleEncoded := url.QueryEscape(url.QueryEscape(validSignature))
-	
-	client := utils.NewHTTPClient(10 * time.Second)
+
+	rec := utils.NewEvidenceRecorder(utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint))
 	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Signature", doubleEncoded)
 	addAuthHeaders(req, session)
-	
-	resp, err := client.Do(req)
+
+	rl.Wait(endpoint.URL)
+	resp, ev, err := rec.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	// If server accepts double-encoded signature, it might be stripping encoding without validation
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		vulns = append(vulns, models.Vulnerability{
@@ -345,6 +376,7 @@ func testSignatureStripEncoding(endpoint models.Endpoint, session *models.Sessio
 			Description: "Server accepts double URL-encoded signatures, indicating improper validation logic",
 			Proof:       fmt.Sprintf("POST %s with double-encoded signature, received %d", endpoint.URL, resp.StatusCode),
 			Timestamp:   time.Now(),
+			Evidence:    ev,
 			CWE:         "CWE-838", // Inappropriate Encoding for Output Context
 			CVSSScore:   7.5,
 			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N",
@@ -352,7 +384,7 @@ func testSignatureStripEncoding(endpoint models.Endpoint, session *models.Sessio
 			Remediation: "Validate signature before any encoding/decoding operations",
 		})
 	}
-	
+
 	return vulns
 }
 
@@ -375,12 +407,12 @@ func generateWeakSignature(data []byte, secret string) string {
 	return fmt.Sprintf("%x", data[:8])
 }
 
-func testMissingSignature(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testMissingSignature(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	// Existing implementation...
 	return []models.Vulnerability{}
 }
 
-func testInvalidSignature(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testInvalidSignature(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	// Existing implementation...
 	return []models.Vulnerability{}
 }
@@ -0,0 +1,282 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// testMTLSWebhookAuth probes whether a callback endpoint that's meant to
+// require mutual TLS actually enforces it, alongside the signature-based
+// probes in callback.go and callback_sigv4.go.
+func testMTLSWebhookAuth(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	vulns = append(vulns, testMTLSNoClientCert(endpoint, session, rl)...)
+	vulns = append(vulns, testMTLSSpoofedCN(endpoint, session, rl)...)
+	vulns = append(vulns, testMTLSExpiredCert(endpoint, session, rl)...)
+	vulns = append(vulns, testMTLSSignatureOverCleartext(endpoint, session, rl)...)
+
+	return vulns
+}
+
+// testMTLSNoClientCert tests whether the endpoint accepts a request
+// carrying no client certificate at all, when session.ClientCert being set
+// says the operator expects one to be required.
+func testMTLSNoClientCert(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+	if session == nil || session.ClientCert == nil {
+		return vulns
+	}
+
+	payload := map[string]interface{}{
+		"event":    "payment.success",
+		"amount":   1000,
+		"order_id": "test_mtls_noclientcert_001",
+	}
+	payloadJSON, _ := json.Marshal(payload)
+
+	client := utils.NewHTTPClient(10 * time.Second) // deliberately no client cert attached
+	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", generateHMACSHA256(payloadJSON, "test_secret_key"))
+	addAuthHeaders(req, session)
+
+	rl.Wait(endpoint.URL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return vulns
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "mTLS Not Enforced",
+			Severity:    "CRITICAL",
+			Title:       "Webhook Accepts Requests Without a Client Certificate",
+			Description: "The endpoint is configured to expect mTLS (session.ClientCert is set) but accepted a request carrying no client certificate at all, indicating the TLS layer isn't actually enforcing mutual authentication.",
+			Proof:       fmt.Sprintf("POST %s over TLS with no client certificate, received %d", endpoint.URL, resp.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-295",
+			CVSSScore:   9.1,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+			Confidence:  "High",
+			Remediation: "Configure the TLS listener/load balancer to require and verify a client certificate (mTLS) before the request reaches application code.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/295.html"},
+		})
+	}
+
+	return vulns
+}
+
+// testMTLSSpoofedCN tests whether the endpoint accepts a self-signed,
+// untrusted client certificate whose CommonName was set to a legitimate
+// provider's domain, which would mean it trusts the CN field rather than
+// verifying the certificate chains to a CA it actually trusts.
+func testMTLSSpoofedCN(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	const spoofedCN = "stripe.com"
+	cert, err := generateSelfSignedClientCert(spoofedCN, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+	if err != nil {
+		return vulns
+	}
+
+	payload := map[string]interface{}{
+		"event":    "payment.success",
+		"amount":   1000,
+		"order_id": "test_mtls_spoofcn_001",
+	}
+	payloadJSON, _ := json.Marshal(payload)
+
+	client := utils.NewMTLSHTTPClient(10*time.Second, cert)
+	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", generateHMACSHA256(payloadJSON, "test_secret_key"))
+	addAuthHeaders(req, session)
+
+	rl.Wait(endpoint.URL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return vulns
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "mTLS CN Spoofing",
+			Severity:    "CRITICAL",
+			Title:       "Webhook Accepts Self-Signed Client Certificate With Spoofed CN",
+			Description: fmt.Sprintf("A self-signed, untrusted client certificate whose CommonName was set to %q (a legitimate provider's domain) was accepted, indicating the server trusts the certificate's CN field without verifying the certificate chains to a CA it actually trusts.", spoofedCN),
+			Proof:       fmt.Sprintf("POST %s with a self-signed client cert CN=%s, received %d", endpoint.URL, spoofedCN, resp.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-295",
+			CVSSScore:   9.8,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+			Confidence:  "High",
+			Remediation: "Verify the client certificate chains to a CA you actually trust (not just that a CN matches an expected string) before accepting the connection.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/295.html"},
+		})
+	}
+
+	return vulns
+}
+
+// testMTLSExpiredCert tests whether the endpoint accepts a client
+// certificate past its NotAfter. The request also asks to test against a
+// supplied CRL/OCSP responder URL; there's currently no config surface
+// carrying that URL through to the scanner, so this only covers the
+// locally-checkable expiry case - a revocation check needs that responder
+// URL threaded through models.ScanConfig before it can be added here.
+func testMTLSExpiredCert(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	cert, err := generateSelfSignedClientCert("webhook-client.internal", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return vulns
+	}
+
+	payload := map[string]interface{}{
+		"event":    "payment.success",
+		"amount":   1000,
+		"order_id": "test_mtls_expired_001",
+	}
+	payloadJSON, _ := json.Marshal(payload)
+
+	client := utils.NewMTLSHTTPClient(10*time.Second, cert)
+	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", generateHMACSHA256(payloadJSON, "test_secret_key"))
+	addAuthHeaders(req, session)
+
+	rl.Wait(endpoint.URL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return vulns
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "mTLS Expired Certificate Accepted",
+			Severity:    "HIGH",
+			Title:       "Webhook Accepts an Expired Client Certificate",
+			Description: "A client certificate whose validity window (NotBefore/NotAfter) ended 24 hours ago was still accepted, indicating the server isn't checking certificate expiry (and likely isn't checking revocation either).",
+			Proof:       fmt.Sprintf("POST %s with a client cert expired 24h ago, received %d", endpoint.URL, resp.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-295",
+			CVSSScore:   8.1,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+			Confidence:  "High",
+			Remediation: "Reject expired client certificates, and check revocation status (CRL/OCSP) for still-valid ones before authenticating the request.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/295.html"},
+		})
+	}
+
+	return vulns
+}
+
+// testMTLSSignatureOverCleartext tests whether the same HMAC signature
+// that's valid over the endpoint's HTTPS URL is also accepted over plain
+// HTTP, which would mean the signature authenticates the payload but
+// isn't bound to the TLS channel (or client certificate) a webhook meant
+// to require mTLS should be checking.
+func testMTLSSignatureOverCleartext(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+	if !strings.HasPrefix(endpoint.URL, "https://") {
+		return vulns
+	}
+	cleartextURL := "http://" + strings.TrimPrefix(endpoint.URL, "https://")
+
+	payload := map[string]interface{}{
+		"event":    "payment.success",
+		"amount":   1000,
+		"order_id": "test_mtls_cleartext_001",
+	}
+	payloadJSON, _ := json.Marshal(payload)
+	signature := generateHMACSHA256(payloadJSON, "test_secret_key")
+
+	client := utils.NewHTTPClient(10 * time.Second)
+	req, _ := http.NewRequest("POST", cleartextURL, bytes.NewBuffer(payloadJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", signature)
+	addAuthHeaders(req, session)
+
+	rl.Wait(cleartextURL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return vulns
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(cleartextURL, resp)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "Signature Not Bound to TLS Channel",
+			Severity:    "HIGH",
+			Title:       "Webhook Signature Accepted Over Cleartext HTTP",
+			Description: "The same HMAC signature that's valid over HTTPS was also accepted over plain HTTP, meaning the signature authenticates the payload but not the transport - it doesn't bind to the TLS channel the way a webhook meant to require mTLS should.",
+			Proof:       fmt.Sprintf("POST %s with a signature computed for the HTTPS endpoint, received %d", cleartextURL, resp.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-319",
+			CVSSScore:   7.4,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+			Confidence:  "Medium",
+			Remediation: "Reject webhook callbacks received over a non-TLS (or non-mTLS) channel regardless of whether the payload signature is otherwise valid.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/319.html"},
+		})
+	}
+
+	return vulns
+}
+
+// generateSelfSignedClientCert builds a throwaway self-signed ECDSA
+// P-256 client certificate with the given CommonName and validity window,
+// for probes that need to present a client certificate the scanner fully
+// controls (it's never expected to be trusted by anything).
+func generateSelfSignedClientCert(commonName string, notBefore, notAfter time.Time) (*tls.Certificate, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating client cert key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("generating client cert serial: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, fmt.Errorf("creating self-signed client cert: %w", err)
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  priv,
+	}, nil
+}
@@ -0,0 +1,231 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// testNonceReuse tests whether a server that only validates the
+// timestamp window still rejects the exact same signed payload replayed
+// a second time with a fresh timestamp - testTimestampReplay only checks
+// that old timestamps get rejected, not that a payload can't simply be
+// resent while it's still within the window.
+func testNonceReuse(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	payload := map[string]interface{}{
+		"event":     "payment.success",
+		"timestamp": time.Now().Unix(),
+		"amount":    1000,
+		"order_id":  "test_nonce_reuse_001",
+		"nonce":     "fixed_nonce_for_replay_test",
+	}
+	payloadJSON, _ := json.Marshal(payload)
+	signature := generateHMACSHA256(payloadJSON, "test_secret_key")
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+
+	send := func() (*http.Response, error) {
+		req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Signature", signature)
+		addAuthHeaders(req, session)
+
+		rl.Wait(endpoint.URL)
+		resp, err := client.Do(req)
+		if err == nil {
+			rl.RecordHTTPResponse(endpoint.URL, resp)
+		}
+		return resp, err
+	}
+
+	first, err := send()
+	if err != nil {
+		return vulns
+	}
+	first.Body.Close()
+	if first.StatusCode < 200 || first.StatusCode >= 300 {
+		return vulns // server never even accepted the original, nothing to replay
+	}
+
+	second, err := send()
+	if err != nil {
+		return vulns
+	}
+	defer second.Body.Close()
+
+	if second.StatusCode >= 200 && second.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "Replay Attack - Nonce Reuse",
+			Severity:    "HIGH",
+			Title:       "Webhook Accepts Identical Payload Replayed Within Timestamp Window",
+			Description: "The exact same signed payload was accepted twice in a row, with a fresh timestamp both times, meaning the server doesn't track which nonces/payloads it has already processed and relies on the timestamp window alone to prevent replay.",
+			Proof:       fmt.Sprintf("POST %s twice with an identical body/signature, both received 2xx (first=%d, second=%d)", endpoint.URL, first.StatusCode, second.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-294",
+			CVSSScore:   7.5,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N",
+			Confidence:  "High",
+			Remediation: "Track processed nonces/event IDs (not just timestamps) and reject a second request carrying one already seen.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/294.html"},
+		})
+	}
+
+	return vulns
+}
+
+// testIdempotencyKeyBypass tests whether a server keys idempotency purely
+// on the Idempotency-Key header value, by reusing a previously-accepted
+// key alongside a materially mutated body.
+func testIdempotencyKeyBypass(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	const idempotencyKey = "idem_test_fixed_key_001"
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+
+	firstPayload := map[string]interface{}{
+		"event":     "payment.success",
+		"timestamp": time.Now().Unix(),
+		"amount":    1000,
+		"order_id":  "test_idem_bypass_001",
+	}
+	firstJSON, _ := json.Marshal(firstPayload)
+
+	req1, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(firstJSON))
+	req1.Header.Set("Content-Type", "application/json")
+	req1.Header.Set("X-Signature", generateHMACSHA256(firstJSON, "test_secret_key"))
+	req1.Header.Set("Idempotency-Key", idempotencyKey)
+	addAuthHeaders(req1, session)
+
+	rl.Wait(endpoint.URL)
+	resp1, err := client.Do(req1)
+	if err != nil {
+		return vulns
+	}
+	resp1.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp1)
+	if resp1.StatusCode < 200 || resp1.StatusCode >= 300 {
+		return vulns
+	}
+
+	// Same Idempotency-Key, but a materially different body (a different
+	// amount) with its own matching signature - a server that keys
+	// idempotency purely off the header, without checking the request
+	// it's deduplicating against actually matches, will process this as
+	// if it were the same request.
+	mutatedPayload := map[string]interface{}{
+		"event":     "payment.success",
+		"timestamp": time.Now().Unix(),
+		"amount":    999999,
+		"order_id":  "test_idem_bypass_001",
+	}
+	mutatedJSON, _ := json.Marshal(mutatedPayload)
+
+	req2, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(mutatedJSON))
+	req2.Header.Set("Content-Type", "application/json")
+	req2.Header.Set("X-Signature", generateHMACSHA256(mutatedJSON, "test_secret_key"))
+	req2.Header.Set("Idempotency-Key", idempotencyKey)
+	addAuthHeaders(req2, session)
+
+	rl.Wait(endpoint.URL)
+	resp2, err := client.Do(req2)
+	if err != nil {
+		return vulns
+	}
+	defer resp2.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp2)
+
+	if resp2.StatusCode >= 200 && resp2.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "Idempotency Key Trust Bypass",
+			Severity:    "HIGH",
+			Title:       "Webhook Accepts Mutated Payload Reusing an Existing Idempotency Key",
+			Description: "A second request reusing the same Idempotency-Key but a different amount was accepted, indicating the server keys idempotency purely on the header value rather than also verifying the request it's deduplicating against actually matches.",
+			Proof:       fmt.Sprintf("POST %s with Idempotency-Key=%s and a mutated amount, received %d", endpoint.URL, idempotencyKey, resp2.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-807",
+			CVSSScore:   8.1,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N",
+			Confidence:  "Medium",
+			Remediation: "Hash the full request (or at least its security-relevant fields) alongside the idempotency key, and reject a reused key whose request no longer matches the original.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/807.html"},
+		})
+	}
+
+	return vulns
+}
+
+// testMonotonicSequenceGap tests whether the endpoint enforces Stripe-
+// style event.id anti-reordering by processing a later sequence number
+// first and then replaying an earlier one, which a server tracking the
+// highest event.id it has seen per source should reject as stale.
+func testMonotonicSequenceGap(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+
+	send := func(eventID string) (*http.Response, error) {
+		payload := map[string]interface{}{
+			"event":     "payment.success",
+			"timestamp": time.Now().Unix(),
+			"amount":    1000,
+			"order_id":  "test_seq_gap_001",
+			"event_id":  eventID,
+		}
+		payloadJSON, _ := json.Marshal(payload)
+
+		req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Signature", generateHMACSHA256(payloadJSON, "test_secret_key"))
+		addAuthHeaders(req, session)
+
+		rl.Wait(endpoint.URL)
+		resp, err := client.Do(req)
+		if err == nil {
+			rl.RecordHTTPResponse(endpoint.URL, resp)
+		}
+		return resp, err
+	}
+
+	// Process a later sequence number first...
+	later, err := send("evt_00000100")
+	if err != nil {
+		return vulns
+	}
+	later.Body.Close()
+	if later.StatusCode < 200 || later.StatusCode >= 300 {
+		return vulns
+	}
+
+	// ...then replay an earlier one. An endpoint enforcing anti-
+	// reordering should reject this as stale/out-of-order.
+	earlier, err := send("evt_00000050")
+	if err != nil {
+		return vulns
+	}
+	defer earlier.Body.Close()
+
+	if earlier.StatusCode >= 200 && earlier.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "Missing Anti-Reordering Check",
+			Severity:    "MEDIUM",
+			Title:       "Webhook Accepts Out-of-Order Event Sequence Numbers",
+			Description: "After processing event_id=evt_00000100, the endpoint also accepted evt_00000050 (an earlier sequence number) rather than rejecting it as stale, indicating it doesn't enforce monotonic event ordering per source.",
+			Proof:       fmt.Sprintf("POST %s with event_id=evt_00000100 then evt_00000050, both received 2xx (first=%d, second=%d)", endpoint.URL, later.StatusCode, earlier.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-294",
+			CVSSScore:   5.3,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:N",
+			Confidence:  "Medium",
+			Remediation: "Track the highest event.id processed per source and reject events whose sequence number is not greater than what's already been processed.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/294.html"},
+		})
+	}
+
+	return vulns
+}
@@ -0,0 +1,304 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// sigV4TestCredentials are the throwaway AWS-shaped credentials every
+// SigV4/SigV4A probe in this file signs with - there's no real AWS account
+// behind them, they just need to be well-formed enough to produce a
+// structurally valid signature for the server to (mis)validate.
+var sigV4TestCredentials = SigV4Credentials{
+	AccessKeyID:     "AKIATESTSCANNER0001",
+	SecretAccessKey: "test_secret_key",
+	Region:          "us-east-1",
+	Service:         "execute-api",
+}
+
+// testSigV4CanonicalizationMismatch tests whether a server that validates
+// SigV4 signatures actually enforces that SignedHeaders covers every
+// header it trusts, by smuggling a routing-relevant header outside of it.
+func testSigV4CanonicalizationMismatch(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	payload := map[string]interface{}{
+		"event":    "payment.success",
+		"amount":   1000,
+		"order_id": "test_sigv4_canon_001",
+	}
+	payloadJSON, _ := json.Marshal(payload)
+
+	rec := utils.NewEvidenceRecorder(utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint))
+	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
+	req.Header.Set("Content-Type", "application/json")
+	addAuthHeaders(req, session)
+
+	headers, err := (sigV4Scheme{}).Sign(req, payloadJSON, sigV4TestCredentials, time.Now())
+	if err != nil {
+		return vulns
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	// Smuggle a header the signature doesn't cover - a server that trusts
+	// it for routing/auth decisions without checking it's listed in
+	// SignedHeaders is vulnerable to having it altered in transit without
+	// invalidating the signature.
+	req.Header.Set("X-Forwarded-Host", "attacker.example.com")
+
+	rl.Wait(endpoint.URL)
+	resp, ev, err := rec.Do(req)
+	if err != nil {
+		return vulns
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "SigV4 Canonicalization Mismatch",
+			Severity:    "HIGH",
+			Title:       "SigV4 Signature Does Not Cover Security-Relevant Header",
+			Description: "Server accepted a SigV4-signed webhook carrying an X-Forwarded-Host header outside of SignedHeaders, meaning a header the signature is supposed to protect can be altered without invalidating the signature.",
+			Proof:       fmt.Sprintf("POST %s with Authorization=%s but an unsigned X-Forwarded-Host, received %d", endpoint.URL, headers["Authorization"], resp.StatusCode),
+			Timestamp:   time.Now(),
+			Evidence:    ev,
+			CWE:         "CWE-347",
+			CVSSScore:   7.5,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N",
+			Confidence:  "Medium",
+			Remediation: "Include every header the handler relies on (especially routing/host headers) in SignedHeaders, and reject requests whose SignedHeaders list omits them.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/347.html"},
+		})
+	}
+
+	return vulns
+}
+
+// testSigV4ScopeConfusion tests whether a server accepts a SigV4
+// signature whose Credential scope was swapped for a different
+// region/service after signing, which would indicate it trusts the scope
+// string rather than recomputing the signature against it.
+func testSigV4ScopeConfusion(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	payload := map[string]interface{}{
+		"event":    "payment.success",
+		"amount":   1000,
+		"order_id": "test_sigv4_scope_001",
+	}
+	payloadJSON, _ := json.Marshal(payload)
+
+	signedCreds := sigV4TestCredentials
+	signedCreds.Region = "us-west-2"
+	signedCreds.Service = "sns"
+	claimedScope := "us-east-1/execute-api"
+
+	rec := utils.NewEvidenceRecorder(utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint))
+	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
+	req.Header.Set("Content-Type", "application/json")
+	addAuthHeaders(req, session)
+
+	headers, err := (sigV4Scheme{}).Sign(req, payloadJSON, signedCreds, time.Now())
+	if err != nil {
+		return vulns
+	}
+
+	// Reuse the signature and SignedHeaders list verbatim but swap in a
+	// different region/service scope in the Authorization preamble - a
+	// server that only string-matches the Credential scope against an
+	// allow-list, rather than recomputing the signature with it, can be
+	// fooled into accepting a signature that was never produced for that
+	// scope.
+	confusedAuth := strings.Replace(headers["Authorization"],
+		fmt.Sprintf("%s/%s/aws4_request", signedCreds.Region, signedCreds.Service),
+		fmt.Sprintf("%s/aws4_request", claimedScope), 1)
+
+	req.Header.Set("X-Amz-Date", headers["X-Amz-Date"])
+	req.Header.Set("Authorization", confusedAuth)
+
+	rl.Wait(endpoint.URL)
+	resp, ev, err := rec.Do(req)
+	if err != nil {
+		return vulns
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "SigV4 Scope Confusion",
+			Severity:    "HIGH",
+			Title:       "SigV4 Signature Accepted Across Mismatched Region/Service Scope",
+			Description: fmt.Sprintf("A signature computed for scope %s/%s was accepted under the claimed scope %s, indicating the server trusts the Credential scope string instead of recomputing the signature against it.", signedCreds.Region, signedCreds.Service, claimedScope),
+			Proof:       fmt.Sprintf("POST %s with Authorization=%s, received %d", endpoint.URL, confusedAuth, resp.StatusCode),
+			Timestamp:   time.Now(),
+			Evidence:    ev,
+			CWE:         "CWE-347",
+			CVSSScore:   8.1,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+			Confidence:  "Medium",
+			Remediation: "Recompute the expected signature using the server's own known region/service scope rather than trusting the Credential scope supplied in the request.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/347.html"},
+		})
+	}
+
+	return vulns
+}
+
+// testPresignedURLReplay tests whether a server honors X-Amz-Expires on a
+// presigned webhook callback URL, by firing one whose expiry window has
+// already elapsed.
+func testPresignedURLReplay(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	creds := sigV4TestCredentials
+
+	// Build the presigned URL as if issued 10 minutes ago with a 60s
+	// expiry, so firing it now replays it well past X-Amz-Expires without
+	// needing to actually wait out a real expiry window.
+	issuedAt := time.Now().Add(-10 * time.Minute)
+	const expirySeconds = 60
+
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return vulns
+	}
+	dateStamp := issuedAt.UTC().Format("20060102")
+	amzDate := issuedAt.UTC().Format("20060102T150405Z")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", dateStamp, creds.Region, creds.Service)
+
+	q := u.Query()
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", fmt.Sprintf("%s/%s", creds.AccessKeyID, scope))
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", fmt.Sprintf("%d", expirySeconds))
+	q.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = q.Encode()
+
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		sigV4CanonicalURI(u),
+		sigV4CanonicalQueryString(u),
+		fmt.Sprintf("host:%s\n", u.Host),
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	signingKey := sigV4SigningKey(creds.SecretAccessKey, dateStamp, creds.Region, creds.Service)
+	signature := hex.EncodeToString(hmacSHA256Bytes(signingKey, stringToSign))
+
+	q.Set("X-Amz-Signature", signature)
+	u.RawQuery = q.Encode()
+
+	rec := utils.NewEvidenceRecorder(utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint))
+	req, _ := http.NewRequest("GET", u.String(), nil)
+	addAuthHeaders(req, session)
+
+	rl.Wait(endpoint.URL)
+	resp, ev, err := rec.Do(req)
+	if err != nil {
+		return vulns
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		expiredSince := time.Since(issuedAt.Add(expirySeconds * time.Second)).Round(time.Second)
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "Presigned URL Replay",
+			Severity:    "HIGH",
+			Title:       "Expired Presigned URL Accepted",
+			Description: fmt.Sprintf("A presigned URL issued at %s with X-Amz-Expires=%d (expired %s ago) was still accepted.", amzDate, expirySeconds, expiredSince),
+			Proof:       fmt.Sprintf("GET %s, received %d", u.String(), resp.StatusCode),
+			Timestamp:   time.Now(),
+			Evidence:    ev,
+			CWE:         "CWE-294",
+			CVSSScore:   7.5,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N",
+			Confidence:  "High",
+			Remediation: "Reject presigned requests once X-Amz-Date + X-Amz-Expires has passed.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/294.html"},
+		})
+	}
+
+	return vulns
+}
+
+// testSigV4AKeyDowngrade tests whether a server that accepts SigV4A
+// signatures cross-checks the Authorization header's declared algorithm
+// against the signature it actually received, by relabeling a genuine
+// ECDSA SigV4A signature as the symmetric AWS4-HMAC-SHA256 algorithm.
+func testSigV4AKeyDowngrade(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	payload := map[string]interface{}{
+		"event":    "payment.success",
+		"amount":   1000,
+		"order_id": "test_sigv4a_downgrade_001",
+	}
+	payloadJSON, _ := json.Marshal(payload)
+
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(payloadJSON))
+	req.Header.Set("Content-Type", "application/json")
+	addAuthHeaders(req, session)
+
+	headers, err := (sigV4AScheme{}).Sign(req, payloadJSON, sigV4TestCredentials, time.Now())
+	if err != nil {
+		return vulns
+	}
+
+	// Keep the genuine SigV4A (ECDSA) signature bytes but claim the
+	// symmetric AWS4-HMAC-SHA256 algorithm in the preamble, to see if the
+	// server picks its verification path from the algorithm string
+	// without checking the bytes actually are a valid signature for it.
+	downgraded := strings.Replace(headers["Authorization"], "AWS4-ECDSA-P256-SHA256", "AWS4-HMAC-SHA256", 1)
+
+	req.Header.Set("X-Amz-Date", headers["X-Amz-Date"])
+	req.Header.Set("X-Amz-Region-Set", headers["X-Amz-Region-Set"])
+	req.Header.Set("Authorization", downgraded)
+
+	rl.Wait(endpoint.URL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return vulns
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "SigV4A Algorithm Downgrade",
+			Severity:    "CRITICAL",
+			Title:       "Server Accepts SigV4A Signature Under Claimed SigV4 Algorithm",
+			Description: "Server accepted a request carrying an ECDSA SigV4A signature while the Authorization header claimed the symmetric AWS4-HMAC-SHA256 algorithm, indicating the algorithm preamble isn't cross-checked against the signature actually supplied.",
+			Proof:       fmt.Sprintf("POST %s with Authorization=%s, received %d", endpoint.URL, downgraded, resp.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-347",
+			CVSSScore:   9.1,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+			Confidence:  "Medium",
+			Remediation: "Validate that the Authorization header's declared algorithm matches the structure of the signature presented, and reject any mismatch outright.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/347.html"},
+		})
+	}
+
+	return vulns
+}
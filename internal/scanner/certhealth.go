@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// certExpiryWarningWindow is how close to NotAfter a certificate can be
+// before CheckCertificateHealth flags it as expiring soon.
+const certExpiryWarningWindow = 7 * 24 * time.Hour
+
+// shortLivedCertThreshold is the total validity window (NotAfter -
+// NotBefore) below which a cert is flagged as suspiciously short-lived -
+// legitimate CAs rarely issue anything under a day, so this usually means
+// a dev/test/throwaway cert slipped into a target that should be using a
+// normal one.
+const shortLivedCertThreshold = 24 * time.Hour
+
+// weakSignatureAlgorithms are x509.SignatureAlgorithm values considered
+// cryptographically weak (broken hash, or an algorithm deprecated for new
+// issuance) for TLS server certificates.
+var weakSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.ECDSAWithSHA1: true,
+}
+
+// CheckCertificateHealth connects to targetURL's host over TLS (presenting
+// session's client certificate, if any, the same as every other mTLS-aware
+// request this scanner makes), records the server's certificate chain, and
+// flags informational findings for weak signature algorithms and
+// short-lived/near-expiry certificates. A non-HTTPS targetURL or a failed
+// handshake returns an empty chain and no error - TLS posture simply isn't
+// applicable.
+func CheckCertificateHealth(targetURL string, session *models.Session) ([]models.CertificateInfo, []models.Vulnerability) {
+	u, err := url.Parse(targetURL)
+	if err != nil || u.Scheme != "https" {
+		return nil, nil
+	}
+
+	host := u.Host
+	if !strings.Contains(host, ":") {
+		host = host + ":443"
+	}
+
+	tlsCfg := utils.TLSClientConfigForEndpoint(session, models.Endpoint{})
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", host, tlsCfg)
+	if err != nil {
+		return nil, nil
+	}
+	defer conn.Close()
+
+	chain := conn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, nil
+	}
+
+	infos := make([]models.CertificateInfo, 0, len(chain))
+	vulns := []models.Vulnerability{}
+	for _, cert := range chain {
+		infos = append(infos, models.CertificateInfo{
+			Subject:            cert.Subject.String(),
+			Issuer:             cert.Issuer.String(),
+			SerialNumber:       cert.SerialNumber.String(),
+			SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+			NotBefore:          cert.NotBefore,
+			NotAfter:           cert.NotAfter,
+		})
+		vulns = append(vulns, certificateFindings(targetURL, cert)...)
+	}
+
+	return infos, vulns
+}
+
+// certificateFindings returns the informational findings (if any) for a
+// single certificate in the chain.
+func certificateFindings(targetURL string, cert *x509.Certificate) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	if weakSignatureAlgorithms[cert.SignatureAlgorithm] {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "TLS Certificate Weak Signature",
+			Severity:    "LOW",
+			Title:       "Server Certificate Uses a Weak Signature Algorithm",
+			Description: fmt.Sprintf("Certificate %q is signed with %s, which is considered cryptographically weak for new issuance.", cert.Subject.CommonName, cert.SignatureAlgorithm),
+			Endpoint:    targetURL,
+			Proof:       fmt.Sprintf("Signature algorithm: %s, serial: %s", cert.SignatureAlgorithm, cert.SerialNumber.String()),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-327",
+			Confidence:  "High",
+			Impact:      "A weak signature algorithm makes the certificate easier to forge and erodes trust in the chain.",
+			Remediation: "Reissue the certificate with a modern signature algorithm (SHA-256 or stronger with RSA/ECDSA).",
+			References: []string{
+				"https://cwe.mitre.org/data/definitions/327.html",
+			},
+		})
+	}
+
+	validity := cert.NotAfter.Sub(cert.NotBefore)
+	if validity > 0 && validity < shortLivedCertThreshold {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "TLS Certificate Short-Lived",
+			Severity:    "LOW",
+			Title:       "Server Certificate Has an Unusually Short Validity Window",
+			Description: fmt.Sprintf("Certificate %q is valid for only %s (%s -> %s), suggesting a dev/test certificate rather than a normally issued one.", cert.Subject.CommonName, validity, cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339)),
+			Endpoint:    targetURL,
+			Proof:       fmt.Sprintf("NotBefore=%s NotAfter=%s", cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339)),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-295",
+			Confidence:  "Medium",
+			Impact:      "Short-lived certificates are a common sign of a non-production or misconfigured TLS endpoint.",
+			Remediation: "Confirm this target is meant to be reachable at all, and issue a normal-duration certificate if it is.",
+			References: []string{
+				"https://cwe.mitre.org/data/definitions/295.html",
+			},
+		})
+	}
+
+	if until := time.Until(cert.NotAfter); until > 0 && until < certExpiryWarningWindow {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "TLS Certificate Expiring Soon",
+			Severity:    "LOW",
+			Title:       "Server Certificate Expires Within a Week",
+			Description: fmt.Sprintf("Certificate %q expires %s.", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339)),
+			Endpoint:    targetURL,
+			Proof:       fmt.Sprintf("NotAfter=%s", cert.NotAfter.Format(time.RFC3339)),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-298",
+			Confidence:  "High",
+			Impact:      "An expired certificate will break TLS connectivity for clients that validate the chain.",
+			Remediation: "Renew the certificate before it expires.",
+			References: []string{
+				"https://cwe.mitre.org/data/definitions/298.html",
+			},
+		})
+	} else if until <= 0 {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "TLS Certificate Expired",
+			Severity:    "MEDIUM",
+			Title:       "Server Certificate Has Expired",
+			Description: fmt.Sprintf("Certificate %q expired %s.", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339)),
+			Endpoint:    targetURL,
+			Proof:       fmt.Sprintf("NotAfter=%s", cert.NotAfter.Format(time.RFC3339)),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-298",
+			Confidence:  "High",
+			Impact:      "Clients that validate the certificate chain will refuse to connect.",
+			Remediation: "Renew the certificate immediately.",
+			References: []string{
+				"https://cwe.mitre.org/data/definitions/298.html",
+			},
+		})
+	}
+
+	return vulns
+}
@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// sensitiveCookieKeywords identifies cookies likely to carry session or
+// authentication state, which is what the hardening checks below care about.
+var sensitiveCookieKeywords = []string{"session", "auth", "token", "sid", "jwt", "login"}
+
+// TestCookieSecurity audits the cookies captured in session.CookieDetails
+// for missing hardening attributes. Unlike the other Test* checks this
+// works off the browser-captured session rather than replaying HTTP
+// requests, so it can flag issues even when no HTTP replay is possible.
+func TestCookieSecurity(session *models.Session) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	for _, cookie := range session.CookieDetails {
+		if !isSensitiveCookie(cookie.Name) {
+			continue
+		}
+
+		if !cookie.HttpOnly {
+			vulns = append(vulns, models.Vulnerability{
+				Type:        "Cookie Security",
+				Severity:    "HIGH",
+				Title:       fmt.Sprintf("Session Cookie %q Missing HttpOnly", cookie.Name),
+				Description: fmt.Sprintf("Cookie %q appears to carry session/auth state but is not marked HttpOnly, so it can be read and exfiltrated via client-side script injection (XSS).", cookie.Name),
+				Proof:       fmt.Sprintf("Cookie %s: HttpOnly=%v Secure=%v SameSite=%q", cookie.Name, cookie.HttpOnly, cookie.Secure, cookie.SameSite),
+				Timestamp:   time.Now(),
+				CWE:         "CWE-1004",
+				CVSSScore:   6.5,
+				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:N/A:N",
+				Confidence:  "Medium",
+				Impact:      "An XSS vulnerability elsewhere on the site can be used to steal this cookie and hijack the payment session.",
+				Remediation: `Set HttpOnly on all session/authentication cookies:
+
+http.SetCookie(w, &http.Cookie{Name: "` + cookie.Name + `", Value: value, HttpOnly: true})`,
+				References: []string{
+					"https://cwe.mitre.org/data/definitions/1004.html",
+					"https://cheatsheetseries.owasp.org/cheatsheets/Session_Management_Cheat_Sheet.html",
+				},
+			})
+		}
+
+		if !cookie.Secure {
+			vulns = append(vulns, models.Vulnerability{
+				Type:        "Cookie Security",
+				Severity:    "HIGH",
+				Title:       fmt.Sprintf("Session Cookie %q Missing Secure Flag", cookie.Name),
+				Description: fmt.Sprintf("Cookie %q appears to carry session/auth state but is not marked Secure, so it may be sent over plaintext HTTP and intercepted.", cookie.Name),
+				Proof:       fmt.Sprintf("Cookie %s: HttpOnly=%v Secure=%v SameSite=%q", cookie.Name, cookie.HttpOnly, cookie.Secure, cookie.SameSite),
+				Timestamp:   time.Now(),
+				CWE:         "CWE-614",
+				CVSSScore:   6.5,
+				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+				Confidence:  "Medium",
+				Impact:      "A man-in-the-middle on an insecure network can read or replay the session cookie.",
+				Remediation: `Set Secure on all session/authentication cookies:
+
+http.SetCookie(w, &http.Cookie{Name: "` + cookie.Name + `", Value: value, Secure: true})`,
+				References: []string{
+					"https://cwe.mitre.org/data/definitions/614.html",
+				},
+			})
+		}
+
+		if cookie.SameSite == "None" && !cookie.Secure {
+			vulns = append(vulns, models.Vulnerability{
+				Type:        "Cookie Security",
+				Severity:    "MEDIUM",
+				Title:       fmt.Sprintf("Cookie %q Uses SameSite=None Without Secure", cookie.Name),
+				Description: fmt.Sprintf("Cookie %q is set with SameSite=None but lacks the Secure flag, which modern browsers reject and, where accepted, leaves the cookie exposed to cross-site requests without an encrypted transport.", cookie.Name),
+				Proof:       fmt.Sprintf("Cookie %s: SameSite=None Secure=%v", cookie.Name, cookie.Secure),
+				Timestamp:   time.Now(),
+				CWE:         "CWE-1275",
+				CVSSScore:   5.4,
+				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:N/I:L/A:N",
+				Confidence:  "Medium",
+				Remediation: "SameSite=None cookies must also set Secure; prefer SameSite=Lax or Strict for session cookies that don't need cross-site delivery.",
+				References: []string{
+					"https://cwe.mitre.org/data/definitions/1275.html",
+				},
+			})
+		}
+	}
+
+	return vulns
+}
+
+func isSensitiveCookie(name string) bool {
+	lower := strings.ToLower(name)
+	for _, kw := range sensitiveCookieKeywords {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
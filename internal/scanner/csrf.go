@@ -0,0 +1,197 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// csrfCookieNames lists the cookie names commonly used to carry a
+// double-submit CSRF token, checked in order of preference.
+var csrfCookieNames = []string{"grv_csrf", "csrf_token", "XSRF-TOKEN", "_csrf"}
+
+// TestCSRFProtection probes state-changing endpoints for the "double submit
+// cookie" CSRF pattern: a request with a valid session cookie should only
+// succeed if it also carries a header that echoes a CSRF token cookie.
+func TestCSRFProtection(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	// Only state-changing methods are susceptible to CSRF
+	if endpoint.Method != "POST" && endpoint.Method != "PUT" && endpoint.Method != "DELETE" {
+		return vulns
+	}
+
+	cookieName, cookieValue := findCSRFCookie(session)
+	if cookieName == "" {
+		// No double-submit cookie to test against
+		return vulns
+	}
+
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+
+	// (a) Baseline: valid cookies + valid header echoing the cookie value
+	baseline, err := sendCSRFVariant(client, endpoint, session, rl, cookieValue)
+	if err != nil || baseline == nil {
+		return vulns
+	}
+
+	// (b) Cookies present, header omitted entirely
+	omitted, err := sendCSRFVariant(client, endpoint, session, rl, "")
+	if err != nil {
+		omitted = nil
+	}
+
+	// (c) Cookies present, header set to a random/mismatched value
+	mismatched, err := sendCSRFVariant(client, endpoint, session, rl, randomToken())
+	if err != nil {
+		mismatched = nil
+	}
+
+	bypassed := sameResponseShape(baseline, omitted) || sameResponseShape(baseline, mismatched)
+	if !bypassed {
+		return vulns
+	}
+
+	missingSameSite := cookieMissingSameSite(session, cookieName)
+
+	description := fmt.Sprintf(
+		"Endpoint accepted a state-changing %s request with valid session cookies even though the CSRF header was missing or did not match the %q cookie.",
+		endpoint.Method, cookieName,
+	)
+	if missingSameSite {
+		description += fmt.Sprintf(" The %q cookie also has no SameSite=Lax/Strict attribute, removing the browser-side mitigation for cross-site requests.", cookieName)
+	}
+
+	vulns = append(vulns, models.Vulnerability{
+		Type:        "CSRF",
+		Severity:    "HIGH",
+		Title:       "Double-Submit Cookie CSRF Protection Bypassed",
+		Description: description,
+		Endpoint:    endpoint.URL,
+		Method:      endpoint.Method,
+		Proof:       fmt.Sprintf("Baseline status %d; omitted-header status %d; mismatched-header status %d (cookie: %s)", baseline.statusCode, responseStatus(omitted), responseStatus(mismatched), cookieName),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-352",
+		CVSSScore:   8.1,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:N/I:H/A:N",
+		Confidence:  "Medium",
+		Impact:      "An attacker can forge state-changing payment requests from a victim's authenticated browser session without knowing the CSRF token.",
+		Remediation: `Validate the double-submit token on every state-changing request, or switch to the synchronizer token pattern:
+
+// Go example:
+if r.Header.Get("X-CSRF-Token") != getCookie(r, "grv_csrf") {
+    http.Error(w, "invalid csrf token", http.StatusForbidden)
+    return
+}
+
+// Also set the CSRF/session cookie with SameSite=Lax or Strict:
+http.SetCookie(w, &http.Cookie{Name: "grv_csrf", Value: token, SameSite: http.SameSiteStrictMode})`,
+		References: []string{
+			"https://cheatsheetseries.owasp.org/cheatsheets/Cross-Site_Request_Forgery_Prevention_Cheat_Sheet.html",
+			"https://cwe.mitre.org/data/definitions/352.html",
+		},
+	})
+
+	return vulns
+}
+
+type csrfResponse struct {
+	statusCode int
+	bodyLen    int
+}
+
+func responseStatus(r *csrfResponse) int {
+	if r == nil {
+		return -1
+	}
+	return r.statusCode
+}
+
+// sameResponseShape reports whether two responses look like the same
+// outcome (status code and roughly the same body size), which indicates
+// the server did not actually reject the request.
+func sameResponseShape(a, b *csrfResponse) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.statusCode != b.statusCode {
+		return false
+	}
+	// 2xx/3xx responses of similar size are treated as "the same outcome";
+	// rejections (401/403) are never similar regardless of body size.
+	if a.statusCode >= 400 {
+		return false
+	}
+	return abs(a.bodyLen-b.bodyLen) <= 32
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// sendCSRFVariant issues a request with session cookies and, when
+// headerValue is non-empty, an X-CSRF-Token/grv_csrf header set to it.
+func sendCSRFVariant(client *http.Client, endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, headerValue string) (*csrfResponse, error) {
+	req, err := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBufferString(endpoint.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	addAuthHeaders(req, session)
+
+	if headerValue != "" {
+		req.Header.Set("X-CSRF-Token", headerValue)
+		req.Header.Set("grv_csrf", headerValue)
+	}
+
+	rl.Wait(endpoint.URL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	body, _ := utils.ReadResponseBody(resp)
+	return &csrfResponse{statusCode: resp.StatusCode, bodyLen: len(body)}, nil
+}
+
+// findCSRFCookie returns the first known CSRF cookie name/value present
+// in the session, if any.
+func findCSRFCookie(session *models.Session) (name, value string) {
+	for _, candidate := range csrfCookieNames {
+		if v, ok := session.Cookies[candidate]; ok && v != "" {
+			return candidate, v
+		}
+	}
+	return "", ""
+}
+
+// cookieMissingSameSite reports whether the named cookie's captured
+// metadata lacks a SameSite=Lax/Strict attribute.
+func cookieMissingSameSite(session *models.Session, name string) bool {
+	for _, c := range session.CookieDetails {
+		if c.Name == name {
+			return c.SameSite != "Lax" && c.SameSite != "Strict"
+		}
+	}
+	// No captured metadata; can't confirm, so don't call it out.
+	return false
+}
+
+func randomToken() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "deadbeefdeadbeefdeadbeefdeadbeef"
+	}
+	return hex.EncodeToString(buf)
+}
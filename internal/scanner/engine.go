@@ -1,35 +1,146 @@
 package scanner
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/discovery"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/reporter"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
 )
 
 // Engine orchestrates the scanning process
 type Engine struct {
-	Config    models.ScanConfig
-	Session   *models.Session
-	Browser   *browser.Browser
-	Endpoints []models.Endpoint
-	Vulns     []models.Vulnerability
-	Logger    *utils.Logger
-	mu        sync.Mutex
+	Config      models.ScanConfig
+	Session     *models.Session
+	Browser     *browser.Browser
+	Endpoints   []models.Endpoint
+	Vulns       []models.Vulnerability
+	CertChain   []models.CertificateInfo
+	Logger      *utils.Logger
+	RateLimiter *utils.RateLimiter
+	mu          sync.Mutex
+
+	events   chan reporter.Event
+	eventsWG sync.WaitGroup
+	stream   *reporter.StreamWriter
+	hub      *reporter.Hub
+}
+
+// newConfigLogger builds the Logger an Engine starts with, honoring
+// config.LogFormat alongside config.Verbose.
+func newConfigLogger(config models.ScanConfig) *utils.Logger {
+	if config.LogFormat == "json" {
+		level := utils.LevelInfo
+		if config.Verbose {
+			level = utils.LevelDebug
+		}
+		return utils.NewJSONLogger(level)
+	}
+	return utils.NewLogger(config.Verbose)
 }
 
 // NewEngine creates a new scanner engine
 func NewEngine(config models.ScanConfig, session *models.Session, br *browser.Browser) *Engine {
-	return &Engine{
+	e := &Engine{
 		Config:  config,
 		Session: session,
 		Browser: br,
-		Logger:  utils.NewLogger(config.Verbose),
+		Logger:  newConfigLogger(config).With("target", config.TargetURL),
 		Vulns:   make([]models.Vulnerability, 0),
+		events:  make(chan reporter.Event, 64),
+	}
+
+	e.eventsWG.Add(1)
+	go e.consumeEvents()
+
+	return e
+}
+
+// SetStreamWriter makes the engine additionally publish every endpoint and
+// vulnerability event to w as it happens (NDJSON), instead of only at the
+// end via GetResults. Existing JSON/HTML reports are unaffected since they
+// still read from Endpoints/Vulns once the scan completes.
+func (e *Engine) SetStreamWriter(w *reporter.StreamWriter) {
+	e.stream = w
+}
+
+// SetHub makes the engine additionally publish every endpoint/vulnerability
+// event to h's per-topic SSE subscribers as it happens, so a dashboard
+// served from h.Subscribe can watch the scan live. The final summary event
+// Close writes goes to the stream writer only, not to h.
+func (e *Engine) SetHub(h *reporter.Hub) {
+	e.hub = h
+}
+
+// consumeEvents is the event bus's sole subscriber. Vulnerability events are
+// the in-memory aggregator: they're how Vulns gets populated at all.
+// Endpoint events are informational only - Endpoints is still built by
+// StartDiscovery's own dedup pass, so they're just forwarded here for live
+// visibility. Either way, the stream writer (if any) sees the same feed the
+// aggregator does.
+func (e *Engine) consumeEvents() {
+	defer e.eventsWG.Done()
+	for ev := range e.events {
+		if ev.Kind == reporter.EventVulnerability {
+			e.mu.Lock()
+			e.Vulns = append(e.Vulns, *ev.Vulnerability)
+			e.mu.Unlock()
+		}
+
+		if e.stream != nil {
+			if err := e.stream.Write(ev); err != nil {
+				e.Logger.Error("Failed to write stream event: %v", err)
+			}
+		}
+
+		if e.hub != nil {
+			e.hub.Publish(reporter.HubTopic(ev.Kind), ev)
+		}
+	}
+}
+
+// Close stops the event bus. Call it once discovery, scanning, and any
+// WebSocket tests are done, before GetResults - closing the channel drains
+// every event already sent (so Vulns is guaranteed complete), and a final
+// summary event is written to the stream writer, if any.
+func (e *Engine) Close() {
+	close(e.events)
+	e.eventsWG.Wait()
+
+	if e.stream != nil {
+		result := e.GetResults()
+		if err := e.stream.Write(reporter.Event{Kind: reporter.EventSummary, Summary: &result}); err != nil {
+			e.Logger.Error("Failed to write summary stream event: %v", err)
+		}
+	}
+}
+
+// passiveSources builds the list of enabled discovery.PassiveSource
+// implementations for this scan's config. CrtSh, when enabled, is included
+// like any other source - Aggregator special-cases it internally to chain
+// its subdomains into the others' queries.
+func (e *Engine) passiveSources() []discovery.PassiveSource {
+	var sources []discovery.PassiveSource
+	if e.Config.EnableWayback {
+		sources = append(sources, discovery.NewWaybackMachine())
+	}
+	if e.Config.EnableCommonCrawl {
+		sources = append(sources, discovery.NewCommonCrawl())
 	}
+	if e.Config.EnableURLScan {
+		sources = append(sources, discovery.NewURLScan(e.Config.URLScanAPIKey))
+	}
+	if e.Config.EnableOTX {
+		sources = append(sources, discovery.NewOTX(e.Config.OTXAPIKey))
+	}
+	if e.Config.EnableCrtSh {
+		sources = append(sources, discovery.NewCrtSh())
+	}
+	return sources
 }
 
 // StartDiscovery runs the discovery phase
@@ -37,52 +148,87 @@ func (e *Engine) StartDiscovery() error {
 	// 0. WAF Detection
 	e.Logger.Info("Checking for WAF...")
 	waf := DetectWAF(e.Config.TargetURL)
-	if waf != "None Detected" {
+	wafDetected := waf != "None Detected"
+	if wafDetected {
 		e.Logger.Warn("WAF Detected: %s", waf)
 		e.Logger.Warn("Scanning might be blocked. Reducing speed...")
-		// Reduce concurrency or add delays if needed
 	} else {
 		e.Logger.Success("No WAF detected.")
 	}
 
+	e.RateLimiter = e.buildRateLimiter(waf)
+
+	e.Logger.Info("Checking server certificate health...")
+	certChain, certVulns := CheckCertificateHealth(e.Config.TargetURL, e.Session)
+	e.CertChain = certChain
+	e.addVulnerabilities(certVulns)
+
 	e.Logger.Section("Phase 1: Discovery")
-	
+
 	var allEndpoints []models.Endpoint
 	var mu sync.Mutex
 	var wg sync.WaitGroup
-	
-	// Rate Limiter for discovery (conservative)
-	_ = utils.NewRateLimiter(10) // 10 req/sec default (unused for now)
-	
+
 	// 1. Crawler (requires browser)
 	if e.Config.EnableCrawl && e.Browser != nil {
+		concurrency := e.Config.ConcurrentReqs
+		if concurrency < 1 {
+			concurrency = 5
+		}
+
+		pool, err := browser.NewContextPool(e.Browser, concurrency)
+		if err != nil {
+			e.Logger.Error("Failed to create browser context pool: %v", err)
+		} else {
+			// Throttle the pool itself rather than reducing its size, so
+			// isolation between workers is unaffected.
+			pool.SetRateLimiter(e.RateLimiter)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer pool.Close()
+				crawler := discovery.NewCrawler(e.Config.TargetURL, e.Config.MaxDepth, concurrency, pool)
+				crawler.SetEventStream(e.events)
+				eps, err := crawler.Start()
+				if err != nil {
+					e.Logger.Error("Crawler failed: %v", err)
+					return
+				}
+				mu.Lock()
+				allEndpoints = append(allEndpoints, eps...)
+				mu.Unlock()
+			}()
+		}
+	} else if e.Config.EnableCrawl && e.Browser == nil {
+		e.Logger.Warn("Crawler skipped (no browser available - using cached session)")
+	}
+
+	// 2. Passive sources (Wayback, CommonCrawl, URLScan, OTX, crt.sh)
+	if sources := e.passiveSources(); len(sources) > 0 {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			// Pass limiter if crawler supports it, or just let it run (crawler usually slow anyway)
-			crawler := discovery.NewCrawler(e.Config.TargetURL, e.Config.MaxDepth, e.Browser)
-			eps, err := crawler.Start()
-			if err != nil {
-				e.Logger.Error("Crawler failed: %v", err)
-				return
-			}
+			agg := discovery.NewAggregator(sources, e.Config.PassiveSourceRPS, e.Config.PassiveCacheDir)
+			eps := agg.Search(context.Background(), e.Config.Domain)
 			mu.Lock()
 			allEndpoints = append(allEndpoints, eps...)
 			mu.Unlock()
 		}()
-	} else if e.Config.EnableCrawl && e.Browser == nil {
-		e.Logger.Warn("Crawler skipped (no browser available - using cached session)")
 	}
-	
-	// 2. Wayback Machine
-	if e.Config.EnableWayback {
+
+	// 3. Common Paths
+	if e.Config.EnableCommonPaths {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			wb := discovery.NewWaybackMachine()
-			eps, err := wb.Search(e.Config.Domain)
+			bf := discovery.NewPathBruteForcer(e.Config.TargetURL, e.Config.WordlistPath)
+			bf.SetRateLimiter(e.RateLimiter)
+			bf.SetSession(e.Session)
+			bf.SetWordlistSources(e.Config.WordlistSources)
+			eps, err := bf.Start()
 			if err != nil {
-				e.Logger.Error("Wayback search failed: %v", err)
+				e.Logger.Error("Path discovery failed: %v", err)
 				return
 			}
 			mu.Lock()
@@ -90,17 +236,17 @@ func (e *Engine) StartDiscovery() error {
 			mu.Unlock()
 		}()
 	}
-	
-	// 3. Common Paths
-	if e.Config.EnableCommonPaths {
+
+	// 3b. SignalR hubs (.NET payment dashboards commonly expose one)
+	if e.Config.EnableSignalR {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			// TODO: Make wordlist path configurable
-			bf := discovery.NewPathBruteForcer(e.Config.TargetURL, e.Config.WordlistPath)
-			eps, err := bf.Start()
+			sr := discovery.NewSignalRScanner(e.Config.TargetURL)
+			sr.SetRateLimiter(e.RateLimiter)
+			eps, err := sr.Scan()
 			if err != nil {
-				e.Logger.Error("Path discovery failed: %v", err)
+				e.Logger.Error("SignalR discovery failed: %v", err)
 				return
 			}
 			mu.Lock()
@@ -108,56 +254,114 @@ func (e *Engine) StartDiscovery() error {
 			mu.Unlock()
 		}()
 	}
-	
-	// 4. JS Analysis
+
+	// 4. HAR import (bootstraps flows that need manual navigation, e.g. 3DS/OTP)
+	if e.Config.HARImportPath != "" {
+		eps, err := discovery.ImportHAR(e.Config.HARImportPath)
+		if err != nil {
+			e.Logger.Error("HAR import failed: %v", err)
+		} else {
+			e.Logger.Success("Imported %d endpoints from HAR file", len(eps))
+			mu.Lock()
+			allEndpoints = append(allEndpoints, eps...)
+			mu.Unlock()
+		}
+	}
+
+	// 5. OpenAPI import (guarantees coverage of documented routes)
+	if e.Config.OpenAPIImportPath != "" {
+		eps, err := discovery.ImportOpenAPI(e.Config.OpenAPIImportPath)
+		if err != nil {
+			e.Logger.Error("OpenAPI import failed: %v", err)
+		} else {
+			e.Logger.Success("Imported %d endpoints from OpenAPI spec", len(eps))
+			mu.Lock()
+			allEndpoints = append(allEndpoints, eps...)
+			mu.Unlock()
+		}
+	}
+
+	// 6. JS Analysis
 	if e.Config.EnableJSAnalysis {
 		// JS analysis needs browser, so run it sequentially or with care
 		// For now, let's skip parallel execution for this one or assume browser is thread-safe enough
 		// (Browser instance is not thread safe for navigation, so we skip for now or run after)
 	}
-	
+
 	wg.Wait()
-	
+
 	// Deduplicate
 	e.Endpoints = discovery.DeduplicateEndpoints(allEndpoints)
 	e.Logger.Success("Discovery complete. Found %d unique endpoints.", len(e.Endpoints))
-	
+
 	return nil
 }
 
 // StartScanning runs the vulnerability scanning phase
 func (e *Engine) StartScanning() error {
 	e.Logger.Section("Phase 2: Vulnerability Scanning")
-	
+
 	// Filter for relevant endpoints
 	targetEndpoints := e.filterTargetEndpoints()
 	e.Logger.Info("Targeting %d payment-related endpoints", len(targetEndpoints))
-	
+
 	for _, ep := range targetEndpoints {
 		e.Logger.Info("Scanning %s (%s)", ep.URL, ep.Type)
-		
+
 		// Race Condition
 		if e.Config.EnableRaceCondition {
-			vulns := TestRaceCondition(ep, e.Session)
+			vulns := TestRaceCondition(ep, e.Session, e.RateLimiter, e.Config.RaceMode)
 			e.addVulnerabilities(vulns)
 		}
-		
+
 		// Price Manipulation
 		if e.Config.EnablePriceManipulation {
-			vulns := TestPriceManipulation(ep, e.Session)
+			vulns := TestPriceManipulation(ep, e.Session, e.RateLimiter, &e.Config)
 			e.addVulnerabilities(vulns)
 		}
-		
+
 		// IDOR
 		if e.Config.EnableIDOR {
-			vulns := TestIDOR(ep, e.Session)
+			vulns := TestIDOR(ep, e.Session, e.RateLimiter)
 			e.addVulnerabilities(vulns)
 		}
 	}
-	
+
 	return nil
 }
 
+// buildRateLimiter picks the scan's starting/floor rate based on CLI flags
+// and, when WAFAdaptive is set, the WAF fingerprinted during discovery.
+// Detecting no WAF ("None Detected") keeps the CLI floor; detecting one
+// raises the floor to whatever RateFloorForWAF recommends. Either way the
+// limiter adapts between that floor and the max rate.
+func (e *Engine) buildRateLimiter(waf string) *utils.RateLimiter {
+	rps := e.Config.RateRPS
+	if rps <= 0 {
+		rps = 10
+	}
+	min := e.Config.RateMin
+	if min <= 0 {
+		min = 1
+	}
+	max := e.Config.RateMax
+	if max <= 0 {
+		max = rps
+	}
+
+	if !e.Config.WAFAdaptive {
+		return utils.NewAdaptiveRateLimiter(rps, min, max)
+	}
+
+	if floor := RateFloorForWAF(waf); waf != "None Detected" && floor > min {
+		min = floor
+		if rps < min {
+			rps = min
+		}
+	}
+	return utils.NewAdaptiveRateLimiter(rps, min, max)
+}
+
 func (e *Engine) filterTargetEndpoints() []models.Endpoint {
 	var targets []models.Endpoint
 	for _, ep := range e.Endpoints {
@@ -169,9 +373,9 @@ func (e *Engine) filterTargetEndpoints() []models.Endpoint {
 }
 
 func (e *Engine) addVulnerabilities(vulns []models.Vulnerability) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	e.Vulns = append(e.Vulns, vulns...)
+	for i := range vulns {
+		e.events <- reporter.Event{Kind: reporter.EventVulnerability, Vulnerability: &vulns[i]}
+	}
 }
 
 // AddVulnerabilities adds vulnerabilities (public method for external scanners)
@@ -182,11 +386,12 @@ func (e *Engine) AddVulnerabilities(vulns []models.Vulnerability) {
 // GetResults returns the scan results
 func (e *Engine) GetResults() models.ScanResult {
 	return models.ScanResult{
-		Target:          e.Config.TargetURL,
-		StartTime:       time.Now(), // Should be set at start
-		EndTime:         time.Now(),
-		Endpoints:       e.Endpoints,
-		Vulnerabilities: e.Vulns,
-		Config:          e.Config,
+		Target:           e.Config.TargetURL,
+		StartTime:        time.Now(), // Should be set at start
+		EndTime:          time.Now(),
+		Endpoints:        e.Endpoints,
+		Vulnerabilities:  e.Vulns,
+		CertificateChain: e.CertChain,
+		Config:           e.Config,
 	}
 }
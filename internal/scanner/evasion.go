@@ -0,0 +1,370 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EvasionMutator is one reversible request/payload transformation a
+// specific WAF vendor is known not to fully normalize before running its
+// detection rules. Vendors lists the DetectWAF names it's historically
+// effective against; ApplyEvasion only considers a mutator whose Vendors
+// contains the detected vendor.
+type EvasionMutator struct {
+	Name    string
+	Vendors []string
+	Apply   func(req *http.Request, payload string) string
+}
+
+// evasionMutators is the vendor-specific catalog ApplyEvasion draws its
+// pipeline from, in addition to the always-available CaseVariation/
+// URLEncoding/VerbTampering toggles.
+var evasionMutators = []EvasionMutator{
+	{Name: "param-pollution", Vendors: []string{"AWS WAF", "F5 BIG-IP ASM"}, Apply: mutateParamPollution},
+	{Name: "chunked-smuggling", Vendors: []string{"Akamai", "Imperva"}, Apply: mutateChunkedSmuggling},
+	{Name: "overlong-utf8", Vendors: []string{"ModSecurity", "Sucuri"}, Apply: mutateOverlongUTF8},
+	{Name: "sql-comment-injection", Vendors: []string{"Cloudflare", "ModSecurity"}, Apply: mutateSQLCommentInjection},
+	{Name: "json-key-reorder", Vendors: []string{"AWS WAF", "Azure Front Door"}, Apply: mutateJSONKeyReorder},
+	{Name: "mixed-content-type", Vendors: []string{"Wallarm", "Barracuda"}, Apply: mutateMixedContentType},
+	{Name: "http2-pseudo-header-abuse", Vendors: []string{"Cloudflare", "Fastly"}, Apply: mutateHTTP2PseudoAbuse},
+}
+
+// evasionChainStats tracks how often one ordered mutator chain was tried
+// and how many of those attempts got a blocked (429/403) response, so
+// BypassReport can surface the chains that actually got through.
+type evasionChainStats struct {
+	chain   []string
+	total   int
+	blocked int
+}
+
+// EvasionContext applies evasion techniques when a WAF is detected. It
+// picks a randomized pipeline of mutators per request - the toggles below
+// plus whichever catalog entries are known to work against WAFVendor -
+// and, once fed response outcomes via RecordOutcome, can report which
+// chains actually got past the WAF.
+type EvasionContext struct {
+	WAFVendor     string // vendor name from DetectWAF, "" if none/unknown
+	CaseVariation bool
+	URLEncoding   bool
+	VerbTampering bool
+	SlowDown      bool
+	RateLimited   int // Count of 429/403 responses
+
+	rng     *rand.Rand
+	history map[string]*evasionChainStats
+}
+
+// NewEvasionContext creates an EvasionContext with the generic toggles
+// enabled and wafVendor (typically DetectWAF's result) recorded so
+// ApplyEvasion can also draw on vendor-specific mutators.
+func NewEvasionContext(wafVendor string) *EvasionContext {
+	return &EvasionContext{
+		WAFVendor:     wafVendor,
+		CaseVariation: true,
+		URLEncoding:   true,
+		VerbTampering: true,
+	}
+}
+
+// ApplyEvasion selects a randomized pipeline of mutators - the enabled
+// generic toggles plus every catalog mutator whose Vendors includes
+// ec.WAFVendor - applies them to req/payload in turn, and returns the
+// mutated payload along with the ordered chain of mutator names used.
+// Pass that chain to RecordOutcome once the response comes back so
+// BypassReport can track which combinations work.
+func (ec *EvasionContext) ApplyEvasion(req *http.Request, payload string) (string, []string) {
+	pipeline := ec.selectPipeline()
+
+	for _, m := range pipeline {
+		payload = m.Apply(req, payload)
+	}
+
+	names := make([]string, len(pipeline))
+	for i, m := range pipeline {
+		names[i] = m.Name
+	}
+	return payload, names
+}
+
+// selectPipeline builds and shuffles the list of mutators eligible for
+// this request given ec's toggles and detected vendor.
+func (ec *EvasionContext) selectPipeline() []EvasionMutator {
+	if ec.rng == nil {
+		ec.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	var eligible []EvasionMutator
+	if ec.CaseVariation {
+		eligible = append(eligible, EvasionMutator{Name: "case-variation", Apply: mutateCaseVariation})
+	}
+	if ec.URLEncoding {
+		eligible = append(eligible, EvasionMutator{Name: "double-url-encoding", Apply: mutateDoubleEncoding})
+	}
+	if ec.VerbTampering {
+		eligible = append(eligible, EvasionMutator{Name: "verb-tampering", Apply: mutateVerbTampering})
+	}
+	for _, m := range evasionMutators {
+		if containsVendor(m.Vendors, ec.WAFVendor) {
+			eligible = append(eligible, m)
+		}
+	}
+
+	ec.rng.Shuffle(len(eligible), func(i, j int) { eligible[i], eligible[j] = eligible[j], eligible[i] })
+	return eligible
+}
+
+func containsVendor(vendors []string, vendor string) bool {
+	if vendor == "" {
+		return false
+	}
+	for _, v := range vendors {
+		if v == vendor {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordOutcome records whether the mutator chain ApplyEvasion returned
+// (in the order it ran) was blocked, mirroring CheckRateLimiting's
+// 429/403 definition, building up the per-run data BypassReport
+// summarizes.
+func (ec *EvasionContext) RecordOutcome(chain []string, statusCode int) {
+	if len(chain) == 0 {
+		return
+	}
+	if ec.history == nil {
+		ec.history = make(map[string]*evasionChainStats)
+	}
+
+	key := strings.Join(chain, "+")
+	stats, ok := ec.history[key]
+	if !ok {
+		stats = &evasionChainStats{chain: append([]string(nil), chain...)}
+		ec.history[key] = stats
+	}
+	stats.total++
+	if statusCode == 429 || statusCode == 403 {
+		stats.blocked++
+	}
+}
+
+// EvasionBypassChain is one ordered mutator combination from the per-run
+// report BypassReport returns.
+type EvasionBypassChain struct {
+	Mutators   []string
+	Attempts   int
+	BypassRate float64 // fraction of Attempts that were not blocked
+}
+
+// BypassReport summarizes every mutator chain RecordOutcome has seen,
+// sorted by BypassRate descending, so the chains most reliably getting
+// past the detected WAF sort first.
+func (ec *EvasionContext) BypassReport() []EvasionBypassChain {
+	report := make([]EvasionBypassChain, 0, len(ec.history))
+	for _, stats := range ec.history {
+		bypassed := stats.total - stats.blocked
+		report = append(report, EvasionBypassChain{
+			Mutators:   stats.chain,
+			Attempts:   stats.total,
+			BypassRate: float64(bypassed) / float64(stats.total),
+		})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].BypassRate > report[j].BypassRate
+	})
+	return report
+}
+
+// CheckRateLimiting detects if response indicates rate limiting
+func (ec *EvasionContext) CheckRateLimiting(statusCode int) bool {
+	if statusCode == 429 || statusCode == 403 {
+		ec.RateLimited++
+		ec.SlowDown = true
+		return true
+	}
+	return false
+}
+
+// GetDelay returns adaptive delay based on rate limiting
+func (ec *EvasionContext) GetDelay() time.Duration {
+	if ec.RateLimited == 0 {
+		return 0
+	}
+
+	// Exponential backoff: 1s, 2s, 4s, 8s (max)
+	delay := time.Duration(1<<uint(ec.RateLimited-1)) * time.Second
+	if delay > 8*time.Second {
+		delay = 8 * time.Second
+	}
+	return delay
+}
+
+func mutateCaseVariation(req *http.Request, payload string) string {
+	return applyCaseVariation(payload)
+}
+
+func mutateDoubleEncoding(req *http.Request, payload string) string {
+	return applyDoubleEncoding(payload)
+}
+
+func mutateVerbTampering(req *http.Request, payload string) string {
+	if req.Method == "POST" {
+		req.Method = "PUT" // Try alternative verb
+	}
+	return payload
+}
+
+// mutateParamPollution appends a second, decoy value for whatever query
+// parameter the request already carries (whichever one map iteration
+// yields first, so an arbitrary one when there are several, or "payload"
+// when there isn't one) - backends that use the
+// last occurrence of a repeated parameter while the WAF inspects only the
+// first (or vice versa) can be driven to disagree about which value is
+// "the" request.
+func mutateParamPollution(req *http.Request, payload string) string {
+	q := req.URL.Query()
+	name := "payload"
+	for k := range q {
+		name = k
+		break
+	}
+	q.Add(name, "benign")
+	req.URL.RawQuery = q.Encode()
+	return payload
+}
+
+// mutateChunkedSmuggling forces the request to be sent with
+// Transfer-Encoding: chunked instead of a Content-Length header, which
+// some reverse-proxy/WAF pairs parse inconsistently (TE.CL/CL.TE request
+// smuggling).
+func mutateChunkedSmuggling(req *http.Request, payload string) string {
+	req.TransferEncoding = []string{"chunked"}
+	req.ContentLength = -1
+	return payload
+}
+
+// mutateOverlongUTF8 re-encodes every ASCII byte of payload as an invalid
+// overlong two-byte UTF-8 sequence. Many regex-based WAF rules only match
+// the canonical single-byte ASCII form; an application layer with a
+// lenient decoder still recovers the original character.
+func mutateOverlongUTF8(req *http.Request, payload string) string {
+	var b strings.Builder
+	for i := 0; i < len(payload); i++ {
+		c := payload[i]
+		if c < 0x80 {
+			b.WriteByte(0xC0 | (c >> 6))
+			b.WriteByte(0x80 | (c & 0x3F))
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// sqlCommentInjectionKeywords matches the SQL keywords
+// mutateSQLCommentInjection splits with an inline comment.
+var sqlCommentInjectionKeywords = regexp.MustCompile(`(?i)\b(SELECT|UNION|INSERT|UPDATE|DELETE|FROM|WHERE|OR|AND)\b`)
+
+// mutateSQLCommentInjection splits every SQL keyword in payload with an
+// inline comment (SELECT -> SEL/*x*/ECT), which most SQL engines still
+// parse as the same token but defeats a WAF's keyword-boundary regex.
+func mutateSQLCommentInjection(req *http.Request, payload string) string {
+	return sqlCommentInjectionKeywords.ReplaceAllStringFunc(payload, func(kw string) string {
+		mid := len(kw) / 2
+		if mid == 0 {
+			return kw
+		}
+		return kw[:mid] + "/*x*/" + kw[mid:]
+	})
+}
+
+// mutateJSONKeyReorder shuffles the key order of payload's top-level JSON
+// object, leaving it semantically identical - a WAF rule anchored to a
+// fixed key sequence won't match the reordered body.
+func mutateJSONKeyReorder(req *http.Request, payload string) string {
+	keys, values, err := jsonTopLevelKeyOrder(payload)
+	if err != nil || len(keys) < 2 {
+		return payload
+	}
+
+	order := rand.Perm(len(keys))
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, idx := range order {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		keyJSON, _ := json.Marshal(keys[idx])
+		b.Write(keyJSON)
+		b.WriteByte(':')
+		b.Write(values[idx])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// jsonTopLevelKeyOrder decodes payload's top-level JSON object keys, in
+// their original order, and each key's still-encoded raw value, so
+// mutateJSONKeyReorder can rebuild the object with a different key order
+// without needing to understand nested value types.
+func jsonTopLevelKeyOrder(payload string) (keys []string, values []json.RawMessage, err error) {
+	dec := json.NewDecoder(strings.NewReader(payload))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, nil, fmt.Errorf("payload is not a JSON object")
+	}
+
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("non-string JSON key")
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, key)
+		values = append(values, raw)
+	}
+
+	return keys, values, nil
+}
+
+// mutateMixedContentType sets Content-Type: application/x-www-form-urlencoded
+// on a request whose body is actually JSON - a WAF that picks its body
+// parser from the declared Content-Type fails to parse (and so fails to
+// inspect) a JSON payload advertised as form-encoded, while a backend that
+// sniffs the real body format still accepts it.
+func mutateMixedContentType(req *http.Request, payload string) string {
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return payload
+}
+
+// mutateHTTP2PseudoAbuse is a best-effort stand-in for genuine HTTP/2
+// pseudo-header abuse (smuggling a second :path/:authority past a
+// front-end that trusts a TLS-terminating proxy's view of them): Go's
+// net/http derives :path/:authority from req.URL/req.Host itself and
+// doesn't expose a way to put conflicting values on the wire, so this
+// instead sets the override in a regular header, which some
+// misconfigured front-ends forward into routing decisions unchanged.
+func mutateHTTP2PseudoAbuse(req *http.Request, payload string) string {
+	req.Header.Set("X-HTTP2-Pseudo-Path-Override", req.URL.Path)
+	return payload
+}
@@ -0,0 +1,123 @@
+// Package fingerprints loads the DBMS error-signature database used for
+// error-based SQL injection detection, keeping the patterns themselves in
+// an editable data file rather than hardcoded string checks.
+package fingerprints
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultSQLFingerprintsPath is where LoadSQL looks for the fingerprint
+// database by default, relative to the process's working directory.
+const DefaultSQLFingerprintsPath = "scanner/fingerprints/sql.yaml"
+
+// SQLFingerprint pairs a compiled DBMS error regex with the engine it
+// identifies, so a single match tells the caller both that a SQL error
+// leaked and which database produced it.
+type SQLFingerprint struct {
+	Engine  string
+	Pattern string
+	Regex   *regexp.Regexp
+}
+
+// MatchSQLError scans body against fps and returns the engine and pattern
+// of the first fingerprint that matches.
+func MatchSQLError(body string, fps []SQLFingerprint) (engine, pattern string, ok bool) {
+	for _, fp := range fps {
+		if fp.Regex.MatchString(body) {
+			return fp.Engine, fp.Pattern, true
+		}
+	}
+	return "", "", false
+}
+
+// LoadSQL reads the DBMS error-fingerprint database from path, falling
+// back to DefaultSQLFingerprints when the file is missing or fails to
+// parse, so detection keeps working without a checkout of
+// scanner/fingerprints/sql.yaml next to the binary.
+func LoadSQL(path string) []SQLFingerprint {
+	fps, err := loadSQLFile(path)
+	if err != nil || len(fps) == 0 {
+		return DefaultSQLFingerprints()
+	}
+	return fps
+}
+
+// loadSQLFile parses the small YAML subset sql.yaml is written in: a
+// top-level "engines" list of "- name: ...\n  patterns:\n    - ...\n"
+// blocks. It's hand-rolled rather than pulling in a YAML library for this
+// one fixed shape.
+func loadSQLFile(path string) ([]SQLFingerprint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var fps []SQLFingerprint
+	var engine string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "- name:"):
+			engine = unquoteYAML(strings.TrimPrefix(trimmed, "- name:"))
+		case strings.HasPrefix(trimmed, "- ") && engine != "":
+			pattern := unquoteYAML(strings.TrimPrefix(trimmed, "- "))
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				continue
+			}
+			fps = append(fps, SQLFingerprint{Engine: engine, Pattern: pattern, Regex: re})
+		}
+	}
+
+	return fps, scanner.Err()
+}
+
+// unquoteYAML strips a surrounding pair of double quotes and collapses
+// YAML's "\\" backslash escape, e.g. `"ORA-\\d{5}"` -> `ORA-\d{5}`.
+func unquoteYAML(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = strings.ReplaceAll(s[1:len(s)-1], `\\`, `\`)
+	}
+	return s
+}
+
+// DefaultSQLFingerprints returns the built-in error-fingerprint database,
+// a subset of the patterns in scanner/fingerprints/sql.yaml covering the
+// same engines, used when that file can't be loaded.
+func DefaultSQLFingerprints() []SQLFingerprint {
+	raw := []struct{ engine, pattern string }{
+		{"MySQL", `You have an error in your SQL syntax.*MySQL`},
+		{"MySQL", `Warning.*mysql_.*`},
+		{"MySQL", `MySQLSyntaxErrorException`},
+		{"PostgreSQL", `PG::SyntaxError`},
+		{"PostgreSQL", `PostgreSQL.*ERROR`},
+		{"PostgreSQL", `org\.postgresql\.util\.PSQLException`},
+		{"MSSQL", `Unclosed quotation mark`},
+		{"MSSQL", `Microsoft SQL Server`},
+		{"MSSQL", `System\.Data\.SqlClient`},
+		{"Oracle", `ORA-\d{5}`},
+		{"Oracle", `quoted string not properly terminated`},
+		{"SQLite", `SQLite\.Exception`},
+		{"SQLite", `sqlite3\.OperationalError`},
+		{"DB2", `SQLSTATE\[\d+\]`},
+		{"DB2", `DB2 SQL error`},
+	}
+
+	fps := make([]SQLFingerprint, 0, len(raw))
+	for _, r := range raw {
+		fps = append(fps, SQLFingerprint{Engine: r.engine, Pattern: r.pattern, Regex: regexp.MustCompile(r.pattern)})
+	}
+	return fps
+}
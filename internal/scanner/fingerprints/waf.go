@@ -0,0 +1,278 @@
+package fingerprints
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultWAFFingerprintsPath is where LoadWAF looks for the WAF/CDN
+// fingerprint database by default, relative to the process's working
+// directory.
+const DefaultWAFFingerprintsPath = "scanner/fingerprints/waf.yaml"
+
+// WAFHeaderRule matches a single response header against a compiled
+// regex - most WAF signatures live in one or two headers (Server, a
+// vendor-specific ray/request-id header, a cookie).
+type WAFHeaderRule struct {
+	Header  string
+	Pattern string
+	Regex   *regexp.Regexp
+}
+
+// WAFFingerprintRule is one vendor's signature: any of its header, cookie,
+// or body rules matching (or the response landing on one of its trigger
+// status codes) is enough to identify it, similar in spirit to wafw00f's
+// signature catalog.
+type WAFFingerprintRule struct {
+	Name        string
+	Vendor      string
+	Confidence  int
+	StatusCodes []int
+	HeaderRules []WAFHeaderRule
+	CookieRules []*regexp.Regexp
+	BodyRules   []*regexp.Regexp
+}
+
+// LoadWAF reads the WAF fingerprint database from path, falling back to
+// DefaultWAFFingerprints when the file is missing or fails to parse, so
+// detection keeps working without a checkout of
+// scanner/fingerprints/waf.yaml next to the binary. Safe to call again
+// later (e.g. on a SIGHUP) to pick up edits without restarting a scan.
+func LoadWAF(path string) []WAFFingerprintRule {
+	rules, err := loadWAFFile(path)
+	if err != nil || len(rules) == 0 {
+		return DefaultWAFFingerprints()
+	}
+	return rules
+}
+
+// loadWAFFile parses the small YAML subset waf.yaml is written in: a flat
+// "- name: ..." list where each entry carries a vendor, a confidence
+// score, an optional status_codes list, and optional header_patterns/
+// cookie_patterns/body_patterns sub-lists (header_patterns items are
+// "header-name: pattern", the other two are bare patterns). Hand-rolled
+// rather than pulling in a YAML library for this one fixed shape (same
+// approach as sql.go's loadSQLFile).
+func loadWAFFile(path string) ([]WAFFingerprintRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []WAFFingerprintRule
+	section := ""
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "- name:"):
+			rules = append(rules, WAFFingerprintRule{Name: unquoteYAML(strings.TrimPrefix(trimmed, "- name:"))})
+			section = ""
+		case len(rules) == 0:
+			continue
+		case strings.HasPrefix(trimmed, "vendor:"):
+			rules[len(rules)-1].Vendor = unquoteYAML(strings.TrimPrefix(trimmed, "vendor:"))
+		case strings.HasPrefix(trimmed, "confidence:"):
+			rules[len(rules)-1].Confidence = parseWAFInt(strings.TrimPrefix(trimmed, "confidence:"))
+		case strings.HasPrefix(trimmed, "status_codes:"):
+			rules[len(rules)-1].StatusCodes = parseWAFIntList(strings.TrimPrefix(trimmed, "status_codes:"))
+		case strings.HasPrefix(trimmed, "header_patterns:"):
+			section = "header"
+		case strings.HasPrefix(trimmed, "cookie_patterns:"):
+			section = "cookie"
+		case strings.HasPrefix(trimmed, "body_patterns:"):
+			section = "body"
+		case strings.HasPrefix(trimmed, "- "):
+			appendWAFRuleItem(&rules[len(rules)-1], section, strings.TrimPrefix(trimmed, "- "))
+		}
+	}
+
+	return rules, scanner.Err()
+}
+
+// appendWAFRuleItem parses one "- ..." list item into rule according to
+// which patterns section it falls under, skipping it silently if it
+// doesn't compile (a malformed entry shouldn't take down the whole
+// fingerprint database).
+func appendWAFRuleItem(rule *WAFFingerprintRule, section, item string) {
+	switch section {
+	case "header":
+		name, pattern, ok := splitWAFHeaderRule(item)
+		if !ok {
+			return
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return
+		}
+		rule.HeaderRules = append(rule.HeaderRules, WAFHeaderRule{Header: strings.ToLower(name), Pattern: pattern, Regex: re})
+	case "cookie":
+		if re, err := regexp.Compile(unquoteYAML(item)); err == nil {
+			rule.CookieRules = append(rule.CookieRules, re)
+		}
+	case "body":
+		if re, err := regexp.Compile(unquoteYAML(item)); err == nil {
+			rule.BodyRules = append(rule.BodyRules, re)
+		}
+	}
+}
+
+// splitWAFHeaderRule splits a "header-name: pattern" list item into its
+// header name and (unquoted) pattern.
+func splitWAFHeaderRule(item string) (header, pattern string, ok bool) {
+	idx := strings.Index(item, ": ")
+	if idx < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(item[:idx]), unquoteYAML(item[idx+2:]), true
+}
+
+func parseWAFInt(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+func parseWAFIntList(s string) []int {
+	s = strings.Trim(strings.TrimSpace(s), "[]")
+	var codes []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(part); err == nil {
+			codes = append(codes, n)
+		}
+	}
+	return codes
+}
+
+// DefaultWAFFingerprints returns the built-in WAF/CDN fingerprint
+// database, mirroring scanner/fingerprints/waf.yaml, used when that file
+// can't be loaded.
+func DefaultWAFFingerprints() []WAFFingerprintRule {
+	raw := []struct {
+		name, vendor   string
+		confidence     int
+		statusCodes    []int
+		headers        [][2]string
+		cookiePatterns []string
+		bodyPatterns   []string
+	}{
+		{
+			name: "Cloudflare", vendor: "Cloudflare, Inc.", confidence: 90,
+			statusCodes: []int{403, 429, 503},
+			headers: [][2]string{
+				{"cf-ray", `.+`},
+				{"server", `(?i)cloudflare`},
+			},
+			cookiePatterns: []string{`(?i)__cfduid|__cf_bm|cf_clearance`},
+			bodyPatterns:   []string{`(?i)attention required.{0,200}cloudflare`, `(?i)cloudflare ray id`},
+		},
+		{
+			name: "AWS WAF", vendor: "Amazon Web Services", confidence: 80,
+			statusCodes: []int{403},
+			headers: [][2]string{
+				{"x-amzn-requestid", `.+`},
+				{"x-amz-cf-id", `.+`},
+			},
+			bodyPatterns: []string{`(?i)request blocked.{0,200}amazon`},
+		},
+		{
+			name: "Akamai", vendor: "Akamai Technologies", confidence: 85,
+			headers: [][2]string{
+				{"server", `(?i)AkamaiGHost`},
+				{"x-akamai-transformed", `.+`},
+			},
+			bodyPatterns: []string{`(?i)access denied.{0,200}akamai`},
+		},
+		{
+			name: "Imperva", vendor: "Imperva, Inc.", confidence: 85,
+			headers: [][2]string{
+				{"x-iinfo", `.+`},
+				{"server", `(?i)imperva`},
+			},
+			cookiePatterns: []string{`(?i)incap_ses|visid_incap`},
+			bodyPatterns:   []string{`(?i)incapsula incident id`},
+		},
+		{
+			name: "F5 BIG-IP ASM", vendor: "F5, Inc.", confidence: 80,
+			cookiePatterns: []string{`(?i)TS[0-9a-fA-F]{8,}=|BIGipServer`},
+			bodyPatterns:   []string{`(?i)the requested url was rejected.{0,200}support id`},
+		},
+		{
+			name: "ModSecurity", vendor: "Trustwave/OWASP", confidence: 70,
+			statusCodes: []int{403, 406},
+			headers: [][2]string{
+				{"server", `(?i)mod_security|modsecurity`},
+			},
+			bodyPatterns: []string{`(?i)mod_security|this error was generated by mod_security`},
+		},
+		{
+			name: "Sucuri", vendor: "Sucuri Inc.", confidence: 85,
+			headers: [][2]string{
+				{"x-sucuri-id", `.+`},
+				{"server", `(?i)sucuri`},
+			},
+			bodyPatterns: []string{`(?i)access denied.{0,200}sucuri website firewall`},
+		},
+		{
+			name: "Wallarm", vendor: "Wallarm, Inc.", confidence: 80,
+			headers: [][2]string{
+				{"server", `(?i)wallarm`},
+				{"nginx-wallarm-status", `.+`},
+			},
+		},
+		{
+			name: "Fastly", vendor: "Fastly, Inc.", confidence: 75,
+			headers: [][2]string{
+				{"x-served-by", `(?i)cache-.+-fastly`},
+				{"fastly-debug-digest", `.+`},
+			},
+		},
+		{
+			name: "Barracuda", vendor: "Barracuda Networks", confidence: 80,
+			cookiePatterns: []string{`(?i)barra_counter_session`},
+			bodyPatterns:   []string{`(?i)barracuda.{0,200}web application firewall`},
+		},
+		{
+			name: "Azure Front Door", vendor: "Microsoft Corporation", confidence: 75,
+			headers: [][2]string{
+				{"x-azure-ref", `.+`},
+				{"x-fd-healthprobe", `.+`},
+			},
+		},
+	}
+
+	rules := make([]WAFFingerprintRule, 0, len(raw))
+	for _, r := range raw {
+		rule := WAFFingerprintRule{
+			Name:        r.name,
+			Vendor:      r.vendor,
+			Confidence:  r.confidence,
+			StatusCodes: r.statusCodes,
+		}
+		for _, h := range r.headers {
+			rule.HeaderRules = append(rule.HeaderRules, WAFHeaderRule{
+				Header: h[0], Pattern: h[1], Regex: regexp.MustCompile(h[1]),
+			})
+		}
+		for _, p := range r.cookiePatterns {
+			rule.CookieRules = append(rule.CookieRules, regexp.MustCompile(p))
+		}
+		for _, p := range r.bodyPatterns {
+			rule.BodyRules = append(rule.BodyRules, regexp.MustCompile(p))
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
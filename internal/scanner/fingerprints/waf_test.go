@@ -0,0 +1,59 @@
+package fingerprints
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// repoRootForTest walks up from the package directory looking for go.mod,
+// since DefaultWAFFingerprintsPath (like DefaultSQLFingerprintsPath) is
+// resolved relative to the process's working directory, which for the
+// real binary is the repo root.
+func repoRootForTest(t *testing.T) string {
+	t.Helper()
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd: %v", err)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			t.Fatalf("could not find repo root (go.mod) above %s", dir)
+		}
+		dir = parent
+	}
+}
+
+// TestLoadWAFReadsCheckedInFile pins down DefaultWAFFingerprintsPath to
+// the file actually checked into the repo: LoadWAF swallows read/parse
+// errors and silently falls back to DefaultWAFFingerprints(), so a wrong
+// path would never surface as a test failure unless something checks the
+// underlying load directly.
+func TestLoadWAFReadsCheckedInFile(t *testing.T) {
+	root := repoRootForTest(t)
+	path := filepath.Join(root, DefaultWAFFingerprintsPath)
+
+	rules, err := loadWAFFile(path)
+	if err != nil {
+		t.Fatalf("loadWAFFile(%q) = %v, want the checked-in waf.yaml to load cleanly", path, err)
+	}
+	if len(rules) == 0 {
+		t.Fatalf("loadWAFFile(%q) returned no rules, want the checked-in waf.yaml's rule set", path)
+	}
+
+	names := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		names[r.Name] = true
+	}
+	if !names["Cloudflare"] {
+		t.Errorf("loaded rules missing Cloudflare, want it from waf.yaml's rule list")
+	}
+
+	if got := LoadWAF(path); len(got) != len(rules) {
+		t.Errorf("LoadWAF(%q) returned %d rules, want %d (matching loadWAFFile directly)", path, len(got), len(rules))
+	}
+}
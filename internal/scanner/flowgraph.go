@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/scanner/flowgraph"
+)
+
+// EndpointFlowGraph matches a discovered endpoint's URL against a set of
+// flowgraph.FlowEdge rules and synthesizes the confirmation-step URL(s)
+// reachable from it, substituting back whatever value matched each rule's
+// "{id}" placeholder. It replaces the old inferConfirmationEndpoints, which
+// only covered five hardcoded verb substrings and couldn't express a
+// parameterized flow like "/payments/{id}/authorize" -> "/payments/{id}/capture".
+type EndpointFlowGraph struct {
+	edges []compiledFlowEdge
+}
+
+type compiledFlowEdge struct {
+	edge  flowgraph.FlowEdge
+	regex *regexp.Regexp
+}
+
+// idSegmentPattern matches the value a "{id}" placeholder captures -
+// numeric ids, UUIDs, and the longer alnum/underscore ids payment gateways
+// use (Stripe's "ch_...", Midtrans/Xendit's order/charge ids, etc).
+const idSegmentPattern = `([0-9a-zA-Z_-]+)`
+
+// NewEndpointFlowGraph loads the flow graph rule database from path,
+// falling back to flowgraph.DefaultFlowRules when the file is missing or
+// fails to parse.
+func NewEndpointFlowGraph(path string) *EndpointFlowGraph {
+	return &EndpointFlowGraph{edges: compileFlowEdges(flowgraph.LoadFlowRules(path))}
+}
+
+func compileFlowEdges(edges []flowgraph.FlowEdge) []compiledFlowEdge {
+	compiled := make([]compiledFlowEdge, 0, len(edges))
+	for _, edge := range edges {
+		pattern := regexp.QuoteMeta(edge.FromPattern)
+		pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{id}"), idSegmentPattern)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			// A malformed rule shouldn't take down the whole graph.
+			continue
+		}
+		compiled = append(compiled, compiledFlowEdge{edge: edge, regex: re})
+	}
+	return compiled
+}
+
+// Reachable returns every confirmation-step URL inferred from validateURL:
+// for each edge whose FromPattern matches validateURL's path, ToPattern
+// with "{id}" substituted by whatever that match captured, spliced back
+// into the same position in the path.
+func (g *EndpointFlowGraph) Reachable(validateURL string) []string {
+	u, err := url.Parse(validateURL)
+	if err != nil || u.Path == "" {
+		return nil
+	}
+
+	var out []string
+	for _, ce := range g.edges {
+		loc := ce.regex.FindStringSubmatchIndex(u.Path)
+		if loc == nil {
+			continue
+		}
+
+		id := ""
+		if len(loc) >= 4 && loc[2] >= 0 {
+			id = u.Path[loc[2]:loc[3]]
+		}
+
+		confirmPath := u.Path[:loc[0]] + strings.ReplaceAll(ce.edge.ToPattern, "{id}", id) + u.Path[loc[1]:]
+		confirm := *u
+		confirm.Path = confirmPath
+		out = append(out, confirm.String())
+	}
+
+	return out
+}
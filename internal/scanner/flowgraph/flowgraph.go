@@ -0,0 +1,117 @@
+// Package flowgraph loads the rule file describing multi-step payment
+// flows (authorize->capture, reserve->commit, 3DS-init->3DS-complete,
+// refund-create->refund-confirm, and vendor-specific conventions) that
+// scanner.EndpointFlowGraph walks to find validate/confirm endpoint pairs
+// worth racing.
+package flowgraph
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// DefaultFlowRulesPath is where LoadFlowRules looks for the endpoint flow
+// graph rule file by default, relative to the process's working directory.
+const DefaultFlowRulesPath = "internal/scanner/flowgraph/flows.yaml"
+
+// FlowEdge is one step->step rule: any endpoint whose path matches
+// FromPattern is a candidate validation step, and substituting whatever
+// value matched FromPattern's "{id}" placeholder into ToPattern synthesizes
+// its confirmation step. Patterns with no "{id}" (e.g. the generic
+// "/validate" -> "/confirm" rules) match and substitute as plain text.
+type FlowEdge struct {
+	Name        string
+	FromPattern string
+	ToPattern   string
+}
+
+// LoadFlowRules reads the flow graph rule file from path, falling back to
+// DefaultFlowRules when the file is missing or fails to parse, so
+// EndpointFlowGraph keeps working without a checkout of
+// internal/scanner/flowgraph/flows.yaml next to the binary.
+func LoadFlowRules(path string) []FlowEdge {
+	edges, err := loadFlowFile(path)
+	if err != nil || len(edges) == 0 {
+		return DefaultFlowRules()
+	}
+	return edges
+}
+
+// loadFlowFile parses the same small YAML subset waf.yaml/sql.yaml use: a
+// flat "- name: ..." list where each entry carries a from/to path
+// template. Hand-rolled rather than pulling in a YAML library for this one
+// fixed shape (same approach as fingerprints.loadWAFFile).
+func loadFlowFile(path string) ([]FlowEdge, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var edges []FlowEdge
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "- name:"):
+			edges = append(edges, FlowEdge{Name: unquoteFlowYAML(strings.TrimPrefix(trimmed, "- name:"))})
+		case len(edges) == 0:
+			continue
+		case strings.HasPrefix(trimmed, "from:"):
+			edges[len(edges)-1].FromPattern = unquoteFlowYAML(strings.TrimPrefix(trimmed, "from:"))
+		case strings.HasPrefix(trimmed, "to:"):
+			edges[len(edges)-1].ToPattern = unquoteFlowYAML(strings.TrimPrefix(trimmed, "to:"))
+		}
+	}
+
+	return edges, scanner.Err()
+}
+
+func unquoteFlowYAML(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `"`)
+}
+
+// DefaultFlowRules returns the built-in endpoint flow graph, mirroring
+// internal/scanner/flowgraph/flows.yaml, used when that file can't be loaded.
+func DefaultFlowRules() []FlowEdge {
+	return []FlowEdge{
+		// Generic verb pairs - what inferConfirmationEndpoints used to
+		// hardcode as substring/replace before EndpointFlowGraph.
+		{Name: "generic-validate-confirm", FromPattern: "/validate", ToPattern: "/confirm"},
+		{Name: "generic-check-execute", FromPattern: "/check", ToPattern: "/execute"},
+		{Name: "generic-reserve-commit", FromPattern: "/reserve", ToPattern: "/commit"},
+		{Name: "generic-prepare-complete", FromPattern: "/prepare", ToPattern: "/complete"},
+		{Name: "generic-verify-process", FromPattern: "/verify", ToPattern: "/process"},
+
+		// Generic REST payment conventions, parameterized on the resource id.
+		{Name: "generic-authorize-capture", FromPattern: "/payments/{id}/authorize", ToPattern: "/payments/{id}/capture"},
+		{Name: "generic-refund-create-confirm", FromPattern: "/refunds/{id}", ToPattern: "/refunds/{id}/confirm"},
+		{Name: "generic-3ds-init-complete", FromPattern: "/3ds/{id}/init", ToPattern: "/3ds/{id}/complete"},
+
+		// Stripe: charges created with capture=false need a follow-up
+		// capture call; PaymentIntents need an explicit confirm.
+		{Name: "stripe-charge-capture", FromPattern: "/v1/charges/{id}", ToPattern: "/v1/charges/{id}/capture"},
+		{Name: "stripe-payment-intent-confirm", FromPattern: "/v1/payment_intents/{id}", ToPattern: "/v1/payment_intents/{id}/confirm"},
+
+		// Adyen Checkout API: an authorised payment is captured (and, for
+		// 3DS2, its challenge result confirmed) via a follow-up call keyed
+		// on the same payment id.
+		{Name: "adyen-payment-authorise-capture", FromPattern: "/v68/payments/{id}/authorise", ToPattern: "/v68/payments/{id}/captures"},
+		{Name: "adyen-3ds-challenge-confirm", FromPattern: "/v68/payments/{id}/3ds2/challenge", ToPattern: "/v68/payments/{id}/3ds2/confirm"},
+
+		// Midtrans: a charge can be approved (out of the fraud-challenge
+		// queue) or captured (for pre-authorized card charges) by order id.
+		{Name: "midtrans-charge-capture", FromPattern: "/v2/charge/{id}", ToPattern: "/v2/{id}/capture"},
+		{Name: "midtrans-charge-approve", FromPattern: "/v2/charge/{id}", ToPattern: "/v2/{id}/approve"},
+
+		// Xendit: credit card charges follow the same authorize-then-capture
+		// shape, keyed on the charge id.
+		{Name: "xendit-credit-card-capture", FromPattern: "/credit_card_charges/{id}", ToPattern: "/credit_card_charges/{id}/capture"},
+	}
+}
@@ -5,38 +5,139 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/gorilla/websocket"
 )
 
 // TestGraphQLVulnerabilities tests GraphQL-specific vulnerabilities
 func TestGraphQLVulnerabilities(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
+	if endpoint.Type == "graphql-subscription" {
+		return testGraphQLSubscriptionAuth(endpoint, session)
+	}
+
 	// Test 1: Introspection enabled (CRITICAL in production)
 	vulns = append(vulns, testGraphQLIntrospection(endpoint, session)...)
-	
-	// Test 2: No depth limit (DoS)
-	vulns = append(vulns, testGraphQLDepthLimit(endpoint, session)...)
-	
-	// Test 3: Batch query attack
-	vulns = append(vulns, testGraphQLBatchAttack(endpoint, session)...)
-	
-	// Test 4: Field duplication (resource exhaustion)
-	vulns = append(vulns, testGraphQLFieldDuplication(endpoint, session)...)
-	
-	// Test 5: Authorization bypass
+
+	// Test 2: Query complexity scoring (replaces the old fixed depth/batch/
+	// field-duplication probes with a single quantitative DoS finding)
+	vulns = append(vulns, testGraphQLQueryComplexity(endpoint, session)...)
+
+	// Test 3: Authorization bypass
 	vulns = append(vulns, testGraphQLAuthBypass(endpoint, session)...)
-	
+
+	// Test 4: Introspection-driven attack surface - enumerate payment-
+	// related query/mutation fields from the full schema and probe each
+	// for BOLA/missing-auth, plus flag introspection left on with no
+	// development UI present
+	vulns = append(vulns, testGraphQLAttackSurface(endpoint, session)...)
+
+	return vulns
+}
+
+// testGraphQLSubscriptionAuth connects to a discovered GraphQL subscription
+// endpoint (discovery.GraphQLScanner) with a bare connection_init - no
+// connectionParams auth token at all - and checks whether the server still
+// acks the handshake and lets a subscribe through, instead of rejecting the
+// connection until authenticated.
+func testGraphQLSubscriptionAuth(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	protocol := endpoint.Parameters["protocol"]
+	if protocol == "" {
+		protocol = "graphql-transport-ws"
+	}
+
+	dialer := websocket.Dialer{
+		Subprotocols:     []string{protocol},
+		HandshakeTimeout: 10 * time.Second,
+	}
+	conn, _, err := dialer.Dial(endpoint.URL, nil)
+	if err != nil {
+		return vulns
+	}
+	defer conn.Close()
+
+	initMsg, _ := json.Marshal(map[string]interface{}{
+		"type":    "connection_init",
+		"payload": map[string]string{}, // no auth token in connectionParams
+	})
+	if err := conn.WriteMessage(websocket.TextMessage, initMsg); err != nil {
+		return vulns
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, ackReply, err := conn.ReadMessage()
+	if err != nil || !strings.Contains(string(ackReply), "connection_ack") {
+		return vulns
+	}
+
+	startType := "subscribe"
+	if protocol == "graphql-ws" {
+		startType = "start"
+	}
+	payload, _ := json.Marshal(map[string]string{"query": "subscription { __typename }"})
+	subscribeMsg, _ := json.Marshal(map[string]interface{}{
+		"id":      "1",
+		"type":    startType,
+		"payload": json.RawMessage(payload),
+	})
+	if err := conn.WriteMessage(websocket.TextMessage, subscribeMsg); err != nil {
+		return vulns
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, reply, err := conn.ReadMessage()
+	if err != nil {
+		return vulns
+	}
+	if strings.Contains(string(reply), `"type":"error"`) || strings.Contains(string(reply), `"type":"connection_error"`) {
+		return vulns
+	}
+
+	vulns = append(vulns, models.Vulnerability{
+		Type:        "GraphQL Subscription Authorization Bypass",
+		Severity:    "CRITICAL",
+		Title:       "GraphQL Subscription Accepts Unauthenticated Connections",
+		Description: fmt.Sprintf("The %s subscription endpoint acknowledged connection_init and accepted a subscribe message with no auth token in connectionParams.", protocol),
+		Endpoint:    endpoint.URL,
+		Method:      "GET",
+		Proof:       fmt.Sprintf("connection_init with empty payload acked, subscribe accepted: %s", truncateGraphQLProof(string(reply))),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-306",
+		CVSSScore:   9.1,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+		Confidence:  "High",
+		Impact:      "Anyone can subscribe to payment-related events (order status, payment completion) pushed over this socket without authenticating.",
+		Remediation: "Validate the auth token in connection_init's connectionParams and reject (close with code 4401) any connection or subscribe that isn't authenticated.",
+		References: []string{
+			"https://cwe.mitre.org/data/definitions/306.html",
+			"https://the-guild.dev/graphql/ws/README#auth",
+		},
+	})
+
 	return vulns
 }
 
+func truncateGraphQLProof(s string) string {
+	const max = 200
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
+
 func testGraphQLIntrospection(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Full introspection query
 	query := map[string]string{
 		"query": `
@@ -53,25 +154,25 @@ func testGraphQLIntrospection(endpoint models.Endpoint, session *models.Session)
 			}
 		`,
 	}
-	
+
 	jsonData, _ := json.Marshal(query)
-	client := utils.NewHTTPClient(10 * time.Second)
-	
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+
 	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 	addAuthHeaders(req, session)
-	
+
 	resp, err := client.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+
 	if resp.StatusCode == 200 {
 		bodyBytes := make([]byte, 8192)
 		n, _ := resp.Body.Read(bodyBytes)
 		body := string(bodyBytes[:n])
-		
+
 		// Check if introspection succeeded
 		if strings.Contains(body, "queryType") || strings.Contains(body, "mutationType") {
 			vulns = append(vulns, models.Vulnerability{
@@ -102,217 +203,293 @@ if os.Getenv("ENV") == "production" {
 			})
 		}
 	}
-	
+
 	return vulns
 }
 
-func testGraphQLDepthLimit(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
-	vulns := []models.Vulnerability{}
-	
-	// Create deeply nested query (20 levels)
-	deepQuery := `
-		query DeepNesting {
-			user {
-				posts {
-					comments {
-						author {
-							posts {
-								comments {
-									author {
-										posts {
-											comments {
-												author {
-													posts {
-														comments {
-															author {
-																posts {
-																	comments {
-																		author {
-																			id
-																		}
-																	}
-																}
-															}
-														}
-													}
-												}
-											}
-										}
-									}
-								}
-							}
-						}
+// graphQLSchema is a minimal, introspection-derived view of the target's
+// schema used to weight query complexity. When introspection is blocked we
+// fall back to a generic cost model instead.
+type graphQLSchema struct {
+	listFields map[string]bool // field names known to return lists
+}
+
+// introspectGraphQLSchema runs an introspection query and extracts the
+// field names that look like list-returning fields (plural names, or
+// names under "edges"/"nodes", as used by Relay-style connections).
+func introspectGraphQLSchema(endpoint models.Endpoint, session *models.Session) (*graphQLSchema, bool) {
+	query := map[string]string{
+		"query": `
+			query IntrospectionQuery {
+				__schema {
+					types {
+						name
+						fields { name }
 					}
 				}
 			}
-		}
-	`
-	
-	query := map[string]string{"query": deepQuery}
+		`,
+	}
+
 	jsonData, _ := json.Marshal(query)
-	
-	client := utils.NewHTTPClient(30 * time.Second)
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+
 	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 	addAuthHeaders(req, session)
-	
-	startTime := time.Now()
+
 	resp, err := client.Do(req)
-	duration := time.Since(startTime)
-	
 	if err != nil {
-		return vulns
+		return nil, false
 	}
 	defer resp.Body.Close()
-	
-	// If query succeeds or takes very long = vulnerability
-	if resp.StatusCode == 200 || duration > 10*time.Second {
-		vulns = append(vulns, models.Vulnerability{
-			Type:        "GraphQL DoS",
-			Severity:    "CRITICAL",
-			Title:       "GraphQL Query Depth Not Limited",
-			Description: fmt.Sprintf("GraphQL accepts deeply nested queries (20 levels) which can cause severe performance degradation. Query took %v to execute.", duration),
-			Endpoint:    endpoint.URL,
-			Method:      "POST",
-			Proof:       fmt.Sprintf("20-level nested query executed in %v", duration),
-			Timestamp:   time.Now(),
-			CWE:         "CWE-770",
-			CVSSScore:   7.5,
-			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
-			Confidence:  "High",
-			Impact:      "Attackers can exhaust server resources with complex queries, causing DoS",
-			Remediation: "Implement query depth limiting (max 10-15 levels recommended)",
-			References: []string{
-				"https://cheatsheetseries.owasp.org/cheatsheets/GraphQL_Cheat_Sheet.html#query-limiting-depth",
-				"https://cwe.mitre.org/data/definitions/770.html",
-			},
-		})
-	}
-	
-	return vulns
+
+	if resp.StatusCode != 200 {
+		return nil, false
+	}
+
+	var parsed struct {
+		Data struct {
+			Schema struct {
+				Types []struct {
+					Name   string `json:"name"`
+					Fields []struct {
+						Name string `json:"name"`
+					} `json:"fields"`
+				} `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, false
+	}
+	if len(parsed.Data.Schema.Types) == 0 {
+		return nil, false
+	}
+
+	schema := &graphQLSchema{listFields: make(map[string]bool)}
+	for _, t := range parsed.Data.Schema.Types {
+		for _, f := range t.Fields {
+			if isListFieldName(f.Name) {
+				schema.listFields[f.Name] = true
+			}
+		}
+	}
+
+	return schema, true
 }
 
-func testGraphQLBatchAttack(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
-	vulns := []models.Vulnerability{}
-	
-	// Create batch query with 100 identical queries
-	batchQueries := make([]map[string]string, 100)
-	for i := 0; i < 100; i++ {
-		batchQueries[i] = map[string]string{
-			"query": fmt.Sprintf(`query Query%d { __typename }`, i),
+func isListFieldName(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, "s") {
+		return true
+	}
+	for _, kw := range []string{"edges", "nodes", "items", "list", "connection"} {
+		if strings.Contains(lower, kw) {
+			return true
 		}
 	}
-	
-	jsonData, _ := json.Marshal(batchQueries)
-	client := utils.NewHTTPClient(30 * time.Second)
-	
-	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	addAuthHeaders(req, session)
-	
-	startTime := time.Now()
-	resp, err := client.Do(req)
-	duration := time.Since(startTime)
-	
-	if err != nil {
-		return vulns
+	return false
+}
+
+// computeQueryComplexity walks the query field-by-field (via brace
+// matching, since we don't depend on a full GraphQL AST library) and sums
+// a per-field cost: 1 by default, or the list-argument value (first:/
+// limit:/last:) when the field takes one, multiplied by its nesting depth.
+// This mirrors the cost model gqlgen/Apollo servers use to reject
+// expensive queries. When schema is non-nil, only fields it recognizes as
+// list fields are weighted by their argument; everything else costs 1 per
+// level, same as the generic fallback model.
+func computeQueryComplexity(query string, schema *graphQLSchema) int {
+	fieldRegex := regexp.MustCompile(`(\w+)\s*(\(([^)]*)\))?`)
+	listArgRegex := regexp.MustCompile(`(?:first|limit|last)\s*:\s*(\d+)`)
+
+	complexity := 0
+	depth := 0
+	i := 0
+	for i < len(query) {
+		switch query[i] {
+		case '{':
+			depth++
+			i++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+			i++
+		default:
+			if isIdentStart(query[i]) {
+				rest := query[i:]
+				loc := fieldRegex.FindStringSubmatchIndex(rest)
+				if loc == nil || loc[0] != 0 {
+					i++
+					continue
+				}
+				name := rest[loc[2]:loc[3]]
+				args := ""
+				if loc[6] != -1 {
+					args = rest[loc[6]:loc[7]]
+				}
+
+				weight := 1
+				if schema == nil || schema.listFields[name] {
+					if m := listArgRegex.FindStringSubmatch(args); m != nil {
+						if n, err := strconv.Atoi(m[1]); err == nil {
+							weight = n
+						}
+					}
+				}
+
+				effectiveDepth := depth
+				if effectiveDepth < 1 {
+					effectiveDepth = 1
+				}
+				complexity += weight * effectiveDepth
+
+				i += loc[1]
+				continue
+			}
+			i++
+		}
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode == 200 {
-		vulns = append(vulns, models.Vulnerability{
-			Type:        "GraphQL Batch Attack",
-			Severity:    "HIGH",
-			Title:       "GraphQL Batch Queries Not Limited",
-			Description: fmt.Sprintf("Server accepted 100 batched queries in a single request (executed in %v). This can be exploited for amplification attacks.", duration),
-			Endpoint:    endpoint.URL,
-			Method:      "POST",
-			Proof:       fmt.Sprintf("Sent 100 batched queries, all processed in %v", duration),
-			Timestamp:   time.Now(),
-			CWE:         "CWE-799",
-			CVSSScore:   6.5,
-			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
-			Confidence:  "High",
-			Remediation: "Limit batch query size to maximum 10-20 queries per request",
-		})
-	}
-	
-	return vulns
+
+	return complexity
 }
 
-func testGraphQLFieldDuplication(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func isIdentStart(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '_'
+}
+
+// buildComplexityProbe generates a query nested `depth` levels deep, each
+// level pulling a list field with `first: 2`, so computeQueryComplexity
+// grows roughly quadratically with depth and the probe can binary-search
+// on depth while reporting the real, schema-aware complexity score.
+func buildComplexityProbe(depth int) string {
+	open := strings.Repeat("items(first: 2) { ", depth)
+	closeTag := strings.Repeat("} ", depth)
+	return fmt.Sprintf("query { %sid %s}", open, closeTag)
+}
+
+// testGraphQLQueryComplexity replaces the old fixed depth/batch/field-
+// duplication probes with binary-search complexity probing: starting
+// around complexity 10, it doubles the probe's depth until the server
+// errors or responds too slowly, then reports the maximum accepted
+// complexity and the complexity that broke it as a single, quantitative
+// finding.
+func testGraphQLQueryComplexity(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
-	// Create query with 1000 duplicate fields
-	duplicatedFields := strings.Repeat("__typename\n", 1000)
-	query := fmt.Sprintf(`query { %s }`, duplicatedFields)
-	
-	payload := map[string]string{"query": query}
-	jsonData, _ := json.Marshal(payload)
-	
-	client := utils.NewHTTPClient(30 * time.Second)
-	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	addAuthHeaders(req, session)
-	
-	resp, err := client.Do(req)
-	if err != nil {
+
+	schema, introspected := introspectGraphQLSchema(endpoint, session)
+
+	const latencyThreshold = 10 * time.Second
+	client := utils.NewHTTPClientForEndpoint(30 * time.Second, session, endpoint)
+
+	maxAcceptedComplexity := 0
+	breakingComplexity := 0
+	depth := 3 // buildComplexityProbe(3) already exceeds complexity 10
+
+	for iteration := 0; iteration < 8; iteration++ {
+		probeQuery := buildComplexityProbe(depth)
+		complexity := computeQueryComplexity(probeQuery, schema)
+
+		jsonData, _ := json.Marshal(map[string]string{"query": probeQuery})
+		req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		addAuthHeaders(req, session)
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		duration := time.Since(start)
+
+		if err != nil {
+			breakingComplexity = complexity
+			break
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 || duration > latencyThreshold {
+			breakingComplexity = complexity
+			break
+		}
+
+		maxAcceptedComplexity = complexity
+		depth *= 2
+	}
+
+	if breakingComplexity == 0 {
+		// Never broke within our probing budget - still worth reporting
+		// the largest complexity we confirmed the server accepts.
+		breakingComplexity = maxAcceptedComplexity
+	}
+
+	if maxAcceptedComplexity < 10 {
+		// Server rejected even the smallest probe - complexity limiting
+		// appears to be in place.
 		return vulns
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode == 200 {
-		vulns = append(vulns, models.Vulnerability{
-			Type:        "GraphQL Field Duplication",
-			Severity:    "MEDIUM",
-			Title:       "GraphQL Field Duplication Not Limited",
-			Description: "Server accepted query with 1000 duplicate fields, which can cause excessive memory usage and CPU consumption.",
-			Endpoint:    endpoint.URL,
-			Method:      "POST",
-			Proof:       "Query with 1000 duplicate __typename fields was processed",
-			Timestamp:   time.Now(),
-			CWE:         "CWE-1333",
-			CVSSScore:   5.3,
-			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L",
-			Confidence:  "Medium",
-			Remediation: "Implement field count limits and query complexity analysis",
-		})
-	}
-	
+
+	schemaNote := "no schema available (introspection blocked); used the generic cost model"
+	if introspected {
+		schemaNote = "derived from the target's introspected schema"
+	}
+
+	vulns = append(vulns, models.Vulnerability{
+		Type:        "GraphQL Query Complexity Limit",
+		Severity:    "HIGH",
+		Title:       "GraphQL Query Complexity Not Limited",
+		Description: fmt.Sprintf("Server accepted queries up to complexity %d before failing or exceeding %v latency. Field cost weights were %s.", maxAcceptedComplexity, latencyThreshold, schemaNote),
+		Endpoint:    endpoint.URL,
+		Method:      "POST",
+		Proof:       fmt.Sprintf("Max accepted complexity: %d; breaking complexity: %d", maxAcceptedComplexity, breakingComplexity),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-770",
+		CVSSScore:   7.5,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
+		Confidence:  "High",
+		Impact:      "Attackers can craft a single query that costs as much as the breaking complexity to exhaust server resources, causing DoS.",
+		Remediation: fmt.Sprintf(`Implement query complexity analysis and reject queries above a fixed budget (e.g. gqlgen's complexity.Calculate):
+
+srv.Use(extension.FixedComplexityLimit(%d))`, maxAcceptedComplexity),
+		References: []string{
+			"https://cheatsheetseries.owasp.org/cheatsheets/GraphQL_Cheat_Sheet.html#query-limiting-depth",
+			"https://cwe.mitre.org/data/definitions/770.html",
+		},
+	})
+
 	return vulns
 }
 
 func testGraphQLAuthBypass(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Test accessing sensitive queries without auth
 	sensitiveQueries := []string{
 		`query { users { id email } }`,
 		`query { payments { id amount } }`,
 		`mutation { deleteUser(id: 1) { success } }`,
 	}
-	
-	client := utils.NewHTTPClient(10 * time.Second)
-	
+
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+
 	for _, queryStr := range sensitiveQueries {
 		payload := map[string]string{"query": queryStr}
 		jsonData, _ := json.Marshal(payload)
-		
+
 		req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
 		// NO AUTH HEADERS!
-		
+
 		resp, err := client.Do(req)
 		if err != nil {
 			continue
 		}
-		
+
 		if resp.StatusCode == 200 {
 			bodyBytes := make([]byte, 4096)
 			n, _ := resp.Body.Read(bodyBytes)
 			resp.Body.Close()
 			body := string(bodyBytes[:n])
-			
+
 			// If we get data (not just errors), it's a vulnerability
 			if !strings.Contains(body, "Unauthorized") && !strings.Contains(body, "Forbidden") {
 				vulns = append(vulns, models.Vulnerability{
@@ -336,6 +513,401 @@ func testGraphQLAuthBypass(endpoint models.Endpoint, session *models.Session) []
 			resp.Body.Close()
 		}
 	}
-	
+
 	return vulns
 }
+
+// graphqlPaymentKeywords is matched, case-insensitively, against every
+// Query/Mutation field name and its argument names to decide which parts of
+// a discovered schema are worth actively probing.
+var graphqlPaymentKeywords = []string{"charge", "refund", "payment", "invoice", "subscription", "wallet", "amount", "money"}
+
+// gqlTypeRef is a GraphQL type reference as introspection returns it - a
+// NON_NULL or LIST wrapper carries its wrapped type in OfType, one level
+// deep (enough to resolve the "ID!"/"[String]"-style types payment APIs
+// actually use for their arguments).
+type gqlTypeRef struct {
+	Name   string      `json:"name"`
+	Kind   string      `json:"kind"`
+	OfType *gqlTypeRef `json:"ofType"`
+}
+
+type gqlArg struct {
+	Name string     `json:"name"`
+	Type gqlTypeRef `json:"type"`
+}
+
+type gqlField struct {
+	Name string     `json:"name"`
+	Args []gqlArg   `json:"args"`
+	Type gqlTypeRef `json:"type"`
+}
+
+type gqlEnumValue struct {
+	Name string `json:"name"`
+}
+
+type gqlFullType struct {
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"`
+	EnumValues []gqlEnumValue `json:"enumValues"`
+	Fields     []gqlField     `json:"fields"`
+}
+
+type gqlNamedRef struct {
+	Name string `json:"name"`
+}
+
+// gqlFullSchema is the full-introspection shape testGraphQLAttackSurface
+// needs: the root operation type names plus every type's fields and
+// arguments, so payment-related fields can be found and invoked.
+type gqlFullSchema struct {
+	QueryType        *gqlNamedRef  `json:"queryType"`
+	MutationType     *gqlNamedRef  `json:"mutationType"`
+	SubscriptionType *gqlNamedRef  `json:"subscriptionType"`
+	Types            []gqlFullType `json:"types"`
+}
+
+// graphqlAttackCandidate is a payment-relevant Query/Mutation field found by
+// paymentRelevantFields, tagged with which root operation type it belongs
+// to so it's invoked as the right kind of document.
+type graphqlAttackCandidate struct {
+	opType string // "query" or "mutation"
+	field  gqlField
+}
+
+// testGraphQLAttackSurface fetches the full schema and, when introspection
+// succeeds, enumerates every payment-related Query/Mutation field, probes
+// each for BOLA/missing-auth by invoking it with a synthesized argument set
+// both unauthenticated and authenticated, and flags introspection being
+// enabled with no development UI present as its own finding.
+func testGraphQLAttackSurface(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	schema, ok := fetchGraphQLSchema(endpoint, session)
+	if !ok {
+		return vulns
+	}
+
+	vulns = append(vulns, testGraphQLProductionIntrospection(endpoint)...)
+
+	types := make(map[string]gqlFullType, len(schema.Types))
+	for _, t := range schema.Types {
+		types[t.Name] = t
+	}
+
+	for _, candidate := range paymentRelevantFields(schema) {
+		vulns = append(vulns, testGraphQLFieldAccess(endpoint, session, candidate.opType, candidate.field, types)...)
+	}
+
+	return vulns
+}
+
+// fetchGraphQLSchema runs the full introspection query (root operation type
+// names, plus every type's fields/args/enum values) needed to synthesize
+// invocations, returning ok=false if introspection is disabled or the
+// response doesn't parse as a schema.
+func fetchGraphQLSchema(endpoint models.Endpoint, session *models.Session) (*gqlFullSchema, bool) {
+	query := map[string]string{
+		"query": `
+			query IntrospectionQuery {
+				__schema {
+					queryType { name }
+					mutationType { name }
+					subscriptionType { name }
+					types {
+						name
+						kind
+						enumValues { name }
+						fields {
+							name
+							args {
+								name
+								type { name kind ofType { name kind } }
+							}
+							type { name kind ofType { name kind } }
+						}
+					}
+				}
+			}
+		`,
+	}
+
+	jsonData, _ := json.Marshal(query)
+	client := utils.NewHTTPClientForEndpoint(15 * time.Second, session, endpoint)
+
+	req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	addAuthHeaders(req, session)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, false
+	}
+
+	var parsed struct {
+		Data struct {
+			Schema gqlFullSchema `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, false
+	}
+	if len(parsed.Data.Schema.Types) == 0 {
+		return nil, false
+	}
+
+	return &parsed.Data.Schema, true
+}
+
+// paymentRelevantFields walks schema's Query and Mutation root types and
+// returns every field whose name or argument names match
+// graphqlPaymentKeywords.
+func paymentRelevantFields(schema *gqlFullSchema) []graphqlAttackCandidate {
+	var candidates []graphqlAttackCandidate
+
+	typesByName := make(map[string]gqlFullType, len(schema.Types))
+	for _, t := range schema.Types {
+		typesByName[t.Name] = t
+	}
+
+	collect := func(rootName, opType string) {
+		root, ok := typesByName[rootName]
+		if !ok {
+			return
+		}
+		for _, field := range root.Fields {
+			if isPaymentRelevantField(field) {
+				candidates = append(candidates, graphqlAttackCandidate{opType: opType, field: field})
+			}
+		}
+	}
+
+	if schema.QueryType != nil {
+		collect(schema.QueryType.Name, "query")
+	}
+	if schema.MutationType != nil {
+		collect(schema.MutationType.Name, "mutation")
+	}
+
+	return candidates
+}
+
+func isPaymentRelevantField(field gqlField) bool {
+	if containsAnyKeyword(field.Name, graphqlPaymentKeywords) {
+		return true
+	}
+	for _, arg := range field.Args {
+		if containsAnyKeyword(arg.Name, graphqlPaymentKeywords) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAnyKeyword(s string, keywords []string) bool {
+	lower := strings.ToLower(s)
+	for _, kw := range keywords {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultLiteralForType returns a minimal valid GraphQL literal for t,
+// unwrapping a single level of NON_NULL/LIST (the depth fetchGraphQLSchema's
+// introspection query resolves) and picking a type-aware default: "1" for
+// ID, 1 for Int, 1.0 for Float, true for Boolean, the first declared value
+// for enums, and "test" for anything else (String and unknown scalars).
+func defaultLiteralForType(t gqlTypeRef, types map[string]gqlFullType) string {
+	if (t.Kind == "NON_NULL" || t.Kind == "LIST") && t.OfType != nil {
+		inner := defaultLiteralForType(*t.OfType, types)
+		if t.Kind == "LIST" {
+			return "[" + inner + "]"
+		}
+		return inner
+	}
+
+	if t.Kind == "ENUM" {
+		if def, ok := types[t.Name]; ok && len(def.EnumValues) > 0 {
+			return def.EnumValues[0].Name
+		}
+		return "UNKNOWN"
+	}
+
+	switch t.Name {
+	case "ID":
+		return `"1"`
+	case "Int":
+		return "1"
+	case "Float":
+		return "1.0"
+	case "Boolean":
+		return "true"
+	default:
+		return `"test"`
+	}
+}
+
+// buildFieldInvocation synthesizes a minimal valid query/mutation document
+// that calls field with a default-valued argument for each of its args.
+func buildFieldInvocation(opType string, field gqlField, types map[string]gqlFullType) string {
+	var argParts []string
+	for _, arg := range field.Args {
+		argParts = append(argParts, fmt.Sprintf("%s: %s", arg.Name, defaultLiteralForType(arg.Type, types)))
+	}
+
+	argStr := ""
+	if len(argParts) > 0 {
+		argStr = "(" + strings.Join(argParts, ", ") + ")"
+	}
+
+	return fmt.Sprintf("%s { %s%s { __typename } }", opType, field.Name, argStr)
+}
+
+// executeGraphQLQuery POSTs query to url, attaching session's auth headers
+// when session is non-nil, and returns the first 4KB of the response body
+// plus whether the request completed with a 200.
+func executeGraphQLQuery(client *http.Client, url, query string, session *models.Session) (string, bool) {
+	jsonData, _ := json.Marshal(map[string]string{"query": query})
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	if session != nil {
+		addAuthHeaders(req, session)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+
+	bodyBytes := make([]byte, 4096)
+	n, _ := resp.Body.Read(bodyBytes)
+	return string(bodyBytes[:n]), resp.StatusCode == 200
+}
+
+// testGraphQLFieldAccess invokes field (built via buildFieldInvocation) with
+// no session credentials at all, and flags a BOLA/missing-auth finding if
+// the server still returns a 200 without an Unauthorized/Forbidden error -
+// the same acceptance heuristic testGraphQLAuthBypass uses for its fixed
+// query list, applied here to whatever the schema itself says is
+// payment-related.
+func testGraphQLFieldAccess(endpoint models.Endpoint, session *models.Session, opType string, field gqlField, types map[string]gqlFullType) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+	query := buildFieldInvocation(opType, field, types)
+
+	unauthBody, unauthOK := executeGraphQLQuery(client, endpoint.URL, query, nil)
+	if !unauthOK {
+		return vulns
+	}
+	if strings.Contains(unauthBody, "Unauthorized") || strings.Contains(unauthBody, "Forbidden") {
+		return vulns
+	}
+
+	authBody, _ := executeGraphQLQuery(client, endpoint.URL, query, session)
+
+	vulns = append(vulns, models.Vulnerability{
+		Type:        "GraphQL Authorization Bypass",
+		Severity:    "CRITICAL",
+		Title:       fmt.Sprintf("GraphQL %s %q Accessible Without Authentication", opType, field.Name),
+		Description: fmt.Sprintf("The payment-related %s field %q was invoked with no session credentials and the server did not reject it.", opType, field.Name),
+		Endpoint:    endpoint.URL,
+		Method:      "POST",
+		Proof:       fmt.Sprintf("Query: %s\nUnauthenticated response: %s\nAuthenticated response: %s", query, truncateGraphQLProof(unauthBody), truncateGraphQLProof(authBody)),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-862",
+		CVSSScore:   9.1,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+		Confidence:  "Medium",
+		Impact:      "Attackers can invoke payment-sensitive GraphQL operations (charges, refunds, wallet balances) without authenticating.",
+		Remediation: fmt.Sprintf("Add an authorization check (resolver middleware or directive) to the %s field %q that rejects unauthenticated/unauthorized callers.", opType, field.Name),
+		References: []string{
+			"https://cheatsheetseries.owasp.org/cheatsheets/GraphQL_Cheat_Sheet.html#authorization",
+			"https://cwe.mitre.org/data/definitions/862.html",
+		},
+	})
+
+	return vulns
+}
+
+// testGraphQLProductionIntrospection flags introspection being enabled when
+// no GraphiQL/Playground development UI is reachable at the usual paths -
+// a signal that introspection was left on in a production deployment
+// rather than a dev environment where it's expected.
+func testGraphQLProductionIntrospection(endpoint models.Endpoint) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	if hasGraphiQLUI(endpoint) {
+		return vulns
+	}
+
+	vulns = append(vulns, models.Vulnerability{
+		Type:        "GraphQL Introspection",
+		Severity:    "HIGH",
+		Title:       "GraphQL Introspection Enabled With No Development UI Present",
+		Description: "Full schema introspection succeeded but no GraphiQL/Playground UI was found at the usual paths, suggesting this is a production deployment that left introspection on rather than a development environment.",
+		Endpoint:    endpoint.URL,
+		Method:      "POST",
+		Proof:       "Introspection query returned a full schema; /graphiql and /playground did not serve a development UI",
+		Timestamp:   time.Now(),
+		CWE:         "CWE-200",
+		CVSSScore:   7.5,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+		Confidence:  "Medium",
+		Impact:      "Attackers can enumerate the complete schema - types, queries, mutations, and arguments - to plan targeted attacks against payment operations.",
+		Remediation: `Disable introspection outside development:
+
+// Apollo Server:
+new ApolloServer({ schema, introspection: process.env.NODE_ENV !== 'production' })
+
+// gqlgen:
+srv.Use(extension.Introspection{})  // omit/guard this line in production builds`,
+		References: []string{
+			"https://cheatsheetseries.owasp.org/cheatsheets/GraphQL_Cheat_Sheet.html",
+			"https://cwe.mitre.org/data/definitions/200.html",
+		},
+	})
+
+	return vulns
+}
+
+// hasGraphiQLUI checks the usual development-UI paths on endpoint's host
+// for a GraphiQL or Apollo/GraphQL Playground page.
+func hasGraphiQLUI(endpoint models.Endpoint) bool {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return true // can't tell - don't report a finding on a guess
+	}
+
+	client := utils.NewHTTPClient(5 * time.Second)
+	for _, path := range []string{"/graphiql", "/playground"} {
+		u.Path = path
+		resp, err := client.Get(u.String())
+		if err != nil {
+			continue
+		}
+
+		bodyBytes := make([]byte, 2048)
+		n, _ := resp.Body.Read(bodyBytes)
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			continue
+		}
+		body := strings.ToLower(string(bodyBytes[:n]))
+		if strings.Contains(body, "graphiql") || strings.Contains(body, "playground") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -14,53 +14,55 @@ import (
 )
 
 // TestIdempotency tests idempotency key enforcement vulnerabilities
-func TestIdempotency(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func TestIdempotency(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// P0 Tests (Complete Implementation)
-	vulns = append(vulns, testIdempotencyKeyCollision(endpoint, session)...)
-	vulns = append(vulns, testIdempotencyExpiredKeyReuse(endpoint, session)...)
-	vulns = append(vulns, testIdempotencyMissingKey(endpoint, session)...)
-	vulns = append(vulns, testIdempotencyCaseSensitivity(endpoint, session)...)
-	vulns = append(vulns, testIdempotencyRaceCondition(endpoint, session)...)
-	
+	vulns = append(vulns, testIdempotencyKeyCollision(endpoint, session, rl)...)
+	vulns = append(vulns, testIdempotencyExpiredKeyReuse(endpoint, session, rl)...)
+	vulns = append(vulns, testIdempotencyMissingKey(endpoint, session, rl)...)
+	vulns = append(vulns, testIdempotencyCaseSensitivity(endpoint, session, rl)...)
+	vulns = append(vulns, testIdempotencyRaceCondition(endpoint, session, rl)...)
+
 	return vulns
 }
 
 // testIdempotencyKeyCollision tests if same key with different body is rejected
-func testIdempotencyKeyCollision(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testIdempotencyKeyCollision(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Generate idempotency key
 	idempotencyKey := generateIdempotencyKey()
-	
+
 	// First request with amount 100
 	payload1 := map[string]interface{}{
 		"amount":   100,
 		"currency": "USD",
 		"order_id": "test_collision_001",
 	}
-	
-	resp1, body1 := sendPaymentRequest(endpoint, session, idempotencyKey, payload1)
+
+	rl.Wait(endpoint.URL)
+	resp1, body1 := sendPaymentRequest(endpoint, session, idempotencyKey, payload1, rl)
 	if resp1 == nil || (resp1.StatusCode < 200 || resp1.StatusCode >= 300) {
 		return vulns // First request failed, skip test
 	}
-	
+
 	// Wait a bit
 	time.Sleep(500 * time.Millisecond)
-	
+
 	// Second request with SAME key but DIFFERENT amount (200)
 	payload2 := map[string]interface{}{
 		"amount":   200, // DIFFERENT!
 		"currency": "USD",
 		"order_id": "test_collision_002", // DIFFERENT!
 	}
-	
-	resp2, body2 := sendPaymentRequest(endpoint, session, idempotencyKey, payload2)
+
+	rl.Wait(endpoint.URL)
+	resp2, body2 := sendPaymentRequest(endpoint, session, idempotencyKey, payload2, rl)
 	if resp2 == nil {
 		return vulns
 	}
-	
+
 	// Server should reject or return same result as first request
 	// If it accepts and processes second request differently = VULNERABLE
 	if resp2.StatusCode >= 200 && resp2.StatusCode < 300 {
@@ -116,32 +118,33 @@ func (c *IdempotencyCache) Check(key string, requestHash string) ([]byte, bool)
 			})
 		}
 	}
-	
+
 	return vulns
 }
 
 // testIdempotencyExpiredKeyReuse tests if expired keys (> 24h) can be reused
-func testIdempotencyExpiredKeyReuse(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testIdempotencyExpiredKeyReuse(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Note: This is a simulation since we can't actually wait 25 hours
 	// We test by sending a key with old timestamp in the key itself
-	
+
 	// Create key with "old" timestamp (25 hours ago) encoded in it
 	oldTimestamp := time.Now().Add(-25 * time.Hour).Unix()
 	oldKey := fmt.Sprintf("idem_%d_%s", oldTimestamp, generateRandomString(16))
-	
+
 	payload := map[string]interface{}{
 		"amount":   100,
 		"currency": "USD",
 		"order_id": "test_expired_001",
 	}
-	
-	resp, _ := sendPaymentRequest(endpoint, session, oldKey, payload)
+
+	rl.Wait(endpoint.URL)
+	resp, _ := sendPaymentRequest(endpoint, session, oldKey, payload, rl)
 	if resp == nil {
 		return vulns
 	}
-	
+
 	// If server accepts key that's clearly old (> 24h standard window)
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		vulns = append(vulns, models.Vulnerability{
@@ -160,46 +163,50 @@ func testIdempotencyExpiredKeyReuse(endpoint models.Endpoint, session *models.Se
 			Remediation: "Enforce 24-hour expiry window for idempotency keys. Reject keys older than this threshold.",
 		})
 	}
-	
+
 	return vulns
 }
 
 // testIdempotencyMissingKey tests if requests without idempotency key are handled correctly
-func testIdempotencyMissingKey(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testIdempotencyMissingKey(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	payload := map[string]interface{}{
 		"amount":   100,
 		"currency": "USD",
 		"order_id": "test_missing_key_001",
 	}
-	
+
 	// Send request WITHOUT idempotency key header
 	payloadJSON, _ := json.Marshal(payload)
-	client := utils.NewHTTPClient(10 * time.Second)
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
 	req, _ := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBuffer(payloadJSON))
 	req.Header.Set("Content-Type", "application/json")
 	addAuthHeaders(req, session)
 	// NO Idempotency-Key header!
-	
+
+	rl.Wait(endpoint.URL)
 	resp, err := client.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	// Send same request again (should be duplicate)
 	time.Sleep(500 * time.Millisecond)
 	req2, _ := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBuffer(payloadJSON))
 	req2.Header.Set("Content-Type", "application/json")
 	addAuthHeaders(req2, session)
-	
+
+	rl.Wait(endpoint.URL)
 	resp2, err := client.Do(req2)
 	if err != nil {
 		return vulns
 	}
 	defer resp2.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp2)
+
 	// If both succeed, server doesn't enforce idempotency keys
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 && resp2.StatusCode >= 200 && resp2.StatusCode < 300 {
 		vulns = append(vulns, models.Vulnerability{
@@ -221,37 +228,39 @@ func testIdempotencyMissingKey(endpoint models.Endpoint, session *models.Session
 			},
 		})
 	}
-	
+
 	return vulns
 }
 
 // testIdempotencyCaseSensitivity tests if keys are case-sensitive
-func testIdempotencyCaseSensitivity(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testIdempotencyCaseSensitivity(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	baseKey := "IdempotencyKey123ABC"
-	
+
 	payload := map[string]interface{}{
 		"amount":   100,
 		"currency": "USD",
 		"order_id": "test_case_001",
 	}
-	
+
 	// Send with original case
-	resp1, _ := sendPaymentRequest(endpoint, session, baseKey, payload)
+	rl.Wait(endpoint.URL)
+	resp1, _ := sendPaymentRequest(endpoint, session, baseKey, payload, rl)
 	if resp1 == nil || (resp1.StatusCode < 200 || resp1.StatusCode >= 300) {
 		return vulns
 	}
-	
+
 	time.Sleep(500 * time.Millisecond)
-	
+
 	// Send with DIFFERENT case (should be treated as different key)
 	lowerKey := strings.ToLower(baseKey)
-	resp2, _ := sendPaymentRequest(endpoint, session, lowerKey, payload)
+	rl.Wait(endpoint.URL)
+	resp2, _ := sendPaymentRequest(endpoint, session, lowerKey, payload, rl)
 	if resp2 == nil {
 		return vulns
 	}
-	
+
 	// If both succeed, keys are NOT case-sensitive (VULNERABLE)
 	if resp2.StatusCode >= 200 && resp2.StatusCode < 300 {
 		vulns = append(vulns, models.Vulnerability{
@@ -270,45 +279,45 @@ func testIdempotencyCaseSensitivity(endpoint models.Endpoint, session *models.Se
 			Remediation: "Treat idempotency keys as case-sensitive to prevent unintended collisions.",
 		})
 	}
-	
+
 	return vulns
 }
 
 // testIdempotencyRaceCondition tests race on idempotency validation itself
-func testIdempotencyRaceCondition(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testIdempotencyRaceCondition(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Use same key for concurrent requests
 	sharedKey := generateIdempotencyKey()
-	
+
 	payload := map[string]interface{}{
 		"amount":   100,
 		"currency": "USD",
 		"order_id": "test_race_001",
 	}
-	
+
 	concurrency := 5
 	var wg sync.WaitGroup
 	barrier := make(chan struct{})
 	results := make(chan *http.Response, concurrency)
-	
+
 	// Fire concurrent requests with SAME idempotency key
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			<-barrier // Wait at barrier
-			
-			resp, _ := sendPaymentRequest(endpoint, session, sharedKey, payload)
+
+			resp, _ := sendPaymentRequest(endpoint, session, sharedKey, payload, rl)
 			results <- resp
 		}()
 	}
-	
+
 	// Release all simultaneously
 	close(barrier)
 	wg.Wait()
 	close(results)
-	
+
 	// Count successful responses
 	successCount := 0
 	for resp := range results {
@@ -316,7 +325,7 @@ func testIdempotencyRaceCondition(endpoint models.Endpoint, session *models.Sess
 			successCount++
 		}
 	}
-	
+
 	// If more than 1 succeeded, race condition on idempotency check
 	if successCount > 1 {
 		vulns = append(vulns, models.Vulnerability{
@@ -352,7 +361,7 @@ tx.Commit()`,
 			},
 		})
 	}
-	
+
 	return vulns
 }
 
@@ -371,26 +380,29 @@ func generateRandomString(length int) string {
 	return string(result)
 }
 
-func sendPaymentRequest(endpoint models.Endpoint, session *models.Session, idempotencyKey string, payload map[string]interface{}) (*http.Response, string) {
+// sendPaymentRequest does not call rl.Wait itself: testIdempotencyRaceCondition
+// fires it from multiple goroutines released at a shared barrier, and
+// throttling there would serialize the very burst the test depends on.
+// Sequential callers are expected to rl.Wait before calling this.
+func sendPaymentRequest(endpoint models.Endpoint, session *models.Session, idempotencyKey string, payload map[string]interface{}, rl *utils.RateLimiter) (*http.Response, string) {
 	payloadJSON, _ := json.Marshal(payload)
-	
-	client := utils.NewHTTPClient(10 * time.Second)
+
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
 	req, _ := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBuffer(payloadJSON))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Idempotency-Key", idempotencyKey)
 	addAuthHeaders(req, session)
-	
+
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, ""
 	}
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	// Read body for comparison
 	bodyBytes := make([]byte, 4096)
 	n, _ := resp.Body.Read(bodyBytes)
 	resp.Body.Close()
-	
+
 	return resp, string(bodyBytes[:n])
 }
-
-
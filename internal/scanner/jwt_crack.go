@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hmacWorkerCount bounds how many candidate secrets crackHMACSecret checks
+// in parallel, so a 10k-entry wordlist finishes in seconds without
+// spawning thousands of goroutines.
+const hmacWorkerCount = 16
+
+// hmacHashForAlg returns the hash constructor for a JWT "alg" value, or nil
+// if alg isn't one of the HMAC algorithms testJWTWeakSecret can crack
+// (RS256/ES256/none/... all fall through to nil).
+func hmacHashForAlg(alg string) func() hash.Hash {
+	switch alg {
+	case "HS256":
+		return sha256.New
+	case "HS384":
+		return sha512.New384
+	case "HS512":
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// loadJWTSecrets merges the embedded default leaked-secret list with an
+// optional user-supplied wordlist file, one secret per line (blank lines
+// and "#" comments skipped) - the same shape discovery's wordlist loader
+// uses for path brute-force.
+func loadJWTSecrets(path string) []string {
+	secrets := make([]string, len(embeddedJWTSecrets))
+	copy(secrets, embeddedJWTSecrets)
+
+	if path == "" {
+		return secrets
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return secrets
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		secrets = append(secrets, line)
+	}
+
+	return secrets
+}
+
+// crackHMACSecret tries every candidate secret against signingInput under
+// newHash, parallelised across a bounded worker pool, and returns the one
+// that reproduces signature, if any. Feeding does not stop at a match: it
+// returns once every candidate has been handed to a worker, or with no
+// result once maxDuration elapses, so a scan never hangs on a single token.
+func crackHMACSecret(signingInput string, signature []byte, candidates []string, newHash func() hash.Hash, maxDuration time.Duration) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), maxDuration)
+	defer cancel()
+
+	jobs := make(chan string)
+	found := make(chan string, 1)
+	var workers sync.WaitGroup
+
+	for w := 0; w < hmacWorkerCount; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for candidate := range jobs {
+				mac := hmac.New(newHash, []byte(candidate))
+				mac.Write([]byte(signingInput))
+				if hmac.Equal(mac.Sum(nil), signature) {
+					select {
+					case found <- candidate:
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, candidate := range candidates {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- candidate:
+		}
+	}
+	close(jobs)
+
+	go func() {
+		workers.Wait()
+		close(found)
+	}()
+
+	select {
+	case secret, ok := <-found:
+		return secret, ok
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+// redactSecret masks a cracked secret down to its first/last two
+// characters (or fully, if too short) when redact is true, so reports
+// generated for wider sharing don't have to carry the live secret in the
+// clear. The unredacted secret is still exactly what a consumer needs to
+// forge tokens, which is why it's opt-in rather than the default.
+func redactSecret(secret string, redact bool) string {
+	if !redact {
+		return secret
+	}
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:2] + strings.Repeat("*", len(secret)-4) + secret[len(secret)-2:]
+}
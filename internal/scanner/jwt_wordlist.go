@@ -0,0 +1,29 @@
+package scanner
+
+// embeddedJWTSecrets is the built-in default list testJWTWeakSecret brute
+// forces HMAC-signed tokens against - the usual suspects from public
+// "known leaked/default JWT secret" lists (framework defaults, tutorial
+// copy-paste values, and common human-chosen secrets). JWTSecretsWordlist
+// extends this list rather than replacing it.
+var embeddedJWTSecrets = []string{
+	"secret", "Secret", "SECRET", "secret123", "your-256-bit-secret",
+	"jwt_secret", "jwtsecret", "jwt-secret", "jwtSecret", "JWT_SECRET",
+	"changeme", "change-me", "changethis", "password", "password123",
+	"admin", "administrator", "root", "toor", "letmein",
+	"12345", "123456", "1234567890", "qwerty", "abc123",
+	"key", "apikey", "api_key", "private_key", "privatekey",
+	"mysecret", "my-secret", "mysecretkey", "my_secret_key", "supersecret",
+	"super-secret-key", "superSecretKey", "test", "testing", "development",
+	"dev", "development-secret", "production", "prod-secret", "staging",
+	"shhhh", "shh", "s3cr3t", "s3cret", "secretkey",
+	"thisisasecret", "thisismysecretkey", "this-is-a-secret",
+	"your-secret-key", "your_secret_key", "yoursecretkey",
+	"signing-key", "signingkey", "sign_key", "hmac-secret", "hmacsecret",
+	"default", "defaultsecret", "default-secret", "none", "null",
+	"token", "auth_secret", "authsecret", "session_secret", "sessionsecret",
+	"express-session-secret", "cookie-secret", "cookiesecret",
+	"node_jwt_secret", "nodejwtsecret", "flask-secret-key", "django-secret-key",
+	"laravel-secret", "rails-secret", "spring-secret", "springboot-secret",
+	"0123456789abcdef", "abcdefghijklmnop", "aaaaaaaaaaaaaaaa",
+	"11111111", "00000000", "P@ssw0rd", "Passw0rd!", "Welcome1",
+}
@@ -0,0 +1,619 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// macaroonCaveat is one caveat in a macaroon's caveat list. A first-party
+// caveat (the common case - "account = 1234", "time < ...") carries only
+// CID and is checked locally by whoever verifies the macaroon; a
+// third-party caveat additionally carries VID (the encrypted root key for
+// a discharge macaroon) and CL (where to get that discharge from), and can
+// only be satisfied by presenting a matching discharge macaroon alongside
+// the root one.
+type macaroonCaveat struct {
+	CID string
+	VID []byte
+	CL  string
+}
+
+// macaroon is the subset of a parsed macaroon (https://research.google/pubs/pub41892/)
+// TestMacaroonVulnerabilities needs: enough to inspect its caveat list and
+// recompute its signature chain. Capabilities like bindForRequest
+// (discharge binding) aren't modeled - this is a scanner, not a client
+// library.
+type macaroon struct {
+	Location   string
+	Identifier string
+	Caveats    []macaroonCaveat
+	Signature  []byte
+}
+
+// hasThirdPartyCaveats reports whether m has any caveat a discharge
+// macaroon would be needed to satisfy.
+func (m *macaroon) hasThirdPartyCaveats() bool {
+	for _, c := range m.Caveats {
+		if len(c.VID) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TestMacaroonVulnerabilities tests for macaroon caveat-handling issues: a
+// server that doesn't re-verify the signature over the full caveat list
+// will accept one with caveats stripped or truncated, and a guessable root
+// key lets a caveat be appended under a valid signature. It does not test
+// whether third-party caveats are enforced by replaying the root macaroon
+// without its discharges (see the note at the end of this file).
+func TestMacaroonVulnerabilities(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, cfg *models.ScanConfig) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	m := findMacaroonToken(session)
+	if m == nil {
+		return vulns
+	}
+
+	vulns = append(vulns, testMacaroonCaveatStrip(endpoint, session, m, rl)...)
+	vulns = append(vulns, testMacaroonCaveatTruncate(endpoint, session, m, rl)...)
+	vulns = append(vulns, testMacaroonCaveatInjection(endpoint, session, m, rl, cfg)...)
+
+	return vulns
+}
+
+// findMacaroonToken scans session's headers and cookies for a base64/
+// base32 blob that decodes to a v1 or v2 macaroon packet, the same way
+// findJWTToken looks for a three-part JWT. Checked sources: Authorization
+// (bearer or "Macaroon " scheme), any cookie, and SessionToken/URLToken.
+func findMacaroonToken(session *models.Session) *macaroon {
+	candidates := []string{}
+
+	for k, v := range session.Headers {
+		if strings.EqualFold(k, "authorization") {
+			v = strings.TrimPrefix(v, "Bearer ")
+			v = strings.TrimPrefix(v, "Macaroon ")
+			candidates = append(candidates, v)
+		}
+	}
+	for _, v := range session.Cookies {
+		candidates = append(candidates, v)
+	}
+	if session.SessionToken != "" {
+		candidates = append(candidates, session.SessionToken)
+	}
+	if session.URLToken != "" {
+		candidates = append(candidates, session.URLToken)
+	}
+
+	for _, c := range candidates {
+		if m := decodeMacaroon(c); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+// decodeMacaroon tries every encoding a macaroon is commonly transported
+// as (raw bytes, standard/URL base64 with and without padding, base32) and
+// parses whichever one produces a valid v1 or v2 packet.
+func decodeMacaroon(token string) *macaroon {
+	candidates := [][]byte{[]byte(token)}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if decoded, err := enc.DecodeString(token); err == nil {
+			candidates = append(candidates, decoded)
+		}
+	}
+	if decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(token)); err == nil {
+		candidates = append(candidates, decoded)
+	}
+
+	for _, data := range candidates {
+		if len(data) == 0 {
+			continue
+		}
+		if data[0] == 2 {
+			if m, ok := parseMacaroonV2(data); ok {
+				return m
+			}
+		}
+		if m, ok := parseMacaroonV1(data); ok {
+			return m
+		}
+	}
+	return nil
+}
+
+// parseMacaroonV1 parses the line-oriented v1 packet format: each packet is
+// a 4-hex-digit length (of the whole packet, including the length field
+// and trailing newline), a space, "key value", and a trailing "\n".
+func parseMacaroonV1(data []byte) (*macaroon, bool) {
+	m := &macaroon{}
+	var cur *macaroonCaveat
+
+	for len(data) > 0 {
+		key, value, rest, ok := parseV1Packet(data)
+		if !ok {
+			return nil, false
+		}
+		switch key {
+		case "location":
+			if cur == nil {
+				m.Location = value
+			}
+		case "identifier":
+			m.Identifier = value
+		case "cid":
+			m.Caveats = append(m.Caveats, macaroonCaveat{CID: value})
+			cur = &m.Caveats[len(m.Caveats)-1]
+		case "vid":
+			if cur != nil {
+				cur.VID = []byte(value)
+			}
+		case "cl":
+			if cur != nil {
+				cur.CL = value
+			}
+		case "signature":
+			m.Signature = []byte(value)
+		}
+		data = rest
+	}
+
+	if m.Identifier == "" || len(m.Signature) == 0 {
+		return nil, false
+	}
+	return m, true
+}
+
+// parseV1Packet reads one v1 packet off the front of data, returning its
+// key, value, and whatever follows it.
+func parseV1Packet(data []byte) (key, value string, rest []byte, ok bool) {
+	if len(data) < 5 {
+		return "", "", nil, false
+	}
+	size, err := strconv.ParseUint(string(data[:4]), 16, 32)
+	if err != nil || int(size) > len(data) || int(size) < 5 {
+		return "", "", nil, false
+	}
+
+	content := data[4:size]
+	content = bytes.TrimSuffix(content, []byte("\n"))
+
+	parts := bytes.SplitN(content, []byte(" "), 2)
+	if len(parts) != 2 {
+		return "", "", nil, false
+	}
+	return string(parts[0]), string(parts[1]), data[size:], true
+}
+
+// v2 field-type tags, from the macaroon v2 binary format.
+const (
+	v2FieldEOS        = 0x00
+	v2FieldLocation   = 0x01
+	v2FieldIdentifier = 0x02
+	v2FieldVID        = 0x03
+	v2SignatureLen    = 32
+)
+
+// parseMacaroonV2 parses the field-tagged v2 binary format: a version
+// byte, a location section, an identifier section, a caveat-packet list
+// (each caveat itself a field-tagged group), each section/group
+// terminated by v2FieldEOS, followed by a raw (untagged, unlength-prefixed)
+// 32-byte signature. This covers the common shape of macaroons issued by
+// real libmacaroons implementations; it doesn't attempt namespace packets
+// or any v2 extension fields not listed above.
+func parseMacaroonV2(data []byte) (*macaroon, bool) {
+	if len(data) < 1 || data[0] != 2 {
+		return nil, false
+	}
+	data = data[1:]
+	m := &macaroon{}
+
+	// Location section (optional fields, EOS-terminated).
+	var ok bool
+	data, ok = skipV2Section(data)
+	if !ok {
+		return nil, false
+	}
+
+	// Identifier section.
+	data, m.Identifier, ok = readV2TaggedSection(data, v2FieldIdentifier)
+	if !ok {
+		return nil, false
+	}
+
+	// Caveats: a list of field-tagged groups, terminated by an extra EOS.
+	for {
+		if len(data) == 0 {
+			return nil, false
+		}
+		if data[0] == v2FieldEOS {
+			data = data[1:]
+			break
+		}
+
+		var cav macaroonCaveat
+		for {
+			if len(data) < 1 {
+				return nil, false
+			}
+			tag := data[0]
+			if tag == v2FieldEOS {
+				data = data[1:]
+				break
+			}
+			length, n, ok := readV2Varint(data[1:])
+			if !ok || 1+n+int(length) > len(data) {
+				return nil, false
+			}
+			value := data[1+n : 1+n+int(length)]
+			data = data[1+n+int(length):]
+			switch tag {
+			case v2FieldLocation:
+				cav.CL = string(value)
+			case v2FieldIdentifier:
+				cav.CID = string(value)
+			case v2FieldVID:
+				cav.VID = append([]byte{}, value...)
+			}
+		}
+		m.Caveats = append(m.Caveats, cav)
+	}
+
+	if len(data) < v2SignatureLen {
+		return nil, false
+	}
+	m.Signature = data[len(data)-v2SignatureLen:]
+
+	if m.Identifier == "" {
+		return nil, false
+	}
+	return m, true
+}
+
+// skipV2Section consumes one EOS-terminated, field-tagged section without
+// keeping its contents (used for the location section, which
+// TestMacaroonVulnerabilities doesn't need).
+func skipV2Section(data []byte) ([]byte, bool) {
+	for {
+		if len(data) < 1 {
+			return nil, false
+		}
+		if data[0] == v2FieldEOS {
+			return data[1:], true
+		}
+		length, n, ok := readV2Varint(data[1:])
+		if !ok || 1+n+int(length) > len(data) {
+			return nil, false
+		}
+		data = data[1+n+int(length):]
+	}
+}
+
+// readV2TaggedSection reads a single wantTag field out of an
+// EOS-terminated section, skipping any other fields present.
+func readV2TaggedSection(data []byte, wantTag byte) ([]byte, string, bool) {
+	value := ""
+	for {
+		if len(data) < 1 {
+			return nil, "", false
+		}
+		if data[0] == v2FieldEOS {
+			return data[1:], value, true
+		}
+		length, n, ok := readV2Varint(data[1:])
+		if !ok || 1+n+int(length) > len(data) {
+			return nil, "", false
+		}
+		if data[0] == wantTag {
+			value = string(data[1+n : 1+n+int(length)])
+		}
+		data = data[1+n+int(length):]
+	}
+}
+
+// readV2Varint decodes an unsigned LEB128 varint (the v2 format's field
+// length encoding) from the front of data, returning its value and how
+// many bytes it occupied.
+func readV2Varint(data []byte) (value uint64, n int, ok bool) {
+	var shift uint
+	for i, b := range data {
+		if i >= 10 {
+			return 0, 0, false
+		}
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value, i + 1, true
+		}
+		shift += 7
+	}
+	return 0, 0, false
+}
+
+// hmacSHA256 is the building block of the macaroon signature chain:
+// sig_0 = HMAC-SHA256(rootKey, identifier), and each subsequent caveat
+// folds in with sig_i = HMAC-SHA256(sig_{i-1}, caveatData).
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// macaroonSignatureChain recomputes a macaroon's signature from rootKey,
+// its identifier, and its (possibly just-mutated) caveat list - first-party
+// caveats fold in their CID alone; third-party caveats fold in VID||CID.
+// This intentionally skips libmacaroons' key-derivation step before sig_0
+// (generate_derived_key), so it's only exact when rootKey is already in
+// derived form; treated here as "good enough to confirm a guessed key from
+// a wordlist actually reproduces the signature we observed".
+func macaroonSignatureChain(rootKey []byte, identifier string, caveats []macaroonCaveat) []byte {
+	sig := hmacSHA256(rootKey, []byte(identifier))
+	for _, c := range caveats {
+		if len(c.VID) == 0 {
+			sig = hmacSHA256(sig, []byte(c.CID))
+			continue
+		}
+		data := append(append([]byte{}, c.VID...), []byte(c.CID)...)
+		sig = hmacSHA256(sig, data)
+	}
+	return sig
+}
+
+// crackMacaroonRootKey brute-forces m's root key against candidates by
+// recomputing the full signature chain for each one, parallelized and
+// time-bounded the same way crackHMACSecret is for JWTs.
+func crackMacaroonRootKey(m *macaroon, candidates []string, maxDuration time.Duration) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), maxDuration)
+	defer cancel()
+
+	jobs := make(chan string)
+	found := make(chan string, 1)
+	var workers sync.WaitGroup
+
+	for w := 0; w < hmacWorkerCount; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for candidate := range jobs {
+				sig := macaroonSignatureChain([]byte(candidate), m.Identifier, m.Caveats)
+				if hmac.Equal(sig, m.Signature) {
+					select {
+					case found <- candidate:
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, candidate := range candidates {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- candidate:
+		}
+	}
+	close(jobs)
+
+	go func() {
+		workers.Wait()
+		close(found)
+	}()
+
+	select {
+	case secret, ok := <-found:
+		return secret, ok
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+// encodeMacaroonV1 re-serializes m as a v1 packet stream, for replaying a
+// mutated macaroon (stripped/truncated/injected caveats) against endpoint.
+func encodeMacaroonV1(m *macaroon) []byte {
+	var buf bytes.Buffer
+	writeV1Packet(&buf, "location", m.Location)
+	writeV1Packet(&buf, "identifier", m.Identifier)
+	for _, c := range m.Caveats {
+		writeV1Packet(&buf, "cid", c.CID)
+		if len(c.VID) > 0 {
+			writeV1Packet(&buf, "vid", string(c.VID))
+			writeV1Packet(&buf, "cl", c.CL)
+		}
+	}
+	writeV1Packet(&buf, "signature", string(m.Signature))
+	return buf.Bytes()
+}
+
+func writeV1Packet(buf *bytes.Buffer, key, value string) {
+	content := key + " " + value + "\n"
+	size := len(content) + 4
+	fmt.Fprintf(buf, "%04x%s", size, content)
+}
+
+// sendMacaroon replays m (base64url-encoded, matching how it's usually
+// transported) against endpoint as a bearer credential and returns the
+// response.
+func sendMacaroon(client *http.Client, endpoint models.Endpoint, rl *utils.RateLimiter, m *macaroon) (*http.Response, error) {
+	token := base64.RawURLEncoding.EncodeToString(encodeMacaroonV1(m))
+
+	req, err := http.NewRequest(endpoint.Method, endpoint.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rl.Wait(endpoint.URL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+	return resp, nil
+}
+
+// testMacaroonCaveatStrip drops every third-party caveat from a copy of m
+// and replays it unmodified otherwise - the original signature was
+// computed over the full caveat list, so a server that doesn't re-verify
+// the signature over whatever caveat list actually arrived will accept a
+// token that's had its delegation/restriction caveats quietly removed.
+func testMacaroonCaveatStrip(endpoint models.Endpoint, session *models.Session, m *macaroon, rl *utils.RateLimiter) []models.Vulnerability {
+	if !m.hasThirdPartyCaveats() {
+		return nil
+	}
+
+	stripped := *m
+	stripped.Caveats = nil
+	for _, c := range m.Caveats {
+		if len(c.VID) == 0 {
+			stripped.Caveats = append(stripped.Caveats, c)
+		}
+	}
+
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+	resp, err := sendMacaroon(client, endpoint, rl, &stripped)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil
+	}
+
+	return []models.Vulnerability{{
+		Type:        "Macaroon Caveat Stripping",
+		Severity:    "CRITICAL",
+		Title:       "Third-Party Caveats Can Be Stripped From Macaroon",
+		Description: "Removing every third-party caveat from the macaroon (while keeping its original signature) was still accepted, meaning the server doesn't re-verify the signature against the caveat list it actually received.",
+		Endpoint:    endpoint.URL,
+		Method:      endpoint.Method,
+		Proof:       fmt.Sprintf("Replayed macaroon with %d of %d caveats removed, server responded %d", len(m.Caveats)-len(stripped.Caveats), len(m.Caveats), resp.StatusCode),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-347",
+		Confidence:  "High",
+		Impact:      "Delegation and restriction caveats (scope, expiry, resource limits) can be bypassed entirely.",
+		Remediation: "Recompute the macaroon's HMAC chain over exactly the caveat list presented before trusting any of it.",
+		References: []string{
+			"https://research.google/pubs/pub41892/",
+			"https://cwe.mitre.org/data/definitions/347.html",
+		},
+	}}
+}
+
+// testMacaroonCaveatTruncate replays m with its entire caveat list emptied
+// (but its original signature kept) - the maximal version of the stripping
+// attack above, useful even when none of the caveats are third-party.
+func testMacaroonCaveatTruncate(endpoint models.Endpoint, session *models.Session, m *macaroon, rl *utils.RateLimiter) []models.Vulnerability {
+	if len(m.Caveats) == 0 {
+		return nil
+	}
+
+	truncated := *m
+	truncated.Caveats = nil
+
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+	resp, err := sendMacaroon(client, endpoint, rl, &truncated)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil
+	}
+
+	return []models.Vulnerability{{
+		Type:        "Macaroon Caveat Truncation",
+		Severity:    "CRITICAL",
+		Title:       "Macaroon Caveat List Can Be Truncated To Empty",
+		Description: "Replaying the macaroon with every caveat removed was still accepted.",
+		Endpoint:    endpoint.URL,
+		Method:      endpoint.Method,
+		Proof:       fmt.Sprintf("Replayed macaroon with all %d caveats removed, server responded %d", len(m.Caveats), resp.StatusCode),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-347",
+		Confidence:  "High",
+		Impact:      "Every restriction the macaroon was issued with (scope, amount, expiry) can be bypassed.",
+		Remediation: "Recompute the macaroon's HMAC chain over exactly the caveat list presented before trusting any of it.",
+		References: []string{
+			"https://research.google/pubs/pub41892/",
+			"https://cwe.mitre.org/data/definitions/347.html",
+		},
+	}}
+}
+
+// testMacaroonCaveatInjection only runs if the root key can be recovered
+// from the JWT weak-secret wordlist (same infrastructure testJWTWeakSecret
+// uses): with the root key in hand, it appends a broader first-party
+// caveat ("account = *" or "amount < 999999999"), recomputes a valid
+// signature chain, and replays - demonstrating that a guessable root key
+// lets an attacker mint arbitrarily permissive macaroons, not just forge
+// one that happens to already exist.
+func testMacaroonCaveatInjection(endpoint models.Endpoint, session *models.Session, m *macaroon, rl *utils.RateLimiter, cfg *models.ScanConfig) []models.Vulnerability {
+	wordlistPath, maxDuration := "", 10*time.Second
+	if cfg != nil {
+		wordlistPath = cfg.JWTSecretsWordlist
+		if cfg.JWTMaxCrackDuration > 0 {
+			maxDuration = cfg.JWTMaxCrackDuration
+		}
+	}
+	candidates := loadJWTSecrets(wordlistPath)
+
+	rootKey, cracked := crackMacaroonRootKey(m, candidates, maxDuration)
+	if !cracked {
+		return nil
+	}
+
+	for _, injected := range []string{"account = *", "amount < 999999999"} {
+		mutated := *m
+		mutated.Caveats = append(append([]macaroonCaveat{}, m.Caveats...), macaroonCaveat{CID: injected})
+		mutated.Signature = macaroonSignatureChain([]byte(rootKey), mutated.Identifier, mutated.Caveats)
+
+		client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+		resp, err := sendMacaroon(client, endpoint, rl, &mutated)
+		if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			continue
+		}
+
+		return []models.Vulnerability{{
+			Type:        "Macaroon Caveat Injection",
+			Severity:    "CRITICAL",
+			Title:       "Macaroon Root Key Is Guessable, Enabling Caveat Injection",
+			Description: fmt.Sprintf("The macaroon's root key was recovered from a wordlist of known/default secrets, then used to mint a valid signature for the same macaroon with an additional %q caveat appended.", injected),
+			Endpoint:    endpoint.URL,
+			Method:      endpoint.Method,
+			Proof:       fmt.Sprintf("Recovered root key %s, injected caveat %q, server responded %d", redactSecret(rootKey, cfg != nil && cfg.JWTRedactSecrets), injected, resp.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-798",
+			Confidence:  "High",
+			Impact:      "Complete authorization bypass - an attacker can mint macaroons with any caveats they like.",
+			Remediation: "Use a long, randomly generated root key (>= 256 bits) drawn from a CSPRNG, never one from a wordlist, framework default, or tutorial.",
+			References: []string{
+				"https://research.google/pubs/pub41892/",
+				"https://cwe.mitre.org/data/definitions/798.html",
+			},
+		}}
+	}
+
+	return nil
+}
+
+// A fifth sub-test, "missing discharge enforcement" (replay the root
+// macaroon with no discharges attached), was dropped: this codebase never
+// captures or attaches a discharge macaroon anywhere - findMacaroonToken
+// extracts a single root macaroon from session, and nothing else threads
+// a second token alongside it on the wire. That makes "replay without the
+// discharge" byte-for-byte identical to the request the scan already
+// authenticated with, so it would fire on every endpoint with a
+// third-party caveat regardless of whether the server enforces discharges
+// correctly - a guaranteed false positive, not a real test. Reintroducing
+// it needs session/models support for a distinct discharge macaroon to
+// actually omit.
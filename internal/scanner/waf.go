@@ -1,132 +1,171 @@
-```go
 package scanner
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"github.com/SpaceLeam/web-Payment-scanner/internal/scanner/fingerprints"
 	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
 )
 
-// DetectWAF checks if a WAF is protecting the target
-func DetectWAF(targetURL string) string {
-	client := utils.NewHTTPClient(10 * time.Second)
-	
-	// Payloads that typically trigger WAFs
-	testPayloads := []string{
-		"../../etc/passwd",
-		"<script>alert(1)</script>",
-		"' OR 1=1--",
-		"SELECT * FROM users",
+// RateFloorForWAF returns the conservative requests/sec floor to use once a
+// given WAF has been fingerprinted, based on publicly documented rate
+// thresholds for each vendor. Unrecognized/"None Detected" values fall back
+// to a generic floor, since an unidentified WAF still warrants caution.
+func RateFloorForWAF(waf string) int {
+	switch waf {
+	case "Cloudflare":
+		return 2
+	case "Akamai":
+		return 1
+	case "AWS WAF", "Imperva":
+		return 3
+	default:
+		return 3
+	}
+}
+
+// WAFFingerprint describes one vendor match from WAFDetector.Detect - it
+// replaces the bare vendor-name string DetectWAF used to return, so
+// downstream evasion logic (EvasionContext) can key off Confidence and
+// MatchedRules instead of just a name.
+type WAFFingerprint struct {
+	Name         string
+	Vendor       string
+	Confidence   int
+	MatchedRules []string
+}
+
+// wafProbePayloads are appended to the target URL's query string to elicit
+// a WAF response - the same small set DetectWAF always sent.
+var wafProbePayloads = []string{
+	"../../etc/passwd",
+	"<script>alert(1)</script>",
+	"' OR 1=1--",
+	"SELECT * FROM users",
+}
+
+// WAFDetector fingerprints which WAF/CDN is protecting a target by
+// replaying wafProbePayloads and matching the responses (headers, cookies,
+// body, status code) against a loadable fingerprint database, rather than
+// the handful of hardcoded header checks DetectWAF used to do.
+type WAFDetector struct {
+	rules  []fingerprints.WAFFingerprintRule
+	client *http.Client
+}
+
+// NewWAFDetector loads the WAF fingerprint database from path, falling
+// back to fingerprints.DefaultWAFFingerprints when the file is missing or
+// fails to parse.
+func NewWAFDetector(path string) *WAFDetector {
+	return &WAFDetector{
+		rules:  fingerprints.LoadWAF(path),
+		client: utils.NewHTTPClient(10 * time.Second),
 	}
-	
-	for _, payload := range testPayloads {
-		// Append payload to URL query
-		url := targetURL
+}
+
+// Reload re-reads the fingerprint database from path, letting a long-
+// running scan pick up edits to the rule file without restarting.
+func (d *WAFDetector) Reload(path string) {
+	d.rules = fingerprints.LoadWAF(path)
+}
+
+// Detect probes targetURL and returns the highest-confidence fingerprint
+// match across all probe payloads, or nil if no rule matched.
+func (d *WAFDetector) Detect(targetURL string) *WAFFingerprint {
+	var best *WAFFingerprint
+
+	for _, payload := range wafProbePayloads {
+		probeURL := targetURL
 		if strings.Contains(targetURL, "?") {
-			url += "&test=" + payload
+			probeURL += "&test=" + payload
 		} else {
-			url += "?test=" + payload
+			probeURL += "?test=" + payload
+		}
+
+		req, err := http.NewRequest("GET", probeURL, nil)
+		if err != nil {
+			continue
 		}
-		
-		req, _ := http.NewRequest("GET", url, nil)
 		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-		
-		resp, err := client.Do(req)
+
+		resp, err := d.client.Do(req)
 		if err != nil {
 			continue
 		}
-		defer resp.Body.Close()
-		
-		// 1. Check Headers
-		headers := resp.Header
-		
-		// Cloudflare
-		if headers.Get("cf-ray") != "" || headers.Get("__cfduid") != "" || headers.Get("server") == "cloudflare" {
-			return "Cloudflare"
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+
+		if fp := d.match(resp, string(body)); fp != nil && (best == nil || fp.Confidence > best.Confidence) {
+			best = fp
 		}
-		
-		// AWS WAF
-		if headers.Get("x-amzn-requestid") != "" || headers.Get("x-amz-cf-id") != "" {
-			return "AWS WAF"
+	}
+
+	return best
+}
+
+// match checks resp/body against every loaded rule and returns the
+// fingerprint for the first rule with at least one matching signal.
+func (d *WAFDetector) match(resp *http.Response, body string) *WAFFingerprint {
+	for _, rule := range d.rules {
+		var matched []string
+
+		for _, hr := range rule.HeaderRules {
+			if hr.Regex.MatchString(resp.Header.Get(hr.Header)) {
+				matched = append(matched, fmt.Sprintf("header:%s", hr.Header))
+			}
 		}
-		
-		// Akamai
-		if strings.Contains(headers.Get("server"), "AkamaiGHost") {
-			return "Akamai"
+		if cookie := resp.Header.Get("Set-Cookie"); cookie != "" {
+			for _, re := range rule.CookieRules {
+				if re.MatchString(cookie) {
+					matched = append(matched, "cookie")
+				}
+			}
 		}
-		
-		// Imperva
-		if headers.Get("x-iinfo") != "" || strings.Contains(headers.Get("server"), "Imperva") {
-			return "Imperva"
+		for _, re := range rule.BodyRules {
+			if re.MatchString(body) {
+				matched = append(matched, "body")
+			}
 		}
-		
-		// 2. Check Status Codes & Body
-		if resp.StatusCode == 403 || resp.StatusCode == 406 || resp.StatusCode == 429 {
-			// Read a bit of body to check for signatures
-			// (Assuming ReadResponseBody is not available or we just read directly)
-			// For simplicity, we won't read body here to avoid complexity with closing/reading
-			// But usually WAFs return specific pages
-			
-			// Simple header check for now is safer than reading body if we don't have a helper handy
-			// But let's check Server header again
+		if containsStatus(rule.StatusCodes, resp.StatusCode) {
+			matched = append(matched, fmt.Sprintf("status:%d", resp.StatusCode))
 		}
-	}
-	
-	return "None Detected"
-}
-
-// AdaptiveEvasion applies evasion techniques when WAF is detected
-type EvasionContext struct {
-	CaseVariation bool
-	URLEncoding   bool
-	VerbTampering bool
-	SlowDown      bool
-	RateLimited   int // Count of 429/403 responses
-}
 
-// ApplyEvasion modifies request to evade WAF
-func (ec *EvasionContext) ApplyEvasion(req *http.Request, payload string) string {
-	if ec.CaseVariation {
-		payload = applyCaseVariation(payload)
-	}
-	
-	if ec.URLEncoding {
-		payload = applyDoubleEncoding(payload)
-	}
-	
-	if ec.VerbTampering && req.Method == "POST" {
-		req.Method = "PUT" // Try alternative verb
+		if len(matched) > 0 {
+			return &WAFFingerprint{
+				Name:         rule.Name,
+				Vendor:       rule.Vendor,
+				Confidence:   rule.Confidence,
+				MatchedRules: matched,
+			}
+		}
 	}
-	
-	return payload
+	return nil
 }
 
-// CheckRateLimiting detects if response indicates rate limiting
-func (ec *EvasionContext) CheckRateLimiting(statusCode int) bool {
-	if statusCode == 429 || statusCode == 403 {
-		ec.RateLimited++
-		ec.SlowDown = true
-		return true
+func containsStatus(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
 	}
 	return false
 }
 
-// GetDelay returns adaptive delay based on rate limiting
-func (ec *EvasionContext) GetDelay() time.Duration {
-	if ec.RateLimited == 0 {
-		return 0
-	}
-	
-	// Exponential backoff: 1s, 2s, 4s, 8s (max)
-	delay := time.Duration(1<<uint(ec.RateLimited-1)) * time.Second
-	if delay > 8*time.Second {
-		delay = 8 * time.Second
+// DetectWAF is a convenience wrapper over WAFDetector.Detect that returns
+// just the vendor name, kept so existing callers (engine.go's WAF check,
+// whose result feeds RateFloorForWAF) don't need to change to use the richer
+// WAFFingerprint.
+func DetectWAF(targetURL string) string {
+	fp := NewWAFDetector(fingerprints.DefaultWAFFingerprintsPath).Detect(targetURL)
+	if fp == nil {
+		return "None Detected"
 	}
-	return delay
+	return fp.Name
 }
 
 func applyCaseVariation(s string) string {
@@ -0,0 +1,30 @@
+package scanner
+
+import (
+	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/scanner/wsactive"
+)
+
+// TestWebSocketActive runs wsactive's battery of active probes
+// (missing-auth, CSWSH, oversized/malformed frames, IDOR, subprotocol
+// downgrade) against session's WebSocket URL, using the most recent "sent"
+// payment message wsi captured as the template frame several probes replay
+// or mutate. Unlike TestWebSocketRaceCondition/Replay/AmountManipulation,
+// this doesn't go through the browser page at all - wsactive dials its own
+// connection, so it still runs useful checks (missing auth, CSWSH) that
+// need a connection the browser's own session *isn't* attached to.
+func TestWebSocketActive(session *models.Session, wsi *browser.WSInterceptor) []models.Vulnerability {
+	if session == nil || session.WebSocketURL == "" {
+		return nil
+	}
+
+	var template []byte
+	for _, msg := range wsi.GetPaymentMessages() {
+		if msg.Direction == "sent" {
+			template = []byte(msg.Data)
+		}
+	}
+
+	return wsactive.RunActiveTests(session.WebSocketURL, session, template)
+}
@@ -11,193 +11,345 @@ import (
 	"github.com/playwright-community/playwright-go"
 )
 
-// TestWebSocketRaceCondition tests race conditions via WebSocket
+// wsOutcomeWindow is how long each test waits for outcome frames after
+// sending its payload(s), since a race/replay/manipulation response can
+// arrive after other interleaved traffic rather than as the very next
+// frame.
+const wsOutcomeWindow = 3 * time.Second
+
+// wsCorrelationFields are the field names checked, in order, to find a
+// message's correlation id - whichever is present first is used to build
+// the Subscribe predicate that picks its outcome frames back out of the
+// rest of the connection's traffic.
+var wsCorrelationFields = []string{"order_id", "payment_id", "request_id"}
+
+// wsAmountFields are the field names checked, in priority order, to find
+// a sent payment message's amount.
+var wsAmountFields = []string{"amount", "price", "total", "value"}
+
+// wsTestAmounts are the manipulated values tried against whichever
+// wsAmountFields entry a message has.
+var wsTestAmounts = []interface{}{-100.0, 0.0, 0.01, "0"}
+
+// TestWebSocketRaceCondition tests for a race condition by firing the same
+// payment message concurrently and checking the invariant "at most one
+// success per correlation id" across every outcome frame the server sends
+// back, not just the last message seen.
 func TestWebSocketRaceCondition(page playwright.Page, wsi *browser.WSInterceptor, concurrency int) []models.Vulnerability {
 	vulns := make([]models.Vulnerability, 0)
-	
-	// Get last payment message as template
-	paymentMsgs := wsi.GetPaymentMessages()
-	if len(paymentMsgs) == 0 {
+
+	templateMsg := lastSentPaymentMessage(wsi)
+	if templateMsg == nil {
 		return vulns
 	}
-	
-	// Use last sent payment message
-	var templateMsg *browser.WSMessage
-	for i := len(paymentMsgs) - 1; i >= 0; i-- {
-		if paymentMsgs[i].Direction == "sent" {
-			templateMsg = &paymentMsgs[i]
-			break
-		}
-	}
-	
-	if templateMsg == nil {
+
+	field, value, ok := wsCorrelationID(*templateMsg)
+	if !ok {
 		return vulns
 	}
-	
-	// Fire concurrent WebSocket messages
+
+	sub := wsi.Subscribe(wsOutcomePredicate(field, value))
+	defer sub.Unsubscribe()
+
 	var wg sync.WaitGroup
 	startSignal := make(chan struct{})
-	results := make(chan bool, concurrency)
-	
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			<-startSignal
-			
-			// Send message via WS
-			success := sendWSMessage(page, templateMsg.Data)
-			results <- success
+			sendWSMessage(page, templateMsg.Data)
 		}()
 	}
-	
 	close(startSignal)
 	wg.Wait()
-	close(results)
-	
-	// Analyze
+
+	outcomes := collectWSOutcomes(sub, wsOutcomeWindow)
+
 	successCount := 0
-	for success := range results {
-		if success {
+	for _, msg := range outcomes {
+		if isSuccessResponse(msg) {
 			successCount++
 		}
 	}
-	
+
 	if successCount > 1 {
 		vulns = append(vulns, models.Vulnerability{
 			Type:        "WebSocket Race Condition",
 			Severity:    "CRITICAL",
 			Title:       "WebSocket Race Condition",
-			Description: fmt.Sprintf("WebSocket accepted %d concurrent identical messages", successCount),
-			Proof:       fmt.Sprintf("Template: %s", templateMsg.Data),
+			Description: fmt.Sprintf("%d of %d concurrent identical messages (correlation %s=%s) were all confirmed successful, violating the at-most-one-success invariant.", successCount, concurrency, field, value),
+			Proof:       fmt.Sprintf("Template: %s, %d outcome frame(s) observed within %v", templateMsg.Data, len(outcomes), wsOutcomeWindow),
 			Timestamp:   time.Now(),
 		})
 	}
-	
+
 	return vulns
 }
 
-func sendWSMessage(page playwright.Page, data string) bool {
-	result, err := page.Evaluate(fmt.Sprintf(`() => {
-		if (!window._ws || window._ws.readyState !== 1) {
-			return false;
+// TestWebSocketReplay tests message replay attacks: every sent payment
+// message is resent unmodified after a delay, and the invariant "replay
+// yields an error or duplicate-rejection code" is checked across every
+// outcome frame observed for that message's correlation id.
+func TestWebSocketReplay(page playwright.Page, wsi *browser.WSInterceptor) []models.Vulnerability {
+	vulns := make([]models.Vulnerability, 0)
+
+	for _, msg := range wsi.GetPaymentMessages() {
+		if msg.Direction != "sent" {
+			continue
 		}
-		
-		try {
-			window._ws.send('%s');
-			return true;
-		} catch(e) {
-			return false;
+
+		field, value, ok := wsCorrelationID(msg)
+		if !ok {
+			continue
+		}
+
+		sub := wsi.Subscribe(wsOutcomePredicate(field, value))
+
+		time.Sleep(2 * time.Second) // Delay to simulate replay
+		if !sendWSMessage(page, msg.Data) {
+			sub.Unsubscribe()
+			continue
+		}
+
+		outcomes := collectWSOutcomes(sub, wsOutcomeWindow)
+		sub.Unsubscribe()
+
+		if replayAccepted(outcomes) {
+			vulns = append(vulns, models.Vulnerability{
+				Type:        "WebSocket Replay Attack",
+				Severity:    "HIGH",
+				Title:       "WebSocket Message Replay Vulnerability",
+				Description: "Server confirmed a replayed WebSocket message instead of returning an error or duplicate-rejection code.",
+				Proof:       fmt.Sprintf("Original: %s, %d outcome frame(s) observed within %v", msg.Data, len(outcomes), wsOutcomeWindow),
+				Timestamp:   time.Now(),
+			})
 		}
-	}`, escapeJS(data)))
-	
-	if err != nil {
-		return false
-	}
-	
-	if success, ok := result.(bool); ok {
-		return success
 	}
-	
-	return false
+
+	return vulns
 }
 
-// TestWebSocketReplay tests message replay attacks
-func TestWebSocketReplay(page playwright.Page, wsi *browser.WSInterceptor) []models.Vulnerability {
+// TestWebSocketAmountManipulation tests amount manipulation in WS: each
+// sent payment message's amount-like field is replaced with a suspicious
+// value, and the invariant "amount in response matches amount in request"
+// is checked across every outcome frame observed for that message's
+// correlation id - a bare success response that doesn't also echo the
+// manipulated amount back isn't enough to flag.
+func TestWebSocketAmountManipulation(page playwright.Page, wsi *browser.WSInterceptor) []models.Vulnerability {
 	vulns := make([]models.Vulnerability, 0)
-	
-	paymentMsgs := wsi.GetPaymentMessages()
-	
-	for _, msg := range paymentMsgs {
-		if msg.Direction == "sent" {
-			// Replay the message
-			time.Sleep(2 * time.Second) // Delay to simulate replay
-			
-			success := sendWSMessage(page, msg.Data)
-			if success {
-				// Check if server accepted it (by monitoring responses)
-				time.Sleep(1 * time.Second)
-				
-				newMsgs := wsi.GetMessages()
-				lastMsg := newMsgs[len(newMsgs)-1]
-				
-				// If we got a success response
-				if lastMsg.Direction == "received" && isSuccessResponse(lastMsg) {
-					vulns = append(vulns, models.Vulnerability{
-						Type:        "WebSocket Replay Attack",
-						Severity:    "HIGH",
-						Title:       "WebSocket Message Replay Vulnerability",
-						Description: "Server accepted replayed WebSocket message",
-						Proof:       fmt.Sprintf("Original: %s", msg.Data),
-						Timestamp:   time.Now(),
-					})
-				}
+
+	for _, msg := range wsi.GetPaymentMessages() {
+		if msg.Direction != "sent" || msg.Parsed == nil {
+			continue
+		}
+
+		field, value, ok := wsCorrelationID(msg)
+		if !ok {
+			continue
+		}
+
+		amountField, originalAmount, ok := wsAmountField(msg.Parsed)
+		if !ok {
+			continue
+		}
+
+		for _, testAmount := range wsTestAmounts {
+			manipulated := cloneMap(msg.Parsed)
+			manipulated[amountField] = testAmount
+			manipulatedJSON, _ := json.Marshal(manipulated)
+
+			sub := wsi.Subscribe(wsOutcomePredicate(field, value))
+			sent := sendWSMessage(page, string(manipulatedJSON))
+			if !sent {
+				sub.Unsubscribe()
+				continue
+			}
+
+			outcomes := collectWSOutcomes(sub, wsOutcomeWindow)
+			sub.Unsubscribe()
+
+			if echoed, ok := wsAcceptedManipulatedAmount(outcomes, amountField, testAmount); ok {
+				vulns = append(vulns, models.Vulnerability{
+					Type:        "WebSocket Amount Manipulation",
+					Severity:    "CRITICAL",
+					Title:       fmt.Sprintf("Amount Manipulation Accepted (%v → %v)", originalAmount, testAmount),
+					Description: "Server confirmed a manipulated amount via WebSocket, echoing it back in a success response instead of rejecting or correcting it.",
+					Proof:       fmt.Sprintf("Manipulated message: %s, server echoed %s=%v", string(manipulatedJSON), amountField, echoed),
+					Timestamp:   time.Now(),
+				})
+				break
 			}
 		}
 	}
-	
+
 	return vulns
 }
 
-// TestWebSocketAmountManipulation tests amount manipulation in WS
-func TestWebSocketAmountManipulation(page playwright.Page, wsi *browser.WSInterceptor) []models.Vulnerability {
-	vulns := make([]models.Vulnerability, 0)
-	
+// lastSentPaymentMessage returns the most recently captured sent
+// payment-related message, to use as a test's template, or nil if none
+// has been seen yet.
+func lastSentPaymentMessage(wsi *browser.WSInterceptor) *browser.WSMessage {
 	paymentMsgs := wsi.GetPaymentMessages()
-	
-	for _, msg := range paymentMsgs {
-		if msg.Direction == "sent" && msg.Parsed != nil {
-			// Find amount field
-			amountFields := []string{"amount", "price", "total", "value"}
-			
-			for _, field := range amountFields {
-				if originalAmount, ok := msg.Parsed[field]; ok {
-					// Test manipulated amounts
-					testAmounts := []interface{}{
-						-100.0,
-						0.0,
-						0.01,
-						"0",
-					}
-					
-					for _, testAmount := range testAmounts {
-						// Clone and modify
-						manipulated := cloneMap(msg.Parsed)
-						manipulated[field] = testAmount
-						
-						manipulatedJSON, _ := json.Marshal(manipulated)
-						success := sendWSMessage(page, string(manipulatedJSON))
-						
-						if success {
-							// Wait for response
-							time.Sleep(1 * time.Second)
-							
-							newMsgs := wsi.GetMessages()
-							if len(newMsgs) > 0 {
-								lastMsg := newMsgs[len(newMsgs)-1]
-								
-								if lastMsg.Direction == "received" && isSuccessResponse(lastMsg) {
-									vulns = append(vulns, models.Vulnerability{
-										Type:        "WebSocket Amount Manipulation",
-										Severity:    "CRITICAL",
-										Title:       fmt.Sprintf("Amount Manipulation Accepted (%v → %v)", originalAmount, testAmount),
-										Description: "Server accepted manipulated amount via WebSocket",
-										Proof:       fmt.Sprintf("Manipulated message: %s", string(manipulatedJSON)),
-										Timestamp:   time.Now(),
-									})
-									break
-								}
-							}
-						}
-					}
-					
-					break
+	for i := len(paymentMsgs) - 1; i >= 0; i-- {
+		if paymentMsgs[i].Direction == "sent" {
+			return &paymentMsgs[i]
+		}
+	}
+	return nil
+}
+
+// wsCorrelationID looks up msg.Parsed for the first of wsCorrelationFields
+// present, returning the field name and its value stringified for
+// predicate matching.
+func wsCorrelationID(msg browser.WSMessage) (field, value string, ok bool) {
+	if msg.Parsed == nil {
+		return "", "", false
+	}
+	for _, f := range wsCorrelationFields {
+		if v, present := msg.Parsed[f]; present {
+			return f, fmt.Sprintf("%v", v), true
+		}
+	}
+	return "", "", false
+}
+
+// wsOutcomePredicate builds a Subscribe predicate matching received
+// messages whose Parsed[field] stringifies to value - i.e. every frame
+// that's part of the outcome for one particular correlation id, regardless
+// of what else is interleaved on the connection.
+func wsOutcomePredicate(field, value string) func(browser.WSMessage) bool {
+	return func(msg browser.WSMessage) bool {
+		if msg.Direction != "received" || msg.Parsed == nil {
+			return false
+		}
+		v, ok := msg.Parsed[field]
+		return ok && fmt.Sprintf("%v", v) == value
+	}
+}
+
+// collectWSOutcomes drains sub for window, returning every message
+// delivered in that time (possibly none, if the server never replied).
+func collectWSOutcomes(sub *browser.WSSubscription, window time.Duration) []browser.WSMessage {
+	var msgs []browser.WSMessage
+	deadline := time.After(window)
+	for {
+		select {
+		case msg, ok := <-sub.Channel:
+			if !ok {
+				return msgs
+			}
+			msgs = append(msgs, msg)
+		case <-deadline:
+			return msgs
+		}
+	}
+}
+
+// replayAccepted is true when at least one outcome frame looks like a
+// success and none of them look like a rejection - i.e. the server treated
+// the replay as a brand new, successful request rather than erroring or
+// flagging it as a duplicate.
+func replayAccepted(outcomes []browser.WSMessage) bool {
+	sawSuccess := false
+	for _, msg := range outcomes {
+		if wsOutcomeIsRejection(msg) {
+			return false
+		}
+		if isSuccessResponse(msg) {
+			sawSuccess = true
+		}
+	}
+	return sawSuccess
+}
+
+// wsOutcomeIsRejection reports whether msg looks like an error or
+// duplicate-rejection response, the outcome a correctly implemented server
+// should produce for a replayed message.
+func wsOutcomeIsRejection(msg browser.WSMessage) bool {
+	if msg.Parsed != nil {
+		if status, ok := msg.Parsed["status"].(string); ok {
+			rejections := []string{"error", "failed", "rejected", "duplicate"}
+			for _, r := range rejections {
+				if status == r {
+					return true
 				}
 			}
 		}
+		if errVal, ok := msg.Parsed["error"]; ok && errVal != nil && errVal != "" {
+			return true
+		}
 	}
-	
-	return vulns
+
+	rejectionKeywords := []string{"duplicate", "already processed", "replay", "error"}
+	for _, kw := range rejectionKeywords {
+		if contains(msg.Data, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// wsAmountField returns the first of wsAmountFields present in parsed.
+func wsAmountField(parsed map[string]interface{}) (field string, value interface{}, ok bool) {
+	for _, f := range wsAmountFields {
+		if v, present := parsed[f]; present {
+			return f, v, true
+		}
+	}
+	return "", nil, false
+}
+
+// wsAcceptedManipulatedAmount reports whether any outcome frame both looks
+// like a success and echoes amountField back equal to testAmount - the
+// actual invariant violation this test looks for, rather than just "the
+// server replied with something that looked like success".
+func wsAcceptedManipulatedAmount(outcomes []browser.WSMessage, amountField string, testAmount interface{}) (interface{}, bool) {
+	want := fmt.Sprintf("%v", testAmount)
+	for _, msg := range outcomes {
+		if !isSuccessResponse(msg) || msg.Parsed == nil {
+			continue
+		}
+		if v, ok := msg.Parsed[amountField]; ok && fmt.Sprintf("%v", v) == want {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// sendWSMessage sends data over the page's active WebSocket by
+// JSON-encoding it and injecting the result as a JS value, rather than
+// concatenating raw data into the script source - so payloads containing
+// quotes, backslashes, or arbitrary Unicode can't break out of the
+// generated JS or get mangled.
+func sendWSMessage(page playwright.Page, data string) bool {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return false
+	}
+
+	result, err := page.Evaluate(fmt.Sprintf(`() => {
+		if (!window._ws || window._ws.readyState !== 1) {
+			return false;
+		}
+
+		try {
+			window._ws.send(%s);
+			return true;
+		} catch(e) {
+			return false;
+		}
+	}`, encoded))
+
+	if err != nil {
+		return false
+	}
+
+	if success, ok := result.(bool); ok {
+		return success
+	}
+
+	return false
 }
 
 func isSuccessResponse(msg browser.WSMessage) bool {
@@ -211,12 +363,12 @@ func isSuccessResponse(msg browser.WSMessage) bool {
 				}
 			}
 		}
-		
+
 		if success, ok := msg.Parsed["success"].(bool); ok && success {
 			return true
 		}
 	}
-	
+
 	// Check string
 	successKeywords := []string{"success", "completed", "confirmed"}
 	for _, kw := range successKeywords {
@@ -224,7 +376,7 @@ func isSuccessResponse(msg browser.WSMessage) bool {
 			return true
 		}
 	}
-	
+
 	return false
 }
 
@@ -235,8 +387,3 @@ func cloneMap(original map[string]interface{}) map[string]interface{} {
 	}
 	return clone
 }
-
-func escapeJS(s string) string {
-	// Escape single quotes for JS
-	return s // TODO: proper escaping
-}
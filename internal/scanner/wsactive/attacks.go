@@ -0,0 +1,359 @@
+package wsactive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// dialTimeout bounds each probe's own connection attempt/response wait so a
+// single unresponsive target can't stall the whole battery.
+const dialTimeout = 15 * time.Second
+
+// responseWait is how long a probe waits for a frame back before concluding
+// the server didn't respond (and so didn't accept the probe).
+const responseWait = 5 * time.Second
+
+// weakSubprotocols are offered one at a time by testSubprotocolDowngrade.
+// The "" entry is skipped there, so a handshake offering no subprotocol at
+// all is never tried.
+var weakSubprotocols = []string{"", "json", "plain", "insecure"}
+
+// RunActiveTests opens real connections to wsURL and runs a battery of
+// active probes a passive browser.WSInterceptor capture can't perform on
+// its own: missing authentication, cross-site hijacking, oversized/malformed
+// frames, IDOR via mutated fields, and subprotocol downgrade. capturedFrame
+// is a previously observed "sent" payment frame (e.g. from
+// browser.WSInterceptor.GetPaymentMessages) used as the template several
+// probes replay or mutate; probes that need a template are skipped if it's
+// empty, and the IDOR probe is also skipped if it's not JSON (the
+// missing-auth and CSWSH probes replay it as raw bytes).
+func RunActiveTests(wsURL string, session *models.Session, capturedFrame []byte) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	vulns = append(vulns, testMissingAuth(wsURL, capturedFrame)...)
+	vulns = append(vulns, testCrossSiteHijacking(wsURL, session, capturedFrame)...)
+	vulns = append(vulns, testOversizedAndMalformed(wsURL, session)...)
+	vulns = append(vulns, testIDORViaMutatedFrame(wsURL, session, capturedFrame)...)
+	vulns = append(vulns, testSubprotocolDowngrade(wsURL, session)...)
+
+	return vulns
+}
+
+// legitHeader rebuilds the handshake headers a real browser session would
+// send: session cookies, any extra headers the session carries, and a
+// bearer token if one was issued.
+func legitHeader(session *models.Session) http.Header {
+	h := http.Header{}
+	if len(session.Cookies) > 0 {
+		pairs := make([]string, 0, len(session.Cookies))
+		for k, v := range session.Cookies {
+			pairs = append(pairs, k+"="+v)
+		}
+		h.Set("Cookie", strings.Join(pairs, "; "))
+	}
+	for k, v := range session.Headers {
+		h.Set(k, v)
+	}
+	if session.BearerToken != "" {
+		h.Set("Authorization", "Bearer "+session.BearerToken)
+	}
+	return h
+}
+
+// testMissingAuth connects with no cookies, headers, or token at all and
+// replays capturedFrame - a secure server should reject the upgrade or drop
+// the frame, since nothing here identifies the caller.
+func testMissingAuth(wsURL string, capturedFrame []byte) []models.Vulnerability {
+	if len(capturedFrame) == 0 {
+		return nil
+	}
+
+	resp, ok := probe(wsURL, http.Header{}, nil, capturedFrame)
+	if !ok || !looksLikeAcceptance(resp) {
+		return nil
+	}
+
+	return []models.Vulnerability{{
+		Type:        "WebSocket Authentication",
+		Severity:    "CRITICAL",
+		Title:       "WebSocket Accepts Payment Frames Without Authentication",
+		Description: "Connecting to the WebSocket endpoint with no session cookies, auth header, or token still let a previously captured payment frame through.",
+		Endpoint:    wsURL,
+		Proof:       fmt.Sprintf("Unauthenticated connection received: %s", truncate(resp, 200)),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-306",
+		CVSSScore:   9.1,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+		Confidence:  "High",
+		Remediation: "Require the same session authentication on the WebSocket upgrade that HTTP payment endpoints already enforce, and reject frames from connections that never authenticated.",
+		References:  []string{"https://cwe.mitre.org/data/definitions/306.html"},
+	}}
+}
+
+// testCrossSiteHijacking connects with valid session credentials but a
+// foreign Origin header, simulating a malicious page riding the victim's
+// browser session (CSWSH) rather than an attacker who stole the cookies.
+func testCrossSiteHijacking(wsURL string, session *models.Session, capturedFrame []byte) []models.Vulnerability {
+	if len(capturedFrame) == 0 {
+		return nil
+	}
+
+	const foreignOrigin = "https://evil-attacker.example"
+	header := legitHeader(session)
+	header.Set("Origin", foreignOrigin)
+
+	resp, ok := probe(wsURL, header, nil, capturedFrame)
+	if !ok || !looksLikeAcceptance(resp) {
+		return nil
+	}
+
+	return []models.Vulnerability{{
+		Type:        "Cross-Site WebSocket Hijacking",
+		Severity:    "HIGH",
+		Title:       "WebSocket Upgrade Ignores Origin Header",
+		Description: "The server upgraded the connection and accepted a payment frame despite a foreign Origin header accompanying otherwise-valid session cookies, which lets any third-party page ride the victim's session (CSWSH).",
+		Endpoint:    wsURL,
+		Proof:       fmt.Sprintf("Origin: %s accepted: %s", foreignOrigin, truncate(resp, 200)),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-346",
+		CVSSScore:   8.1,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:N",
+		Confidence:  "Medium",
+		Remediation: "Validate the Origin header (or require a CSRF-style token in the handshake) on WebSocket upgrade, the same way state-changing HTTP endpoints already should.",
+		References:  []string{"https://cwe.mitre.org/data/definitions/346.html"},
+	}}
+}
+
+// testOversizedAndMalformed sends a >10MB frame and a truncated/invalid JSON
+// frame over separate connections, checking whether the server enforces any
+// size limit or payload validation before processing a message.
+func testOversizedAndMalformed(wsURL string, session *models.Session) []models.Vulnerability {
+	var vulns []models.Vulnerability
+	header := legitHeader(session)
+
+	oversized := make([]byte, 10*1024*1024+1)
+	if resp, ok := probe(wsURL, header, nil, oversized); ok && !looksLikeError(resp) {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "WebSocket Input Validation",
+			Severity:    "MEDIUM",
+			Title:       "WebSocket Accepts Oversized Frames",
+			Description: "Sending a single WebSocket frame larger than 10MB didn't close the connection or return an error, suggesting the server enforces no frame size limit.",
+			Endpoint:    wsURL,
+			Proof:       fmt.Sprintf("Sent %d byte frame; connection stayed open and responded", len(oversized)),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-770",
+			CVSSScore:   5.3,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L",
+			Confidence:  "Low",
+			Remediation: "Enforce a maximum WebSocket message size server-side and close connections that exceed it.",
+		})
+	}
+
+	malformed := []byte(`{"action":"payment.create","amount":`) // truncated JSON
+	if resp, ok := probe(wsURL, header, nil, malformed); ok && !looksLikeError(resp) {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "WebSocket Input Validation",
+			Severity:    "LOW",
+			Title:       "Malformed WebSocket Payload Not Rejected",
+			Description: "Sending syntactically invalid JSON over the WebSocket connection produced a response that doesn't look like a validation error, suggesting frame payloads may not be parsed/validated strictly.",
+			Endpoint:    wsURL,
+			Proof:       fmt.Sprintf("Response to malformed JSON: %s", truncate(resp, 200)),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-20",
+			CVSSScore:   4.3,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:N",
+			Confidence:  "Low",
+			Remediation: "Reject malformed payloads with an explicit error and drop the frame instead of processing it further.",
+		})
+	}
+
+	return vulns
+}
+
+// testIDORViaMutatedFrame replays capturedFrame with its amount/user/account
+// fields mutated one at a time, checking whether the server re-validates
+// ownership of the referenced resource rather than trusting the frame as-is.
+func testIDORViaMutatedFrame(wsURL string, session *models.Session, capturedFrame []byte) []models.Vulnerability {
+	if len(capturedFrame) == 0 {
+		return nil
+	}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(capturedFrame, &parsed); err != nil {
+		return nil
+	}
+
+	mutations := []struct {
+		field string
+		value interface{}
+	}{
+		{"amount", 0.01},
+		{"user_id", "1"},
+		{"account_id", "1"},
+	}
+
+	header := legitHeader(session)
+	var vulns []models.Vulnerability
+
+	for _, m := range mutations {
+		if _, present := parsed[m.field]; !present {
+			continue
+		}
+
+		mutated := make(map[string]interface{}, len(parsed))
+		for k, v := range parsed {
+			mutated[k] = v
+		}
+		mutated[m.field] = m.value
+
+		payload, err := json.Marshal(mutated)
+		if err != nil {
+			continue
+		}
+
+		resp, ok := probe(wsURL, header, nil, payload)
+		if !ok || !looksLikeAcceptance(resp) {
+			continue
+		}
+
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "IDOR",
+			Severity:    "CRITICAL",
+			Title:       fmt.Sprintf("WebSocket Accepts Mutated %q Field (IDOR)", m.field),
+			Description: fmt.Sprintf("Replaying the captured payment frame with its %q field mutated was still accepted, suggesting the WebSocket handler doesn't re-validate resource ownership server-side.", m.field),
+			Endpoint:    wsURL,
+			Proof:       fmt.Sprintf("Mutated frame: %s\nResponse: %s", string(payload), truncate(resp, 200)),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-639",
+			CVSSScore:   8.1,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:N",
+			Confidence:  "Medium",
+			Remediation: "Re-validate that the authenticated session owns the referenced resource (user_id/account_id) on every WebSocket message, not just once at connection time.",
+			References:  []string{"https://cwe.mitre.org/data/definitions/639.html"},
+		})
+	}
+
+	return vulns
+}
+
+// testSubprotocolDowngrade offers the non-empty weakSubprotocols one at a
+// time and flags the first one the server actually negotiates, since
+// accepting a downgrade can drop whatever integrity guarantees the intended
+// subprotocol carries.
+func testSubprotocolDowngrade(wsURL string, session *models.Session) []models.Vulnerability {
+	header := legitHeader(session)
+
+	for _, proto := range weakSubprotocols {
+		if proto == "" {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+		client, err := Dial(ctx, wsURL, header, []string{proto})
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		negotiated := client.Subprotocol()
+		client.Close()
+
+		if negotiated == proto {
+			return []models.Vulnerability{{
+				Type:        "WebSocket Subprotocol Downgrade",
+				Severity:    "LOW",
+				Title:       fmt.Sprintf("Server Accepts Weak Subprotocol %q", proto),
+				Description: "The server negotiated a weak, unauthenticated subprotocol instead of rejecting the handshake, which can let an attacker downgrade a client to a transport variant with fewer integrity guarantees.",
+				Endpoint:    wsURL,
+				Proof:       fmt.Sprintf("Offered %q, server accepted it", proto),
+				Timestamp:   time.Now(),
+				CWE:         "CWE-757",
+				CVSSScore:   4.3,
+				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N",
+				Confidence:  "Low",
+				Remediation: "Only advertise/accept the subprotocol(s) the server actually implements securely, and reject unexpected Sec-WebSocket-Protocol offers.",
+			}}
+		}
+	}
+
+	return nil
+}
+
+// probe opens a short-lived connection, sends payload once, and waits for a
+// single response frame. ok is false if the connection itself failed or no
+// response arrived within responseWait.
+func probe(wsURL string, header http.Header, subprotocols []string, payload []byte) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	client, err := Dial(ctx, wsURL, header, subprotocols)
+	if err != nil {
+		return nil, false
+	}
+	defer client.Close()
+
+	client.Send(payload)
+	return client.ReadWithTimeout(responseWait)
+}
+
+func looksLikeAcceptance(data []byte) bool {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(data, &parsed); err == nil {
+		if _, hasErr := parsed["error"]; hasErr {
+			return false
+		}
+		if status, ok := parsed["status"].(string); ok {
+			for _, s := range []string{"success", "ok", "completed", "paid", "confirmed", "accepted"} {
+				if strings.EqualFold(status, s) {
+					return true
+				}
+			}
+		}
+		if success, ok := parsed["success"].(bool); ok && success {
+			return true
+		}
+		return false
+	}
+
+	lower := strings.ToLower(string(data))
+	for _, kw := range []string{"success", "completed", "confirmed", "accepted"} {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func looksLikeError(data []byte) bool {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(data, &parsed); err == nil {
+		if _, hasErr := parsed["error"]; hasErr {
+			return true
+		}
+		if status, ok := parsed["status"].(string); ok && strings.EqualFold(status, "error") {
+			return true
+		}
+	}
+
+	lower := strings.ToLower(string(data))
+	for _, kw := range []string{"error", "invalid", "malformed", "bad request"} {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(data []byte, n int) string {
+	s := string(data)
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
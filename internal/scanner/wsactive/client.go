@@ -0,0 +1,167 @@
+// Package wsactive opens real WebSocket connections against a target (as
+// opposed to browser.WSInterceptor, which only observes frames the browser
+// already sent) so the scanner can actively probe auth, origin, and
+// server-side validation on the WS/WSS transport itself.
+package wsactive
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
+)
+
+// Client is a minimal active WebSocket client, shaped like the long-lived
+// WS clients this scanner already depends on elsewhere (one readLoop
+// goroutine draining frames, one writer goroutine owning the connection so
+// concurrent Sends never race each other, and a ping/pong heartbeat that
+// notices a dead connection instead of a caller blocking on a closed
+// socket forever).
+type Client struct {
+	conn     *websocket.Conn
+	send     chan []byte
+	Received chan []byte
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+// Dial opens a WebSocket connection to wsURL. header carries whatever
+// cookies/Authorization/Origin the caller wants presented during the
+// handshake - that's the entire attack surface for most of the probes in
+// this package. subprotocols, if non-empty, is offered via
+// Sec-WebSocket-Protocol.
+func Dial(ctx context.Context, wsURL string, header http.Header, subprotocols []string) (*Client, error) {
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 10 * time.Second,
+		Subprotocols:     subprotocols,
+		// Target TLS trust isn't what these probes test; skip verification
+		// so a self-signed cert in a staging target doesn't block them.
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+
+	conn, _, err := dialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		return nil, err
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	c := &Client{
+		conn:     conn,
+		send:     make(chan []byte, 16),
+		Received: make(chan []byte, 16),
+		ctx:      cctx,
+		cancel:   cancel,
+	}
+
+	go c.readLoop()
+	go c.writeLoop()
+
+	return c, nil
+}
+
+// Subprotocol returns the subprotocol the server selected during the
+// handshake, or "" if none was negotiated.
+func (c *Client) Subprotocol() string {
+	return c.conn.Subprotocol()
+}
+
+func (c *Client) readLoop() {
+	defer close(c.Received)
+	defer c.cancel()
+
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		select {
+		case c.Received <- data:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Client) writeLoop() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	defer c.conn.Close()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// Send queues data to be written by the write loop. It returns false if the
+// client is already closed instead of blocking forever on a dead connection.
+func (c *Client) Send(data []byte) bool {
+	// Close cancels ctx before closing c.send, so checking ctx first keeps
+	// a Send after Close from picking the closed channel below.
+	select {
+	case <-c.ctx.Done():
+		return false
+	default:
+	}
+	select {
+	case c.send <- data:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
+// ReadWithTimeout waits up to d for the next received frame. ok is false if
+// d elapses first or the connection closes before a frame arrives.
+func (c *Client) ReadWithTimeout(d time.Duration) (data []byte, ok bool) {
+	select {
+	case data, ok = <-c.Received:
+		return data, ok
+	case <-time.After(d):
+		return nil, false
+	}
+}
+
+// Close cancels the client's context, which stops both goroutines and closes
+// the underlying connection. Safe to call more than once.
+func (c *Client) Close() error {
+	c.once.Do(func() {
+		c.cancel()
+		close(c.send)
+	})
+	return nil
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/browser"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// FileStore caches sessions on disk, keyed by (loginURL, targetURL, user).
+// It writes through browser.SaveSessionToFile (so sessions still get
+// encrypted-at-rest when SCANNER_SESSION_KEY is set) but to a temp file
+// that's renamed into place, so a crash mid-write can never leave a
+// corrupted cache entry behind.
+type FileStore struct {
+	Dir       string
+	Login     LoginFunc
+	Prober    Prober
+	Refresher Refresher
+}
+
+// NewFileStore creates a FileStore rooted at dir.
+func NewFileStore(dir string, login LoginFunc, prober Prober, refresher Refresher) *FileStore {
+	return &FileStore{Dir: dir, Login: login, Prober: prober, Refresher: refresher}
+}
+
+func (f *FileStore) path(params LoginParams) string {
+	return filepath.Join(f.Dir, fmt.Sprintf("session_%s.json", params.key()))
+}
+
+// New logs in via f.Login and caches the result.
+func (f *FileStore) New(ctx context.Context, params LoginParams) (*models.Session, error) {
+	sess, err := f.Login(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	stamp(sess, params)
+
+	if err := f.save(params, sess); err != nil {
+		return nil, fmt.Errorf("failed to cache session: %w", err)
+	}
+	return sess, nil
+}
+
+// Validate probes sess and falls back to f.Refresher then f.Login,
+// re-caching whatever comes out.
+func (f *FileStore) Validate(ctx context.Context, sess *models.Session) (*models.Session, error) {
+	params := paramsFromSession(sess)
+
+	updated, err := validate(ctx, sess, params, f.Prober, f.Refresher, f.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	stamp(updated, params)
+	if updated != sess {
+		if err := f.save(params, updated); err != nil {
+			return nil, fmt.Errorf("failed to cache refreshed session: %w", err)
+		}
+	}
+	return updated, nil
+}
+
+// Load reads a previously cached session for params, if any.
+func (f *FileStore) Load(params LoginParams) (*models.Session, error) {
+	return browser.LoadSessionFromFile(f.path(params))
+}
+
+func (f *FileStore) save(params LoginParams, sess *models.Session) error {
+	if err := os.MkdirAll(f.Dir, 0700); err != nil {
+		return err
+	}
+
+	dest := f.path(params)
+	tmp := dest + ".tmp"
+	if err := browser.SaveSessionToFile(sess, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"sync"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// MemoryStore keeps sessions in memory only, for tests that shouldn't
+// touch disk.
+type MemoryStore struct {
+	Login     LoginFunc
+	Prober    Prober
+	Refresher Refresher
+
+	mu       sync.Mutex
+	sessions map[string]*models.Session
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore(login LoginFunc, prober Prober, refresher Refresher) *MemoryStore {
+	return &MemoryStore{
+		Login:     login,
+		Prober:    prober,
+		Refresher: refresher,
+		sessions:  make(map[string]*models.Session),
+	}
+}
+
+// New logs in via m.Login and caches the result.
+func (m *MemoryStore) New(ctx context.Context, params LoginParams) (*models.Session, error) {
+	sess, err := m.Login(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	stamp(sess, params)
+
+	m.mu.Lock()
+	m.sessions[params.key()] = sess
+	m.mu.Unlock()
+	return sess, nil
+}
+
+// Validate probes sess and falls back to m.Refresher then m.Login,
+// re-caching whatever comes out.
+func (m *MemoryStore) Validate(ctx context.Context, sess *models.Session) (*models.Session, error) {
+	params := paramsFromSession(sess)
+
+	updated, err := validate(ctx, sess, params, m.Prober, m.Refresher, m.Login)
+	if err != nil {
+		return nil, err
+	}
+	stamp(updated, params)
+
+	m.mu.Lock()
+	m.sessions[params.key()] = updated
+	m.mu.Unlock()
+	return updated, nil
+}
+
+// Load returns a previously cached session for params, if any.
+func (m *MemoryStore) Load(params LoginParams) (*models.Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sess, ok := m.sessions[params.key()]
+	return sess, ok
+}
@@ -0,0 +1,53 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+)
+
+// HTTPProber returns a Prober that makes a single authenticated GET
+// against targetURL, carrying sess's cookies/headers/bearer token, and
+// treats any non-redirect-to-login 2xx/3xx response as still valid. This
+// is the "cheap authenticated probe" Validate uses before paying for a
+// refresh or full re-login.
+func HTTPProber() Prober {
+	return func(ctx context.Context, targetURL string, sess *models.Session) (bool, error) {
+		client := utils.NewHTTPClientForSession(10*time.Second, sess)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+		if err != nil {
+			return false, err
+		}
+
+		for name, value := range sess.Cookies {
+			req.AddCookie(&http.Cookie{Name: name, Value: value})
+		}
+		for name, value := range sess.Headers {
+			req.Header.Set(name, value)
+		}
+		if sess.BearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+sess.BearerToken)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return false, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return false, nil
+		}
+		if loc, err := resp.Location(); err == nil && sess.LoginURL != "" {
+			if login, err := url.Parse(sess.LoginURL); err == nil && loc.Host == login.Host && loc.Path == login.Path {
+				return false, nil
+			}
+		}
+		return resp.StatusCode < 400, nil
+	}
+}
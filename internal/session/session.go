@@ -0,0 +1,96 @@
+// Package session manages authenticated scan sessions with the
+// Validate/Refresh split popularized by Teleport's web session refactor:
+// a cheap probe decides whether a cached session is still good, and only
+// a failed probe pays for a refresh or full re-login.
+package session
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// LoginParams identifies which session to create, validate, or cache.
+type LoginParams struct {
+	LoginURL  string
+	TargetURL string
+	User      string // optional; empty for a single shared login
+}
+
+// key is the cache key FileStore/MemoryStore index sessions by.
+func (p LoginParams) key() string {
+	sum := md5.Sum([]byte(p.LoginURL + "|" + p.TargetURL + "|" + p.User))
+	return fmt.Sprintf("%x", sum)
+}
+
+// LoginFunc performs the actual login (typically browser-driven) and
+// returns a freshly authenticated session. It's supplied by the caller,
+// since only the CLI layer knows how to drive Playwright through a login
+// flow (manual, SSO, etc.).
+type LoginFunc func(ctx context.Context, params LoginParams) (*models.Session, error)
+
+// Prober performs a cheap authenticated request against targetURL to
+// check whether sess is still accepted by the server.
+type Prober func(ctx context.Context, targetURL string, sess *models.Session) (bool, error)
+
+// Refresher re-establishes a session that failed Validate without
+// necessarily falling back to a full interactive re-login: a silent
+// cookie re-issue, a bearer-token refresh endpoint, and so on.
+type Refresher interface {
+	Refresh(ctx context.Context, sess *models.Session) (*models.Session, error)
+}
+
+// Store creates and validates sessions. FileStore and MemoryStore are the
+// two implementations: they differ only in where the session is cached
+// between runs.
+type Store interface {
+	New(ctx context.Context, params LoginParams) (*models.Session, error)
+	Validate(ctx context.Context, sess *models.Session) (*models.Session, error)
+}
+
+// validate is the Validate/Refresh orchestration shared by every Store
+// implementation. An explicit ExpiresAt in the past skips the probe
+// entirely; otherwise a cheap probe decides whether to keep the session
+// as-is or fall back to refresher/login.
+func validate(ctx context.Context, sess *models.Session, params LoginParams, prober Prober, refresher Refresher, login LoginFunc) (*models.Session, error) {
+	if sess == nil {
+		return login(ctx, params)
+	}
+
+	if !sess.ExpiresAt.IsZero() && time.Now().After(sess.ExpiresAt) {
+		return refreshOrLogin(ctx, sess, params, refresher, login)
+	}
+
+	if prober != nil {
+		if ok, err := prober(ctx, params.TargetURL, sess); err == nil && ok {
+			return sess, nil
+		}
+	}
+
+	return refreshOrLogin(ctx, sess, params, refresher, login)
+}
+
+func refreshOrLogin(ctx context.Context, sess *models.Session, params LoginParams, refresher Refresher, login LoginFunc) (*models.Session, error) {
+	if refresher != nil {
+		if refreshed, err := refresher.Refresh(ctx, sess); err == nil {
+			return refreshed, nil
+		}
+	}
+	return login(ctx, params)
+}
+
+// paramsFromSession recovers the LoginParams a cached session was created
+// with, so Validate doesn't need them passed in separately.
+func paramsFromSession(sess *models.Session) LoginParams {
+	return LoginParams{LoginURL: sess.LoginURL, TargetURL: sess.TargetURL, User: sess.User}
+}
+
+// stamp fills in the identifying fields New/Validate rely on.
+func stamp(sess *models.Session, params LoginParams) {
+	sess.LoginURL = params.LoginURL
+	sess.TargetURL = params.TargetURL
+	sess.User = params.User
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// maxEvidenceResponseBody caps how much of a response body an
+// EvidenceRecorder keeps, so a probe hitting an endpoint that streams back
+// megabytes doesn't balloon scan results.
+const maxEvidenceResponseBody = 64 * 1024
+
+// EvidenceRecorder wraps an *http.Client, capturing the full request/
+// response exchange (headers, bodies, TLS state, timing) for every call
+// made through it so probes can attach a models.Evidence to a finding
+// instead of discarding the exchange that proved it.
+type EvidenceRecorder struct {
+	Client *http.Client
+}
+
+// NewEvidenceRecorder wraps client for evidence capture.
+func NewEvidenceRecorder(client *http.Client) *EvidenceRecorder {
+	return &EvidenceRecorder{Client: client}
+}
+
+// Do performs req exactly like r.Client.Do, returning the response
+// unchanged (its Body remains readable/closeable by the caller) alongside
+// an Evidence record of the full exchange. On transport error, ev is still
+// populated with everything captured before the failure (method, URL,
+// request headers/body, elapsed time).
+func (r *EvidenceRecorder) Do(req *http.Request) (*http.Response, *models.Evidence, error) {
+	ev := &models.Evidence{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: cloneHeader(req.Header),
+	}
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err == nil {
+			ev.RequestBody = string(body)
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+	}
+
+	start := time.Now()
+	ev.StartedAt = start
+	resp, err := r.Client.Do(req)
+	ev.Duration = time.Since(start)
+	if err != nil {
+		return nil, ev, err
+	}
+
+	ev.StatusCode = resp.StatusCode
+	ev.ResponseHeaders = cloneHeader(resp.Header)
+	if resp.TLS != nil {
+		ev.TLSVersion = tlsVersionName(resp.TLS.Version)
+		ev.TLSCipherSuite = tls.CipherSuiteName(resp.TLS.CipherSuite)
+	}
+
+	bodyBytes, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if readErr == nil {
+		recordResponseBody(ev, bodyBytes)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+
+	return resp, ev, nil
+}
+
+func recordResponseBody(ev *models.Evidence, body []byte) {
+	capped := body
+	if len(capped) > maxEvidenceResponseBody {
+		capped = capped[:maxEvidenceResponseBody]
+		ev.ResponseBodyTruncated = true
+	}
+
+	if utf8.Valid(capped) {
+		ev.ResponseBody = string(capped)
+	} else {
+		ev.ResponseBodyBinary = true
+		ev.ResponseBody = base64.StdEncoding.EncodeToString(capped)
+	}
+}
+
+func cloneHeader(h http.Header) map[string][]string {
+	cloned := make(map[string][]string, len(h))
+	for k, v := range h {
+		values := make([]string, len(v))
+		copy(values, v)
+		cloned[k] = values
+	}
+	return cloned
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return "unknown"
+	}
+}
@@ -1,11 +1,12 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"time"
-	
+
 	"github.com/fatih/color"
 )
 
@@ -22,13 +23,29 @@ const (
 type Logger struct {
 	level   int
 	verbose bool
+	format  string // "text" (default, ANSI-colored human lines) or "json"
+	fields  map[string]interface{}
 }
 
-// NewLogger creates a new logger instance
+// NewLogger creates a new logger instance that writes ANSI-colored human
+// lines.
 func NewLogger(verbose bool) *Logger {
 	return &Logger{
 		level:   LevelInfo,
 		verbose: verbose,
+		format:  "text",
+	}
+}
+
+// NewJSONLogger creates a logger that writes one JSON object per line -
+// {"ts":...,"level":...,"msg":...,"fields":{...}} - instead of
+// ANSI-colored text, for CI pipelines and SIEM ingestion. level sets the
+// minimum level emitted; Debug is only emitted when level is LevelDebug.
+func NewJSONLogger(level int) *Logger {
+	return &Logger{
+		level:   level,
+		verbose: level <= LevelDebug,
+		format:  "json",
 	}
 }
 
@@ -37,10 +54,53 @@ func (l *Logger) SetLevel(level int) {
 	l.level = level
 }
 
+// With returns a child logger that writes every field already carried by
+// l plus key/val, letting callers (typically scanner phases) tag every
+// line they emit with context like scan_id, target, or endpoint without
+// repeating it in every format string.
+func (l *Logger) With(key string, val interface{}) *Logger {
+	fields := make(map[string]interface{}, len(l.fields)+1)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fields[key] = val
+
+	child := *l
+	child.fields = fields
+	return &child
+}
+
+// jsonLogRecord is the shape written by logJSON.
+type jsonLogRecord struct {
+	TS     string                 `json:"ts"`
+	Level  string                 `json:"level"`
+	Msg    string                 `json:"msg"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// logJSON writes a single JSON log line to w.
+func (l *Logger) logJSON(w *os.File, level, msg string) {
+	rec := jsonLogRecord{
+		TS:     time.Now().Format(time.RFC3339Nano),
+		Level:  level,
+		Msg:    msg,
+		Fields: l.fields,
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(w, string(data))
+}
+
 // Debug logs debug messages (only in verbose mode)
 func (l *Logger) Debug(format string, args ...interface{}) {
 	if l.verbose && l.level <= LevelDebug {
 		msg := fmt.Sprintf(format, args...)
+		if l.format == "json" {
+			l.logJSON(os.Stdout, "debug", msg)
+			return
+		}
 		timestamp := time.Now().Format("15:04:05")
 		fmt.Printf("[%s] %s %s\n", timestamp, color.CyanString("DEBUG"), msg)
 	}
@@ -50,6 +110,10 @@ func (l *Logger) Debug(format string, args ...interface{}) {
 func (l *Logger) Info(format string, args ...interface{}) {
 	if l.level <= LevelInfo {
 		msg := fmt.Sprintf(format, args...)
+		if l.format == "json" {
+			l.logJSON(os.Stdout, "info", msg)
+			return
+		}
 		timestamp := time.Now().Format("15:04:05")
 		fmt.Printf("[%s] %s %s\n", timestamp, color.BlueString("INFO"), msg)
 	}
@@ -59,6 +123,10 @@ func (l *Logger) Info(format string, args ...interface{}) {
 func (l *Logger) Success(format string, args ...interface{}) {
 	if l.level <= LevelInfo {
 		msg := fmt.Sprintf(format, args...)
+		if l.format == "json" {
+			l.logJSON(os.Stdout, "success", msg)
+			return
+		}
 		timestamp := time.Now().Format("15:04:05")
 		fmt.Printf("[%s] %s %s\n", timestamp, color.GreenString("✓"), msg)
 	}
@@ -68,6 +136,10 @@ func (l *Logger) Success(format string, args ...interface{}) {
 func (l *Logger) Warn(format string, args ...interface{}) {
 	if l.level <= LevelWarn {
 		msg := fmt.Sprintf(format, args...)
+		if l.format == "json" {
+			l.logJSON(os.Stdout, "warn", msg)
+			return
+		}
 		timestamp := time.Now().Format("15:04:05")
 		fmt.Printf("[%s] %s %s\n", timestamp, color.YellowString("WARN"), msg)
 	}
@@ -77,6 +149,10 @@ func (l *Logger) Warn(format string, args ...interface{}) {
 func (l *Logger) Error(format string, args ...interface{}) {
 	if l.level <= LevelError {
 		msg := fmt.Sprintf(format, args...)
+		if l.format == "json" {
+			l.logJSON(os.Stderr, "error", msg)
+			return
+		}
 		timestamp := time.Now().Format("15:04:05")
 		fmt.Printf("[%s] %s %s\n", timestamp, color.RedString("ERROR"), msg)
 	}
@@ -85,6 +161,10 @@ func (l *Logger) Error(format string, args ...interface{}) {
 // Critical logs critical errors and exits
 func (l *Logger) Critical(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
+	if l.format == "json" {
+		l.logJSON(os.Stderr, "critical", msg)
+		os.Exit(1)
+	}
 	timestamp := time.Now().Format("15:04:05")
 	fmt.Printf("[%s] %s %s\n", timestamp, color.RedString("CRITICAL"), msg)
 	os.Exit(1)
@@ -93,14 +173,22 @@ func (l *Logger) Critical(format string, args ...interface{}) {
 // Fatal logs a fatal error and exits
 func (l *Logger) Fatal(err error) {
 	if err != nil {
+		if l.format == "json" {
+			l.logJSON(os.Stderr, "critical", err.Error())
+			os.Exit(1)
+		}
 		timestamp := time.Now().Format("15:04:05")
 		fmt.Printf("[%s] %s %s\n", timestamp, color.RedString("FATAL"), err.Error())
 		os.Exit(1)
 	}
 }
 
-// Banner prints a formatted banner
+// Banner prints a formatted banner in text mode. In JSON mode it no-ops,
+// since a decorative banner has no structured equivalent worth shipping.
 func (l *Logger) Banner(text string) {
+	if l.format == "json" {
+		return
+	}
 	fmt.Println()
 	fmt.Println(color.CyanString("═══════════════════════════════════════════════════════════"))
 	fmt.Println(color.CyanString("  " + text))
@@ -108,8 +196,22 @@ func (l *Logger) Banner(text string) {
 	fmt.Println()
 }
 
-// Section prints a section header
+// Section prints a section header in text mode, or emits a
+// {"event":"phase","phase":"..."} record in JSON mode so pipelines can
+// still track phase transitions.
 func (l *Logger) Section(text string) {
+	if l.format == "json" {
+		rec := struct {
+			Event string `json:"event"`
+			Phase string `json:"phase"`
+		}{Event: "phase", Phase: text}
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
 	fmt.Println()
 	fmt.Println(color.YellowString("▶ " + text))
 	fmt.Println(color.YellowString("───────────────────────────────────────────────────────────"))
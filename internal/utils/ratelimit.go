@@ -1,101 +1,366 @@
 package utils
 
 import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
-// RateLimiter handles request rate limiting
+// aimdRecoverAfter is how many consecutive non-throttled responses must be
+// observed before the limiter doubles its rate back up.
+const aimdRecoverAfter = 10
+
+// RateLimiter hands out a golang.org/x/time/rate token bucket per host, so
+// a scan hitting several hosts (the target plus, say, a webhook relay)
+// doesn't have one host's throttling steal the others' budget. Beyond the
+// fixed-rps behavior NewRateLimiter gives every bucket, RecordResponse and
+// RecordHTTPResponse run AIMD-style: a 429/403 halves the shared rate
+// (down to minRPS; every aimdRecoverAfter consecutive healthy responses
+// double it again, up to maxRPS) and, when the target sends Retry-After
+// or X-RateLimit-Reset, pauses that host's bucket until the instant the
+// target actually asked for instead of a made-up backoff ladder.
 type RateLimiter struct {
-	rps       int
-	limiter   *time.Ticker
-	mu        sync.Mutex
-	endpoints map[string]*EndpointState // Per-endpoint tracking
+	rps      int
+	minRPS   int
+	maxRPS   int
+	burst    int
+	consecOK int
+	mu       sync.Mutex
+
+	hosts map[string]*hostState
+}
+
+// hostState is the per-host token bucket plus its throttling bookkeeping.
+type hostState struct {
+	limiter     *rate.Limiter
+	pausedUntil time.Time
+
+	allowed        int
+	throttled      int
+	lastRetryAfter time.Duration
 }
 
-// EndpointState tracks rate limiting per endpoint
-type EndpointState struct {
-	RateLimited   int           // Consecutive 429/403 count
-	LastBackoff   time.Duration // Last backoff duration
-	LastRequest   time.Time
-	ShouldPause   bool          // Auto-pause on sustained rate limiting
+// HostMetrics is a point-in-time snapshot of one host's rate limiting
+// state, for reporting.
+type HostMetrics struct {
+	RequestsAllowed   int
+	RequestsThrottled int
+	LastRetryAfter    time.Duration
 }
 
-// NewRateLimiter creates a new rate limiter
+// NewRateLimiter creates a new rate limiter fixed at rps requests/sec.
 func NewRateLimiter(rps int) *RateLimiter {
 	if rps <= 0 {
 		rps = 10 // Default: 10 req/sec
 	}
-	
+	return NewAdaptiveRateLimiter(rps, rps, rps)
+}
+
+// NewAdaptiveRateLimiter creates a rate limiter that starts at rps and
+// adapts between min and max requests/sec as RecordResponse/
+// RecordHTTPResponse observe throttling (halve) and sustained success
+// (double), AIMD-style. Every host's bucket shares this rate and a burst
+// equal to it, so a host can send a full second's worth of requests
+// back-to-back but no more.
+func NewAdaptiveRateLimiter(rps, min, max int) *RateLimiter {
+	if min <= 0 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	if rps < min {
+		rps = min
+	}
+	if rps > max {
+		rps = max
+	}
+
 	return &RateLimiter{
-		rps:       rps,
-		limiter:   time.NewTicker(time.Second / time.Duration(rps)),
-		endpoints: make(map[string]*EndpointState),
+		rps:    rps,
+		minRPS: min,
+		maxRPS: max,
+		burst:  rps,
+		hosts:  make(map[string]*hostState),
 	}
 }
 
-// Wait pauses until the next request can be sent
-func (rl *RateLimiter) Wait(endpoint string) {
-	rl.mu.Lock()
-	state, exists := rl.endpoints[endpoint]
+// hostForLocked returns (creating if necessary) the bucket for endpoint's host.
+// Caller must hold rl.mu.
+func (rl *RateLimiter) hostForLocked(endpoint string) *hostState {
+	host := hostOf(endpoint)
+	hs, exists := rl.hosts[host]
 	if !exists {
-		state = &EndpointState{}
-		rl.endpoints[endpoint] = state
+		hs = &hostState{limiter: rate.NewLimiter(rate.Limit(rl.rps), rl.burst)}
+		rl.hosts[host] = hs
+	}
+	return hs
+}
+
+// hostOf extracts the host:port a rate-limit bucket should be keyed by,
+// falling back to the raw string for endpoints that don't parse as a URL.
+func hostOf(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
 	}
+	return u.Host
+}
+
+// Wait pauses until the next request to endpoint's host can be sent. It's
+// WaitContext with context.Background(), for callers that don't need to
+// cancel a long pause.
+func (rl *RateLimiter) Wait(endpoint string) {
+	if rl == nil {
+		return
+	}
+	_ = rl.WaitContext(context.Background(), endpoint)
+}
+
+// WaitContext pauses until the next request to endpoint's host can be
+// sent, or returns ctx.Err() if ctx is cancelled first - so a
+// server-requested cooldown of, say, a minute doesn't block a scan the
+// user asked to stop.
+func (rl *RateLimiter) WaitContext(ctx context.Context, endpoint string) error {
+	if rl == nil {
+		return nil
+	}
+
+	rl.mu.Lock()
+	hs := rl.hostForLocked(endpoint)
+	pausedUntil := hs.pausedUntil
+	limiter := hs.limiter
 	rl.mu.Unlock()
-	
-	// Check if paused due to sustained rate limiting
-	if state.ShouldPause {
-		time.Sleep(10 * time.Second) // Long pause
-		state.ShouldPause = false
-	}
-	
-	// Apply adaptive backoff if rate limited
-	if state.RateLimited > 0 {
-		backoff := state.LastBackoff
-		if backoff == 0 {
-			backoff = 1 * time.Second
+
+	if !pausedUntil.IsZero() {
+		if d := time.Until(pausedUntil); d > 0 {
+			t := time.NewTimer(d)
+			defer t.Stop()
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
-		time.Sleep(backoff)
 	}
-	
-	<-rl.limiter.C
-	state.LastRequest = time.Now()
+
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	rl.mu.Lock()
+	hs.allowed++
+	rl.mu.Unlock()
+	return nil
 }
 
-// RecordResponse records endpoint response for rate limit detection
+// RecordResponse records endpoint response for rate limit detection.
 func (rl *RateLimiter) RecordResponse(endpoint string, statusCode int) {
+	rl.RecordResponseWithRetryAfter(endpoint, statusCode, 0)
+}
+
+// RecordResponseWithRetryAfter is RecordResponse plus a pre-parsed
+// Retry-After delay (0 if absent/not sent). Kept for callers that only
+// have the header value already parsed; RecordHTTPResponse is preferred
+// when the full *http.Response is available, since it also honors
+// X-RateLimit-Remaining/X-RateLimit-Reset.
+func (rl *RateLimiter) RecordResponseWithRetryAfter(endpoint string, statusCode int, retryAfter time.Duration) {
+	rl.recordLocked(endpoint, statusCode, retryAfter, time.Time{})
+}
+
+// RecordHTTPResponse is RecordResponse with full Retry-After (RFC 7231
+// §7.1.3, both delta-seconds and HTTP-date forms) and
+// X-RateLimit-Remaining/X-RateLimit-Reset (GitHub/Stripe style) support.
+// A 503 with Retry-After is treated as a server-requested cooldown -
+// endpoint's host bucket is paused until that instant, but it does not
+// count as a rate-limit trip the way 429/403 does (no AIMD halving, no
+// throttled-count increment).
+func (rl *RateLimiter) RecordHTTPResponse(endpoint string, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+	resetAt := rateLimitResetAt(resp.Header)
+
+	if resp.StatusCode == http.StatusServiceUnavailable && retryAfter > 0 {
+		rl.mu.Lock()
+		hs := rl.hostForLocked(endpoint)
+		rl.pauseHostLocked(hs, retryAfter, time.Time{})
+		rl.mu.Unlock()
+		return
+	}
+
+	rl.recordLocked(endpoint, resp.StatusCode, retryAfter, resetAt)
+}
+
+// recordLocked is the shared body of RecordResponseWithRetryAfter and
+// RecordHTTPResponse. retryAfter/resetAt are zero when not supplied by
+// the caller; whichever resolves to the later instant wins.
+func (rl *RateLimiter) recordLocked(endpoint string, statusCode int, retryAfter time.Duration, resetAt time.Time) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
-	
-	state, exists := rl.endpoints[endpoint]
+
+	hs := rl.hostForLocked(endpoint)
+
+	if statusCode == http.StatusTooManyRequests || statusCode == http.StatusForbidden {
+		hs.throttled++
+		rl.pauseHostLocked(hs, retryAfter, resetAt)
+
+		rl.consecOK = 0
+		rl.setRPSLocked(rl.rps / 2)
+		return
+	}
+
+	if statusCode == http.StatusServiceUnavailable {
+		rl.pauseHostLocked(hs, retryAfter, resetAt)
+		rl.consecOK = 0
+		rl.setRPSLocked(rl.rps / 2)
+		return
+	}
+
+	rl.consecOK++
+	if rl.consecOK >= aimdRecoverAfter {
+		rl.consecOK = 0
+		rl.setRPSLocked(rl.rps * 2)
+	}
+}
+
+// pauseHostLocked pushes hs.pausedUntil out to the later of a Retry-After
+// delay and an explicit reset instant, if either is later than now and
+// later than the pause already in effect. Caller must hold rl.mu.
+func (rl *RateLimiter) pauseHostLocked(hs *hostState, retryAfter time.Duration, resetAt time.Time) {
+	candidate := hs.pausedUntil
+
+	if retryAfter > 0 {
+		hs.lastRetryAfter = retryAfter
+		if until := time.Now().Add(retryAfter); until.After(candidate) {
+			candidate = until
+		}
+	}
+	if !resetAt.IsZero() && resetAt.After(candidate) {
+		candidate = resetAt
+	}
+
+	hs.pausedUntil = candidate
+}
+
+// setRPSLocked updates rl.rps (clamped to [minRPS, maxRPS]) and every
+// existing host bucket's limit/burst to match. Caller must hold rl.mu.
+func (rl *RateLimiter) setRPSLocked(rps int) {
+	if rps < rl.minRPS {
+		rps = rl.minRPS
+	}
+	if rps > rl.maxRPS {
+		rps = rl.maxRPS
+	}
+	if rps == rl.rps {
+		return
+	}
+	rl.rps = rps
+	rl.burst = rps
+	for _, hs := range rl.hosts {
+		hs.limiter.SetLimit(rate.Limit(rps))
+		hs.limiter.SetBurst(rps)
+	}
+}
+
+// HostMetrics returns a snapshot of endpoint's host's rate-limiting state.
+func (rl *RateLimiter) HostMetrics(endpoint string) HostMetrics {
+	if rl == nil {
+		return HostMetrics{}
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	hs, exists := rl.hosts[hostOf(endpoint)]
 	if !exists {
-		state = &EndpointState{}
-		rl.endpoints[endpoint] = state
-	}
-	
-	// Detect rate limiting
-	if statusCode == 429 || statusCode == 403 {
-		state.RateLimited++
-		
-		// Exponential backoff: 1s → 2s → 4s → 8s (max)
-		state.LastBackoff = time.Duration(1<<uint(state.RateLimited-1)) * time.Second
-		if state.LastBackoff > 8*time.Second {
-			state.LastBackoff = 8 * time.Second
+		return HostMetrics{}
+	}
+	return HostMetrics{
+		RequestsAllowed:   hs.allowed,
+		RequestsThrottled: hs.throttled,
+		LastRetryAfter:    hs.lastRetryAfter,
+	}
+}
+
+// AllHostMetrics returns a snapshot of every host the limiter has seen,
+// keyed by host:port, for the reporter to surface alongside a scan's
+// results.
+func (rl *RateLimiter) AllHostMetrics() map[string]HostMetrics {
+	if rl == nil {
+		return nil
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	out := make(map[string]HostMetrics, len(rl.hosts))
+	for host, hs := range rl.hosts {
+		out[host] = HostMetrics{
+			RequestsAllowed:   hs.allowed,
+			RequestsThrottled: hs.throttled,
+			LastRetryAfter:    hs.lastRetryAfter,
 		}
-		
-		// Auto-pause on 3 consecutive rate limits
-		if state.RateLimited >= 3 {
-			state.ShouldPause = true
+	}
+	return out
+}
+
+// parseRetryAfter parses a Retry-After header value per RFC 7231 §7.1.3:
+// either delta-seconds ("120") or an HTTP-date
+// ("Wed, 21 Oct 2015 07:28:00 GMT"). Returns 0 if v is empty or neither
+// form parses.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+		if secs < 0 {
+			return 0
 		}
-	} else {
-		// Reset on success
-		state.RateLimited = 0
-		state.LastBackoff = 0
+		return time.Duration(secs) * time.Second
 	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
 }
 
-// Stop stops the rate limiter
-func (rl *RateLimiter) Stop() {
-	rl.limiter.Stop()
+// rateLimitResetAt reads GitHub/Stripe-style X-RateLimit-Remaining/
+// X-RateLimit-Reset headers and, when the quota is exhausted, returns the
+// instant it resets. X-RateLimit-Reset is treated as a Unix timestamp
+// (GitHub's convention); it's ignored if X-RateLimit-Remaining is absent
+// or still positive, since a reset time alone doesn't mean we're
+// throttled.
+func rateLimitResetAt(h http.Header) time.Time {
+	remaining := h.Get("X-RateLimit-Remaining")
+	reset := h.Get("X-RateLimit-Reset")
+	if remaining == "" || reset == "" {
+		return time.Time{}
+	}
+
+	remainingN, err := strconv.Atoi(strings.TrimSpace(remaining))
+	if err != nil || remainingN > 0 {
+		return time.Time{}
+	}
+
+	resetN, err := strconv.ParseInt(strings.TrimSpace(reset), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(resetN, 0)
 }
+
+// Stop is a no-op kept for source compatibility with callers that stopped
+// the old ticker-based limiter; golang.org/x/time/rate.Limiter has no
+// resources to release.
+func (rl *RateLimiter) Stop() {}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRateLimiterHalvesOnThrottle(t *testing.T) {
+	rl := NewAdaptiveRateLimiter(16, 1, 32)
+
+	rl.RecordResponse("https://example.com/pay", http.StatusTooManyRequests)
+	if rl.rps != 8 {
+		t.Errorf("rps after one 429 = %d, want 8", rl.rps)
+	}
+
+	rl.RecordResponse("https://example.com/pay", http.StatusForbidden)
+	if rl.rps != 4 {
+		t.Errorf("rps after a second throttle (403) = %d, want 4", rl.rps)
+	}
+
+	metrics := rl.HostMetrics("https://example.com/pay")
+	if metrics.RequestsThrottled != 2 {
+		t.Errorf("RequestsThrottled = %d, want 2", metrics.RequestsThrottled)
+	}
+}
+
+func TestRateLimiterFloorsAtMinRPS(t *testing.T) {
+	rl := NewAdaptiveRateLimiter(4, 2, 32)
+
+	rl.RecordResponse("https://example.com/pay", http.StatusTooManyRequests)
+	rl.RecordResponse("https://example.com/pay", http.StatusTooManyRequests)
+	rl.RecordResponse("https://example.com/pay", http.StatusTooManyRequests)
+
+	if rl.rps != 2 {
+		t.Errorf("rps = %d, want floored at minRPS=2", rl.rps)
+	}
+}
+
+func TestRateLimiterRecoversAfterConsecutiveOK(t *testing.T) {
+	rl := NewAdaptiveRateLimiter(16, 1, 32)
+
+	rl.RecordResponse("https://example.com/pay", http.StatusTooManyRequests)
+	if rl.rps != 8 {
+		t.Fatalf("rps after throttle = %d, want 8", rl.rps)
+	}
+
+	for i := 0; i < aimdRecoverAfter-1; i++ {
+		rl.RecordResponse("https://example.com/pay", http.StatusOK)
+	}
+	if rl.rps != 8 {
+		t.Errorf("rps recovered early at %d consecutive OKs, want still 8", aimdRecoverAfter-1)
+	}
+
+	rl.RecordResponse("https://example.com/pay", http.StatusOK)
+	if rl.rps != 16 {
+		t.Errorf("rps after %d consecutive OKs = %d, want doubled back to 16", aimdRecoverAfter, rl.rps)
+	}
+}
+
+func TestRateLimiterServiceUnavailableWithRetryAfterDoesNotCountAsThrottle(t *testing.T) {
+	rl := NewAdaptiveRateLimiter(16, 1, 32)
+
+	resp := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+	}
+	rl.RecordHTTPResponse("https://example.com/pay", resp)
+
+	metrics := rl.HostMetrics("https://example.com/pay")
+	if metrics.RequestsThrottled != 0 {
+		t.Errorf("RequestsThrottled = %d, want 0 (a 503+Retry-After pause isn't a throttle trip)", metrics.RequestsThrottled)
+	}
+	if metrics.LastRetryAfter <= 0 {
+		t.Errorf("LastRetryAfter = %v, want a positive pause recorded", metrics.LastRetryAfter)
+	}
+
+	rl.mu.Lock()
+	hs := rl.hosts[hostOf("https://example.com/pay")]
+	paused := hs.pausedUntil
+	rl.mu.Unlock()
+	if paused.IsZero() {
+		t.Errorf("pausedUntil is zero, want the host paused until the Retry-After instant")
+	}
+}
+
+func TestRateLimiterAllHostMetricsTracksMultipleHosts(t *testing.T) {
+	rl := NewRateLimiter(10)
+
+	rl.RecordResponse("https://a.example.com/pay", http.StatusOK)
+	rl.RecordResponse("https://b.example.com/pay", http.StatusTooManyRequests)
+
+	all := rl.AllHostMetrics()
+	if len(all) != 2 {
+		t.Fatalf("len(AllHostMetrics()) = %d, want 2", len(all))
+	}
+	if all["b.example.com"].RequestsThrottled != 1 {
+		t.Errorf("b.example.com RequestsThrottled = %d, want 1", all["b.example.com"].RequestsThrottled)
+	}
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+)
+
+// TLSConfigSpec is the raw, file-path form of a models.TLSConfig - what a
+// --client-cert/--client-key/--ca-bundle/--tls-insecure flag set (or a
+// per-endpoint import) actually carries before LoadTLSConfig resolves it
+// into parsed certificates.
+type TLSConfigSpec struct {
+	// ClientCertPath/ClientKeyPath are a PEM cert+key pair. If
+	// ClientCertPath ends in .p12 or .pfx, it's read as a PKCS#12 bundle
+	// instead, PKCS12Password is the bundle's passphrase, and ClientKeyPath
+	// is ignored.
+	ClientCertPath string
+	ClientKeyPath  string
+	PKCS12Password string
+
+	// ClientCertPEM/ClientKeyPEM carry the cert/key inline instead of on
+	// disk, for callers (e.g. internal/auth's provider configs) that load
+	// credentials from a secrets manager rather than a file path. Ignored
+	// if ClientCertPath is set.
+	ClientCertPEM string
+	ClientKeyPEM  string
+
+	CABundlePath string
+	ServerName   string
+	Insecure     bool
+}
+
+// LoadTLSConfig resolves spec into a models.TLSConfig, parsing the client
+// certificate (PEM file, inline PEM, or PKCS#12) and CA bundle once so
+// every HTTP client built from the result shares the same already-parsed
+// material. Returns nil, nil if spec has nothing set (no cert, no CA
+// bundle, no SNI override, not insecure) - the caller's session then falls
+// back to plain NewHTTPClient behavior.
+func LoadTLSConfig(spec TLSConfigSpec) (*models.TLSConfig, error) {
+	if spec.ClientCertPath == "" && spec.ClientCertPEM == "" && spec.CABundlePath == "" && spec.ServerName == "" && !spec.Insecure {
+		return nil, nil
+	}
+
+	cfg := &models.TLSConfig{
+		ServerName: spec.ServerName,
+		Insecure:   spec.Insecure,
+	}
+
+	if spec.ClientCertPath != "" || spec.ClientCertPEM != "" {
+		cert, err := loadClientCert(spec)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Cert = cert
+	}
+
+	if spec.CABundlePath != "" {
+		pem, err := os.ReadFile(spec.CABundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle %s: %w", spec.CABundlePath, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", spec.CABundlePath)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+// loadClientCert reads spec.ClientCertPath (or, if that's empty,
+// spec.ClientCertPEM/ClientKeyPEM inline) as either a PKCS#12 bundle
+// (.p12/.pfx extension) or a PEM cert+key pair. The returned certificate's
+// Leaf is always populated (parsed explicitly, since tls.LoadX509KeyPair/
+// tls.X509KeyPair don't set it) so callers can inspect its expiry without
+// re-parsing.
+func loadClientCert(spec TLSConfigSpec) (*tls.Certificate, error) {
+	ext := strings.ToLower(spec.ClientCertPath)
+	if strings.HasSuffix(ext, ".p12") || strings.HasSuffix(ext, ".pfx") {
+		data, err := os.ReadFile(spec.ClientCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading PKCS#12 bundle %s: %w", spec.ClientCertPath, err)
+		}
+		key, leaf, err := pkcs12.Decode(data, spec.PKCS12Password)
+		if err != nil {
+			return nil, fmt.Errorf("decoding PKCS#12 bundle %s: %w", spec.ClientCertPath, err)
+		}
+		return &tls.Certificate{
+			Certificate: [][]byte{leaf.Raw},
+			PrivateKey:  key,
+			Leaf:        leaf,
+		}, nil
+	}
+
+	var cert tls.Certificate
+	var err error
+	switch {
+	case spec.ClientCertPath != "":
+		if spec.ClientKeyPath == "" {
+			return nil, fmt.Errorf("--client-cert %s given without --client-key", spec.ClientCertPath)
+		}
+		cert, err = tls.LoadX509KeyPair(spec.ClientCertPath, spec.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading client cert/key pair: %w", err)
+		}
+	default:
+		if spec.ClientKeyPEM == "" {
+			return nil, fmt.Errorf("inline client certificate given without a matching key")
+		}
+		cert, err = tls.X509KeyPair([]byte(spec.ClientCertPEM), []byte(spec.ClientKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("parsing inline client cert/key pair: %w", err)
+		}
+	}
+
+	if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+		cert.Leaf = leaf
+	}
+	return &cert, nil
+}
+
+// NewHTTPClientForSession is NewHTTPClient, but additionally presents
+// session's TLSConfig (client cert, CA bundle, SNI override) during the TLS
+// handshake, for targets that require mutual TLS just to be scanned at all.
+// A nil session or nil session.TLSConfig behaves exactly like
+// NewHTTPClient.
+func NewHTTPClientForSession(timeout time.Duration, session *models.Session) *http.Client {
+	var cfg *models.TLSConfig
+	if session != nil {
+		cfg = session.TLSConfig
+	}
+	return newHTTPClientForTLSConfig(timeout, cfg)
+}
+
+// NewHTTPClientForEndpoint is NewHTTPClientForSession, but lets endpoint's
+// own TLSConfig (set by a per-endpoint import) override the session's, for
+// targets where different paths sit behind different mTLS-enforcing
+// gateways.
+func NewHTTPClientForEndpoint(timeout time.Duration, session *models.Session, endpoint models.Endpoint) *http.Client {
+	cfg := endpoint.TLSConfig
+	if cfg == nil && session != nil {
+		cfg = session.TLSConfig
+	}
+	return newHTTPClientForTLSConfig(timeout, cfg)
+}
+
+func newHTTPClientForTLSConfig(timeout time.Duration, cfg *models.TLSConfig) *http.Client {
+	if cfg == nil {
+		return NewHTTPClient(timeout)
+	}
+
+	transport := defaultTransport()
+	transport.TLSClientConfig = tlsClientConfigFor(cfg)
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// TLSClientConfigForEndpoint resolves the same session/endpoint TLSConfig
+// precedence as NewHTTPClientForEndpoint, but returns a bare *tls.Config for
+// callers (e.g. the race condition burst tester) that assemble their own
+// *http.Transport instead of using the ready-made client.
+func TLSClientConfigForEndpoint(session *models.Session, endpoint models.Endpoint) *tls.Config {
+	cfg := endpoint.TLSConfig
+	if cfg == nil && session != nil {
+		cfg = session.TLSConfig
+	}
+	if cfg == nil {
+		return &tls.Config{InsecureSkipVerify: true}
+	}
+	return tlsClientConfigFor(cfg)
+}
+
+func tlsClientConfigFor(cfg *models.TLSConfig) *tls.Config {
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.Insecure}
+	if cfg.Cert != nil {
+		tlsCfg.Certificates = []tls.Certificate{*cfg.Cert}
+	}
+	if cfg.RootCAs != nil {
+		tlsCfg.RootCAs = cfg.RootCAs
+	}
+	if cfg.ServerName != "" {
+		tlsCfg.ServerName = cfg.ServerName
+	}
+	return tlsCfg
+}
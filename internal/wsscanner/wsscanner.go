@@ -0,0 +1,413 @@
+// Package wsscanner actively exercises a WebSocket endpoint discovered by
+// the browser layer (session.WebSocketURL) rather than just passively
+// observing traffic, the way scanner.TestGraphQLVulnerabilities exercises a
+// GraphQL endpoint over plain HTTP.
+package wsscanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SpaceLeam/web-Payment-scanner/internal/models"
+	"github.com/SpaceLeam/web-Payment-scanner/internal/utils"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// maxFloodFrames caps the message-flooding DoS probe so the scanner
+	// itself doesn't become a real denial-of-service tool.
+	maxFloodFrames      = 200
+	oversizedFrameBytes = 2 * 1024 * 1024 // 2MB
+	dialTimeout         = 10 * time.Second
+)
+
+// Scanner dials a target WebSocket endpoint and runs active vulnerability
+// probes against it.
+type Scanner struct {
+	URL     string
+	Session *models.Session
+	Logger  *utils.Logger
+
+	// FloodFrames caps how many frames the flood probe sends per second.
+	// Defaults to maxFloodFrames when zero.
+	FloodFrames int
+}
+
+// NewScanner creates a new WebSocket scanner for the given URL and session.
+func NewScanner(wsURL string, session *models.Session) *Scanner {
+	return &Scanner{
+		URL:     wsURL,
+		Session: session,
+		Logger:  utils.NewLogger(true),
+	}
+}
+
+// Run dials the endpoint with valid credentials and executes all probes,
+// aggregating their findings.
+func (s *Scanner) Run() []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	if s.URL == "" {
+		return vulns
+	}
+
+	vulns = append(vulns, s.testUnauthenticatedConnect()...)
+	vulns = append(vulns, s.testOriginSpoof()...)
+	vulns = append(vulns, s.testMessageFlooding()...)
+	vulns = append(vulns, s.testOversizedFrame()...)
+	vulns = append(vulns, s.testParameterTampering()...)
+
+	return vulns
+}
+
+// dial connects to the target, attaching auth via query string token,
+// Sec-WebSocket-Protocol, and (once connected) a first-frame JSON
+// handshake - whichever the target actually expects.
+func (s *Scanner) dial(header http.Header, withAuth bool) (*websocket.Conn, *http.Response, error) {
+	target := s.URL
+	if withAuth && s.Session != nil && s.Session.SessionToken != "" {
+		target = appendQueryToken(target, s.Session.SessionToken)
+	}
+
+	dialer := websocket.Dialer{HandshakeTimeout: dialTimeout}
+
+	if header == nil {
+		header = http.Header{}
+	}
+	if withAuth && s.Session != nil {
+		for k, v := range s.Session.Cookies {
+			header.Add("Cookie", fmt.Sprintf("%s=%s", k, v))
+		}
+		if s.Session.SessionToken != "" {
+			header.Set("Sec-WebSocket-Protocol", "bearer."+s.Session.SessionToken)
+		}
+	}
+
+	conn, resp, err := dialer.Dial(target, header)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if withAuth && s.Session != nil && s.Session.SessionToken != "" {
+		// Some servers expect the token as a first JSON frame rather than
+		// (or in addition to) query string / subprotocol auth.
+		handshake, _ := json.Marshal(map[string]string{
+			"type":  "auth",
+			"token": s.Session.SessionToken,
+		})
+		_ = conn.WriteMessage(websocket.TextMessage, handshake)
+	}
+
+	return conn, resp, nil
+}
+
+func appendQueryToken(rawURL, token string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// testUnauthenticatedConnect checks whether the socket accepts a connection
+// (and responds to messages) with no cookies/token attached at all.
+func (s *Scanner) testUnauthenticatedConnect() []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	conn, resp, err := s.dial(nil, false)
+	if err != nil {
+		return vulns
+	}
+	defer conn.Close()
+
+	probe, _ := json.Marshal(map[string]string{"type": "ping"})
+	_ = conn.WriteMessage(websocket.TextMessage, probe)
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, reply, err := conn.ReadMessage()
+	if err != nil {
+		// No response to an unauthenticated probe is the expected, safe behavior.
+		return vulns
+	}
+
+	vulns = append(vulns, models.Vulnerability{
+		Type:        "WebSocket Authentication",
+		Severity:    "CRITICAL",
+		Title:       "WebSocket Accepts Unauthenticated Connections",
+		Description: "The WebSocket endpoint completed the handshake and replied to a message without any session cookies or auth token attached.",
+		Endpoint:    s.URL,
+		Proof:       fmt.Sprintf("Handshake status: %s; response frame: %s", handshakeStatus(resp), truncateFrame(string(reply))),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-306",
+		CVSSScore:   9.1,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+		Confidence:  "High",
+		Impact:      "Anyone can open a session on the payment WebSocket channel without authenticating.",
+		Remediation: "Reject the handshake (or close the connection immediately) unless a valid session cookie or token is presented.",
+		References: []string{
+			"https://cwe.mitre.org/data/definitions/306.html",
+		},
+	})
+
+	return vulns
+}
+
+// testOriginSpoof checks for a missing Origin check (CWE-346) by
+// connecting with a third-party Origin header.
+func (s *Scanner) testOriginSpoof() []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	header := http.Header{}
+	header.Set("Origin", "https://evil.example")
+
+	conn, _, err := s.dial(header, true)
+	if err != nil {
+		return vulns
+	}
+	defer conn.Close()
+
+	probe, _ := json.Marshal(map[string]string{"type": "ping"})
+	_ = conn.WriteMessage(websocket.TextMessage, probe)
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, reply, err := conn.ReadMessage()
+	if err != nil {
+		return vulns
+	}
+
+	vulns = append(vulns, models.Vulnerability{
+		Type:        "WebSocket Origin Validation",
+		Severity:    "HIGH",
+		Title:       "WebSocket Handshake Missing Origin Check",
+		Description: "The server completed the WebSocket handshake and responded to messages despite an Origin header pointing at an untrusted third-party site, indicating the server does not validate Origin.",
+		Endpoint:    s.URL,
+		Proof:       fmt.Sprintf("Connected with Origin: https://evil.example, response frame: %s", truncateFrame(string(reply))),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-346",
+		CVSSScore:   7.1,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:L/A:N",
+		Confidence:  "Medium",
+		Impact:      "A malicious web page can open a cross-site WebSocket connection (CSWSH) and ride the victim's session.",
+		Remediation: "Validate the Origin header against an allow-list during the WebSocket handshake and reject mismatches with a 403.",
+		References: []string{
+			"https://cwe.mitre.org/data/definitions/346.html",
+			"https://christian-schneider.net/CrossSiteWebSocketHijacking.html",
+		},
+	})
+
+	return vulns
+}
+
+// testMessageFlooding writes FloodFrames frames, paced at that many per
+// second, and flags the socket if every write succeeds. Server replies are
+// not read, so an error or rate-limit frame on a connection the server
+// keeps open does not stop the finding; only a write failure (e.g. the
+// server closed the connection) does.
+func (s *Scanner) testMessageFlooding() []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	conn, _, err := s.dial(nil, true)
+	if err != nil {
+		return vulns
+	}
+	defer conn.Close()
+
+	limit := s.FloodFrames
+	if limit <= 0 {
+		limit = maxFloodFrames
+	}
+
+	frame, _ := json.Marshal(map[string]string{"type": "ping"})
+	interval := time.Second / time.Duration(limit)
+	sent := 0
+	start := time.Now()
+	for ; sent < limit; sent++ {
+		if sent > 0 {
+			time.Sleep(interval)
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+			break
+		}
+	}
+	duration := time.Since(start)
+
+	if sent == limit {
+		vulns = append(vulns, models.Vulnerability{
+			Type:        "WebSocket DoS",
+			Severity:    "MEDIUM",
+			Title:       "No Rate Limiting on WebSocket Messages",
+			Description: fmt.Sprintf("Sent %d frames at %d/sec in %v without a write failing.", sent, limit, duration),
+			Endpoint:    s.URL,
+			Proof:       fmt.Sprintf("%d frames accepted in %v", sent, duration),
+			Timestamp:   time.Now(),
+			CWE:         "CWE-770",
+			CVSSScore:   5.9,
+			CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
+			Confidence:  "Medium",
+			Impact:      "A single client can flood the socket and degrade service for other users.",
+			Remediation: "Rate-limit inbound WebSocket frames per connection/session and close connections that exceed the limit.",
+		})
+	}
+
+	return vulns
+}
+
+// testOversizedFrame sends a single very large frame to check whether the
+// server enforces a maximum message size.
+func (s *Scanner) testOversizedFrame() []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	conn, _, err := s.dial(nil, true)
+	if err != nil {
+		return vulns
+	}
+	defer conn.Close()
+
+	payload, _ := json.Marshal(map[string]string{
+		"type": "ping",
+		"pad":  strings.Repeat("A", oversizedFrameBytes),
+	})
+
+	writeErr := conn.WriteMessage(websocket.TextMessage, payload)
+	if writeErr != nil {
+		// Send rejected locally or connection reset - treat as handled.
+		return vulns
+	}
+
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, _, readErr := conn.ReadMessage()
+	if readErr != nil {
+		// No reply/connection closed - server likely dropped the oversized frame.
+		return vulns
+	}
+
+	vulns = append(vulns, models.Vulnerability{
+		Type:        "WebSocket Message Size",
+		Severity:    "MEDIUM",
+		Title:       "Oversized WebSocket Frame Accepted",
+		Description: fmt.Sprintf("Server accepted and responded to a %d byte frame without rejecting it or closing the connection.", len(payload)),
+		Endpoint:    s.URL,
+		Proof:       fmt.Sprintf("Sent %d byte frame, connection remained open", len(payload)),
+		Timestamp:   time.Now(),
+		CWE:         "CWE-770",
+		CVSSScore:   5.3,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L",
+		Confidence:  "Low",
+		Remediation: "Enforce a maximum message size (e.g. gorilla/websocket's SetReadLimit) and close connections that exceed it.",
+	})
+
+	return vulns
+}
+
+// testParameterTampering replays each message in observed with its
+// numeric/ID fields mutated, to catch JSON-RPC-style parameter tampering
+// (e.g. amount or recipient), and stops at the first one the server
+// accepts. Run calls it with no observed messages, so within Run this
+// probe sends nothing.
+func (s *Scanner) testParameterTampering(observed ...map[string]interface{}) []models.Vulnerability {
+	vulns := []models.Vulnerability{}
+
+	if len(observed) == 0 {
+		return vulns
+	}
+
+	conn, _, err := s.dial(nil, true)
+	if err != nil {
+		return vulns
+	}
+	defer conn.Close()
+
+	for _, msg := range observed {
+		tampered := tamperParameters(msg)
+		frame, err := json.Marshal(tampered)
+		if err != nil {
+			continue
+		}
+
+		if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+			continue
+		}
+
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		_, reply, err := conn.ReadMessage()
+		if err != nil {
+			continue
+		}
+
+		if isAcceptedReply(reply) {
+			vulns = append(vulns, models.Vulnerability{
+				Type:        "WebSocket Parameter Tampering",
+				Severity:    "CRITICAL",
+				Title:       "WebSocket Accepts Tampered Message Parameters",
+				Description: "Replaying an observed WebSocket message with numeric/ID fields mutated (e.g. amount, recipient, quantity) was accepted by the server.",
+				Endpoint:    s.URL,
+				Proof:       fmt.Sprintf("Sent: %s\nReceived: %s", truncateFrame(string(frame)), truncateFrame(string(reply))),
+				Timestamp:   time.Now(),
+				CWE:         "CWE-20",
+				CVSSScore:   9.1,
+				CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N",
+				Confidence:  "Medium",
+				Impact:      "An attacker can manipulate payment parameters (amount, recipient) sent over the WebSocket channel.",
+				Remediation: "Re-validate all message fields server-side against the authenticated session's authoritative state; never trust client-supplied amounts/IDs.",
+			})
+			break
+		}
+	}
+
+	return vulns
+}
+
+func tamperParameters(msg map[string]interface{}) map[string]interface{} {
+	tampered := make(map[string]interface{}, len(msg))
+	for k, v := range msg {
+		switch val := v.(type) {
+		case float64:
+			tampered[k] = val + 1000000
+		case string:
+			lower := strings.ToLower(k)
+			if strings.Contains(lower, "id") {
+				tampered[k] = val + "1"
+			} else {
+				tampered[k] = val
+			}
+		default:
+			tampered[k] = v
+		}
+	}
+	return tampered
+}
+
+func isAcceptedReply(reply []byte) bool {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(reply, &parsed); err != nil {
+		return false
+	}
+	for _, key := range []string{"error", "Error", "status"} {
+		if v, ok := parsed[key]; ok {
+			if s, ok := v.(string); ok && strings.Contains(strings.ToLower(s), "error") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func handshakeStatus(resp *http.Response) string {
+	if resp == nil {
+		return "unknown"
+	}
+	return resp.Status
+}
+
+func truncateFrame(s string) string {
+	const max = 200
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}